@@ -0,0 +1,197 @@
+// Package analysis 对编译后的过滤器做静态分析，找出永远不会命中、被覆盖以及互相冲突的过滤器。
+//
+// 分析基于内置操作(=、!=、>、>=、<、<=、between、in、nin、vgt、vgte、vlt、vlte)的数字、集合以及版本约束，
+// 其他操作不做约束，无法分析的条件都认为可能命中，因此只会报告一定存在的问题。
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/airunny/filter/condition"
+	"github.com/airunny/filter/executor"
+)
+
+type Kind string
+
+const (
+	// KindUnreachable 条件互相矛盾，过滤器永远不会命中
+	KindUnreachable Kind = "unreachable"
+	// KindShadowed 非批量模式下被更高优先级的无条件过滤器覆盖
+	KindShadowed Kind = "shadowed"
+	// KindDuplicateId 过滤器id重复
+	KindDuplicateId Kind = "duplicate_id"
+	// KindConflictingAssignment 批量模式下多个过滤器同时命中时给同一个key赋值
+	KindConflictingAssignment Kind = "conflicting_assignment"
+)
+
+type Filter struct {
+	Id        string
+	Weight    int64
+	Priority  int64
+	Condition condition.Condition
+	Executor  executor.Executor
+}
+
+type Issue struct {
+	Kind      Kind
+	FilterIds []string
+	Message   string
+}
+
+func (s Issue) String() string {
+	return fmt.Sprintf("%s [%s]: %s", s.Kind, strings.Join(s.FilterIds, ","), s.Message)
+}
+
+// Analyze 按照配置顺序分析过滤器
+func Analyze(filters []Filter, batch bool) []Issue {
+	var (
+		issues      []Issue
+		unreachable = make(map[int]bool, len(filters))
+	)
+
+	issues = append(issues, duplicateIds(filters)...)
+
+	for index, filter := range filters {
+		if filter.Condition != nil && !Satisfiable(filter.Condition) {
+			unreachable[index] = true
+			issues = append(issues, Issue{
+				Kind:      KindUnreachable,
+				FilterIds: []string{filter.Id},
+				Message:   "conditions are contradictory, filter can never match",
+			})
+		}
+	}
+
+	if batch {
+		issues = append(issues, conflictingAssignments(filters, unreachable)...)
+	} else {
+		issues = append(issues, shadowed(filters, unreachable)...)
+	}
+	return issues
+}
+
+func duplicateIds(filters []Filter) []Issue {
+	var (
+		issues []Issue
+		counts = make(map[string]int, len(filters))
+		order  []string
+	)
+
+	for _, filter := range filters {
+		if counts[filter.Id] == 0 {
+			order = append(order, filter.Id)
+		}
+		counts[filter.Id]++
+	}
+
+	for _, id := range order {
+		if count := counts[id]; count > 1 {
+			issues = append(issues, Issue{
+				Kind:      KindDuplicateId,
+				FilterIds: []string{id},
+				Message:   fmt.Sprintf("filter id is used by %d filters", count),
+			})
+		}
+	}
+	return issues
+}
+
+// shadowed 非批量模式下按照优先级从小到大执行，第一个命中之后停止，
+// 所以无条件的过滤器会覆盖所有优先级更低(priority 更大)的过滤器
+func shadowed(filters []Filter, unreachable map[int]bool) []Issue {
+	var issues []Issue
+	for index, filter := range filters {
+		if unreachable[index] {
+			continue
+		}
+
+		for other, shadow := range filters {
+			if other == index || shadow.Priority >= filter.Priority {
+				continue
+			}
+
+			if shadow.Condition == nil || Tautology(shadow.Condition) {
+				issues = append(issues, Issue{
+					Kind:      KindShadowed,
+					FilterIds: []string{filter.Id, shadow.Id},
+					Message:   fmt.Sprintf("filter is shadowed by unconditional filter [%s] with higher priority", shadow.Id),
+				})
+				break
+			}
+		}
+	}
+	return issues
+}
+
+// conflictingAssignments 批量模式下可能同时命中的过滤器给同一个key赋值
+func conflictingAssignments(filters []Filter, unreachable map[int]bool) []Issue {
+	var (
+		issues []Issue
+		keys   = make([][]string, len(filters))
+	)
+
+	for index, filter := range filters {
+		keys[index] = assignedKeys(filter.Executor)
+	}
+
+	for i := 0; i < len(filters); i++ {
+		if unreachable[i] {
+			continue
+		}
+
+		for j := i + 1; j < len(filters); j++ {
+			if unreachable[j] {
+				continue
+			}
+
+			common := intersectKeys(keys[i], keys[j])
+			if len(common) == 0 {
+				continue
+			}
+
+			if filters[i].Condition != nil && filters[j].Condition != nil && !Overlap(filters[i].Condition, filters[j].Condition) {
+				continue
+			}
+
+			issues = append(issues, Issue{
+				Kind:      KindConflictingAssignment,
+				FilterIds: []string{filters[i].Id, filters[j].Id},
+				Message:   fmt.Sprintf("both filters assign [%s]", strings.Join(common, ",")),
+			})
+		}
+	}
+	return issues
+}
+
+func assignedKeys(exec executor.Executor) []string {
+	switch e := exec.(type) {
+	case *executor.BaseExecutor:
+		return []string{e.Key()}
+	case *executor.Group:
+		var keys []string
+		for _, child := range e.Executors() {
+			keys = append(keys, assignedKeys(child)...)
+		}
+		return keys
+	}
+	return nil
+}
+
+func intersectKeys(left, right []string) []string {
+	set := make(map[string]struct{}, len(left))
+	for _, key := range left {
+		set[key] = struct{}{}
+	}
+
+	var common []string
+	for _, key := range right {
+		if _, ok := set[key]; ok {
+			common = append(common, key)
+			delete(set, key)
+		}
+	}
+	sort.Strings(common)
+	return common
+}
@@ -0,0 +1,209 @@
+package analysis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/airunny/filter/condition"
+	"github.com/airunny/filter/executor"
+	"github.com/stretchr/testify/assert"
+)
+
+func buildCondition(t *testing.T, items ...interface{}) condition.Condition {
+	cond, err := condition.BuildCondition(context.Background(), items, condition.LogicAnd)
+	assert.Nil(t, err)
+	return cond
+}
+
+func buildExecutor(t *testing.T, items ...interface{}) executor.Executor {
+	exec, err := executor.BuildExecutor(context.Background(), items)
+	assert.Nil(t, err)
+	return exec
+}
+
+func TestSatisfiable(t *testing.T) {
+	cases := []struct {
+		items []interface{}
+		want  bool
+	}{
+		{items: []interface{}{[]interface{}{"hour", ">", 20.0}, []interface{}{"hour", "<", 5.0}}, want: false},
+		{items: []interface{}{[]interface{}{"hour", ">", 5.0}, []interface{}{"hour", "<", 20.0}}, want: true},
+		{items: []interface{}{[]interface{}{"hour", ">", 22.0}, []interface{}{"hour", "<", 24.0}}, want: true},
+		{items: []interface{}{[]interface{}{"hour", ">", 23.0}}, want: false},
+		{items: []interface{}{[]interface{}{"hour", ">", 5.0}, []interface{}{"hour", "<", 6.0}}, want: false},
+		{items: []interface{}{[]interface{}{"data.score", ">", 5.0}, []interface{}{"data.score", "<", 6.0}}, want: true},
+		{items: []interface{}{[]interface{}{"city", "in", "北京,上海"}, []interface{}{"city", "in", "广州,深圳"}}, want: false},
+		{items: []interface{}{[]interface{}{"city", "in", "北京,上海"}, []interface{}{"city", "in", "上海,深圳"}}, want: true},
+		{items: []interface{}{[]interface{}{"city", "in", "北京,上海"}, []interface{}{"city", "nin", "北京,上海"}}, want: false},
+		{items: []interface{}{[]interface{}{"city", "=", "北京"}, []interface{}{"city", "nin", "北京"}}, want: false},
+		{items: []interface{}{[]interface{}{"uid", "=", 1.0}, []interface{}{"uid", "between", "2,10"}}, want: true},
+		{items: []interface{}{[]interface{}{"uid", "=", 1.0}, []interface{}{"uid", "between", []interface{}{2.0, 10.0}}}, want: false},
+		{items: []interface{}{[]interface{}{"version", "vgt", "1.2.0"}, []interface{}{"version", "vlt", "1.1"}}, want: false},
+		{items: []interface{}{[]interface{}{"version", "vgte", "1.2.0"}, []interface{}{"version", "vlte", "1.2.0"}}, want: true},
+		{items: []interface{}{[]interface{}{"version", "vgt", "1.2.0"}, []interface{}{"version", "in", "1.0,1.1"}}, want: false},
+		{items: []interface{}{[]interface{}{"rand", ">", 50.0}, []interface{}{"rand", "<", 20.0}}, want: true},
+		{items: []interface{}{[]interface{}{"ua", "~", "/chrome/"}, []interface{}{"ua", "!~", "/chrome/"}}, want: true},
+		{items: []interface{}{[]interface{}{"or", "=>", []interface{}{
+			[]interface{}{"hour", ">", 30.0},
+			[]interface{}{"hour", "<", 0.0},
+		}}}, want: false},
+		{items: []interface{}{[]interface{}{"not", "=>", []interface{}{
+			[]interface{}{"hour", ">=", 0.0},
+		}}}, want: false},
+		{items: []interface{}{[]interface{}{"not", "=>", []interface{}{
+			[]interface{}{"hour", "between", []interface{}{0.0, 23.0}},
+		}}}, want: false},
+		{items: []interface{}{[]interface{}{"not", "=>", []interface{}{
+			[]interface{}{"hour", "between", []interface{}{0.0, 22.0}},
+		}}}, want: true},
+		{items: []interface{}{[]interface{}{"nand", "=>", []interface{}{
+			[]interface{}{"hour", ">=", 0.0},
+			[]interface{}{"hour", "<=", 23.0},
+		}}}, want: false},
+		{items: []interface{}{[]interface{}{"nand", "=>", []interface{}{
+			[]interface{}{"hour", ">", 5.0},
+			[]interface{}{"hour", "<", 20.0},
+		}}}, want: true},
+		// !op 等价于对 op 取反
+		{items: []interface{}{
+			[]interface{}{"hour", "!between", []interface{}{0.0, 23.0}},
+		}, want: false},
+		{items: []interface{}{
+			[]interface{}{"hour", "!>", 10.0},
+			[]interface{}{"hour", ">", 10.0},
+		}, want: false},
+		{items: []interface{}{
+			[]interface{}{"hour", "!between", []interface{}{5.0, 10.0}},
+			[]interface{}{"hour", ">", 8.0},
+		}, want: true},
+		// 按数量判断的分组不做约束
+		{items: []interface{}{[]interface{}{"atleast:2", "=>", []interface{}{
+			[]interface{}{"hour", ">", 30.0},
+			[]interface{}{"hour", "<", 0.0},
+		}}}, want: true},
+	}
+
+	for index, c := range cases {
+		assert.Equal(t, c.want, Satisfiable(buildCondition(t, c.items...)), index)
+	}
+}
+
+func TestTautology(t *testing.T) {
+	assert.True(t, Tautology(buildCondition(t, "hour", ">=", 0.0)))
+	assert.True(t, Tautology(buildCondition(t, []interface{}{"or", "=>", []interface{}{
+		[]interface{}{"city", "=", "北京"},
+		[]interface{}{"city", "nin", "北京"},
+	}})))
+	assert.False(t, Tautology(buildCondition(t, "hour", ">", 0.0)))
+	assert.False(t, Tautology(buildCondition(t, "ua", "~", "/chrome/")))
+}
+
+func TestAnalyze(t *testing.T) {
+	always := buildCondition(t, "hour", ">=", 0.0)
+	night := buildCondition(t, []interface{}{"hour", ">", 20.0}, []interface{}{"hour", "<", 5.0})
+	beijing := buildCondition(t, "city", "=", "北京")
+	shanghai := buildCondition(t, "city", "=", "上海")
+	setA := buildExecutor(t, "a", "=", 1.0)
+	setAB := buildExecutor(t, []interface{}{"a", "=", 2.0}, []interface{}{"b", "=", 2.0})
+
+	filters := []Filter{
+		{Id: "1", Priority: 1, Condition: beijing, Executor: setA},
+		{Id: "2", Priority: 2, Condition: night, Executor: setA},
+		{Id: "3", Priority: 3, Condition: always, Executor: setAB},
+		{Id: "4", Priority: 4, Condition: shanghai, Executor: setA},
+		{Id: "1", Priority: 5, Condition: shanghai, Executor: setAB},
+	}
+
+	issues := Analyze(filters, false)
+	assert.Equal(t, []Issue{
+		{Kind: KindDuplicateId, FilterIds: []string{"1"}, Message: "filter id is used by 2 filters"},
+		{Kind: KindUnreachable, FilterIds: []string{"2"}, Message: "conditions are contradictory, filter can never match"},
+		{Kind: KindShadowed, FilterIds: []string{"4", "3"}, Message: "filter is shadowed by unconditional filter [3] with higher priority"},
+		{Kind: KindShadowed, FilterIds: []string{"1", "3"}, Message: "filter is shadowed by unconditional filter [3] with higher priority"},
+	}, issues)
+
+	issues = Analyze(filters, true)
+	assert.Equal(t, []Issue{
+		{Kind: KindDuplicateId, FilterIds: []string{"1"}, Message: "filter id is used by 2 filters"},
+		{Kind: KindUnreachable, FilterIds: []string{"2"}, Message: "conditions are contradictory, filter can never match"},
+		{Kind: KindConflictingAssignment, FilterIds: []string{"1", "3"}, Message: "both filters assign [a]"},
+		{Kind: KindConflictingAssignment, FilterIds: []string{"3", "4"}, Message: "both filters assign [a]"},
+		{Kind: KindConflictingAssignment, FilterIds: []string{"3", "1"}, Message: "both filters assign [a,b]"},
+		{Kind: KindConflictingAssignment, FilterIds: []string{"4", "1"}, Message: "both filters assign [a]"},
+	}, issues)
+	assert.Equal(t, "duplicate_id [1]: filter id is used by 2 filters", issues[0].String())
+}
+
+// 带有缺失策略的条件在变量不存在时也可能成立
+func TestAnalyzeMissing(t *testing.T) {
+	cases := []struct {
+		items       []interface{}
+		satisfiable bool
+		tautology   bool
+	}{
+		// 变量不存在时两个条件都成立
+		{items: []interface{}{
+			[]interface{}{"data.a?true", ">", 5.0},
+			[]interface{}{"data.a?true", "<", 3.0},
+		}, satisfiable: true},
+		{items: []interface{}{
+			[]interface{}{"data.a?true", ">", 5.0},
+			[]interface{}{"not", "=>", []interface{}{
+				[]interface{}{"data.a?false", ">", 5.0},
+			}},
+		}, satisfiable: true},
+		// 没有缺失策略的条件要求变量存在
+		{items: []interface{}{
+			[]interface{}{"data.a?true", ">", 5.0},
+			[]interface{}{"data.a", "<", 3.0},
+		}, satisfiable: false},
+		// ?false 在变量不存在时不成立
+		{items: []interface{}{
+			[]interface{}{"data.a?false", ">", 5.0},
+			[]interface{}{"data.a?false", "<", 3.0},
+		}, satisfiable: false},
+		// ? 的结果未知，条件以及条件的否定都不成立
+		{items: []interface{}{
+			[]interface{}{"data.a?", ">", 5.0},
+			[]interface{}{"data.a?", "<", 3.0},
+		}, satisfiable: false},
+		// ?=默认值 按照默认值判断
+		{items: []interface{}{
+			[]interface{}{"data.a?=10", ">", 5.0},
+			[]interface{}{"data.a?=10", "<", 3.0},
+		}, satisfiable: false},
+		{items: []interface{}{
+			[]interface{}{"data.a?=4", ">", 3.0},
+			[]interface{}{"data.a?=4", "<", 5.0},
+		}, satisfiable: true},
+		// 变量不存在时两个条件都不成立，不是恒成立的条件
+		{items: []interface{}{[]interface{}{"or", "=>", []interface{}{
+			[]interface{}{"data.a?false", ">=", 0.0},
+			[]interface{}{"data.a?false", "<", 0.0},
+		}}}, satisfiable: true},
+		{items: []interface{}{[]interface{}{"or", "=>", []interface{}{
+			[]interface{}{"data.a?true", ">=", 0.0},
+			[]interface{}{"data.a?true", "<", 0.0},
+		}}}, satisfiable: true, tautology: true},
+	}
+
+	for index, c := range cases {
+		cond := buildCondition(t, c.items...)
+		assert.Equal(t, c.satisfiable, Satisfiable(cond), index)
+		assert.Equal(t, c.tautology, Tautology(cond), index)
+	}
+
+	// 变量不存在时前面的filter不成立，不会覆盖后面的filter
+	setA := buildExecutor(t, "a", "=", 1.0)
+	issues := Analyze([]Filter{
+		{Id: "1", Priority: 1, Condition: buildCondition(t, []interface{}{"or", "=>", []interface{}{
+			[]interface{}{"data.a?false", ">=", 0.0},
+			[]interface{}{"data.a?false", "<", 0.0},
+		}}), Executor: setA},
+		{Id: "2", Priority: 2, Condition: buildCondition(t,
+			[]interface{}{"data.b?true", ">", 5.0},
+			[]interface{}{"data.b?true", "<", 3.0},
+		), Executor: setA},
+	}, false)
+	assert.Empty(t, issues)
+}
@@ -0,0 +1,289 @@
+package analysis
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/airunny/filter/types"
+	"github.com/airunny/filter/utils"
+)
+
+// domain 单个变量所有可能取值的集合，所有约束之间为且的关系
+type domain struct {
+	// number 数字区间，所有跟数字的比较最终都会转化为float64比较
+	min, max         float64
+	minOpen, maxOpen bool
+	integer          bool
+	points           map[float64]struct{} // nil 表示不限制
+	notPoints        map[float64]struct{}
+
+	// string
+	strs    map[string]struct{} // nil 表示不限制
+	notStrs map[string]struct{}
+
+	// version
+	vmin, vmax         string
+	vminOpen, vmaxOpen bool
+
+	// 变量不存在以及其它要求变量存在的约束不能同时满足
+	absent, present bool
+}
+
+func newDomain() *domain {
+	return &domain{
+		min: math.Inf(-1),
+		max: math.Inf(1),
+	}
+}
+
+func (s *domain) clone() *domain {
+	d := *s
+	d.points = cloneSet(s.points)
+	d.notPoints = cloneSet(s.notPoints)
+	d.strs = cloneSet(s.strs)
+	d.notStrs = cloneSet(s.notStrs)
+	return &d
+}
+
+func cloneSet[K comparable](set map[K]struct{}) map[K]struct{} {
+	if set == nil {
+		return nil
+	}
+
+	c := make(map[K]struct{}, len(set))
+	for k := range set {
+		c[k] = struct{}{}
+	}
+	return c
+}
+
+func intersect[K comparable](set map[K]struct{}, values []K) map[K]struct{} {
+	result := make(map[K]struct{}, len(values))
+	for _, value := range values {
+		if set == nil {
+			result[value] = struct{}{}
+			continue
+		}
+
+		if _, ok := set[value]; ok {
+			result[value] = struct{}{}
+		}
+	}
+	return result
+}
+
+func addAll[K comparable](set map[K]struct{}, values []K) map[K]struct{} {
+	if set == nil {
+		set = make(map[K]struct{}, len(values))
+	}
+
+	for _, value := range values {
+		set[value] = struct{}{}
+	}
+	return set
+}
+
+func (s *domain) hasNumberRange() bool {
+	return !math.IsInf(s.min, -1) || !math.IsInf(s.max, 1)
+}
+
+func (s *domain) hasVersionRange() bool {
+	return s.vmin != "" || s.vmax != ""
+}
+
+func (s *domain) lower(value float64, open bool) {
+	if value > s.min || (value == s.min && open) {
+		s.min, s.minOpen = value, open
+	}
+}
+
+func (s *domain) upper(value float64, open bool) {
+	if value < s.max || (value == s.max && open) {
+		s.max, s.maxOpen = value, open
+	}
+}
+
+func (s *domain) versionLower(version string, open bool) {
+	if s.vmin == "" {
+		s.vmin, s.vminOpen = version, open
+		return
+	}
+
+	compare := utils.VersionCompare(version, s.vmin)
+	if compare > 0 || (compare == 0 && open) {
+		s.vmin, s.vminOpen = version, open
+	}
+}
+
+func (s *domain) versionUpper(version string, open bool) {
+	if s.vmax == "" {
+		s.vmax, s.vmaxOpen = version, open
+		return
+	}
+
+	compare := utils.VersionCompare(version, s.vmax)
+	if compare < 0 || (compare == 0 && open) {
+		s.vmax, s.vmaxOpen = version, open
+	}
+}
+
+func (s *domain) inNumberRange(value float64) bool {
+	if value < s.min || (value == s.min && s.minOpen) {
+		return false
+	}
+
+	if value > s.max || (value == s.max && s.maxOpen) {
+		return false
+	}
+
+	if s.integer && value != math.Trunc(value) {
+		return false
+	}
+
+	_, excluded := s.notPoints[value]
+	return !excluded
+}
+
+func (s *domain) inVersionRange(version string) bool {
+	if s.vmin != "" {
+		compare := utils.VersionCompare(version, s.vmin)
+		if compare < 0 || (compare == 0 && s.vminOpen) {
+			return false
+		}
+	}
+
+	if s.vmax != "" {
+		compare := utils.VersionCompare(version, s.vmax)
+		if compare > 0 || (compare == 0 && s.vmaxOpen) {
+			return false
+		}
+	}
+	return true
+}
+
+// acceptString 字符串取值跟数字比较时按照float64比较，跟 utils.ObjectCompare 保持一致
+func (s *domain) acceptString(value string) bool {
+	if _, excluded := s.notStrs[value]; excluded {
+		return false
+	}
+
+	number := types.GetFloat(value)
+	if s.points != nil {
+		if _, ok := s.points[number]; !ok {
+			return false
+		}
+	}
+
+	if (s.hasNumberRange() || len(s.notPoints) > 0) && !s.inNumberRange(number) {
+		return false
+	}
+	return s.inVersionRange(value)
+}
+
+// numbers 满足约束的候选数字，nil 表示区间内的任意数字
+func (s *domain) numbers() []float64 {
+	if s.points == nil {
+		return nil
+	}
+
+	values := make([]float64, 0, len(s.points))
+	for point := range s.points {
+		if s.inNumberRange(point) && s.inVersionRange(formatNumber(point)) {
+			values = append(values, point)
+		}
+	}
+	return values
+}
+
+// strings 满足约束的候选字符串，nil 表示不限制
+func (s *domain) strings() []string {
+	if s.strs == nil {
+		return nil
+	}
+
+	values := make([]string, 0, len(s.strs))
+	for value := range s.strs {
+		if s.acceptString(value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// pickNumber 从区间中选择一个满足约束的数字
+func (s *domain) pickNumber() (float64, bool) {
+	if s.points != nil {
+		values := s.numbers()
+		if len(values) == 0 {
+			return 0, false
+		}
+
+		best := values[0]
+		for _, value := range values[1:] {
+			if value < best {
+				best = value
+			}
+		}
+		return best, true
+	}
+
+	candidates := make([]float64, 0, 8)
+	switch {
+	case !math.IsInf(s.min, -1) && !math.IsInf(s.max, 1):
+		candidates = append(candidates, s.min, math.Ceil(s.min), math.Floor(s.min)+1, (s.min+s.max)/2, math.Floor((s.min+s.max)/2), s.max, math.Floor(s.max))
+	case !math.IsInf(s.min, -1):
+		candidates = append(candidates, s.min, math.Ceil(s.min), math.Floor(s.min)+1, s.min+1)
+	case !math.IsInf(s.max, 1):
+		candidates = append(candidates, s.max, math.Floor(s.max), math.Ceil(s.max)-1, s.max-1)
+	default:
+		candidates = append(candidates, 0, 1)
+	}
+
+	for _, candidate := range candidates {
+		for step := 0; step < len(s.notPoints)+1; step++ {
+			value := candidate + float64(step)
+			if s.inNumberRange(value) {
+				return value, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (s *domain) versionEmpty() bool {
+	if s.vmin == "" || s.vmax == "" {
+		return false
+	}
+
+	compare := utils.VersionCompare(s.vmin, s.vmax)
+	return compare > 0 || (compare == 0 && (s.vminOpen || s.vmaxOpen))
+}
+
+// empty 约束之间互相矛盾时不存在任何满足条件的取值
+func (s *domain) empty() bool {
+	if s.absent && s.present {
+		return true
+	}
+
+	if s.versionEmpty() {
+		return true
+	}
+
+	if s.strs != nil {
+		return len(s.strings()) == 0
+	}
+
+	if s.points != nil {
+		return len(s.numbers()) == 0
+	}
+
+	if s.hasNumberRange() || s.integer {
+		_, ok := s.pickNumber()
+		return !ok
+	}
+	return false
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
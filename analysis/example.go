@@ -0,0 +1,594 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/condition"
+	filterContext "github.com/airunny/filter/context"
+	"github.com/airunny/filter/types"
+	"github.com/airunny/filter/utils"
+	"github.com/airunny/filter/variables"
+	"github.com/airunny/filter/variables/area"
+	"github.com/airunny/filter/variables/calc"
+	ctxVariable "github.com/airunny/filter/variables/ctx"
+	"github.com/airunny/filter/variables/data"
+	"github.com/airunny/filter/variables/freq"
+	isLogin "github.com/airunny/filter/variables/is_login"
+	randVariable "github.com/airunny/filter/variables/rand"
+	"github.com/airunny/filter/variables/success"
+	timeVariable "github.com/airunny/filter/variables/time"
+	"github.com/airunny/filter/variables/uid"
+)
+
+// maxReasons 无法生成示例时最多返回的原因个数
+const maxReasons = 5
+
+// defaultLoginUid 条件要求登录但没有限制uid时使用的uid
+const defaultLoginUid = "10001"
+
+var ErrTooComplex = errors.New("condition is too complex to generate example")
+
+// UnsatisfiableError 条件无法满足时返回的错误，Reasons 为每一种组合无法满足的原因
+type UnsatisfiableError struct {
+	Reasons []string
+}
+
+func (e *UnsatisfiableError) Error() string {
+	return "condition can never be satisfied: " + strings.Join(e.Reasons, "; ")
+}
+
+// Example 一个可以命中条件的请求示例
+type Example struct {
+	// Data 请求数据，data.a.b 会生成嵌套的对象
+	Data map[string]interface{} `json:"data"`
+	// Values 上下文中的取值，例如 ip、version、platform、uid 以及 ctx.xxx
+	Values map[string]interface{} `json:"values"`
+	// Now 固定的当前时间
+	Now time.Time `json:"now"`
+	// Verified 示例是否已经实际执行条件验证命中
+	Verified bool `json:"verified"`
+	// Notes 无法直接构造的变量说明，例如根据ip解析的城市、频率以及随机数
+	Notes []string `json:"notes,omitempty"`
+}
+
+// Context 将示例中上下文的取值以及固定时间写入ctx
+func (s *Example) Context(ctx context.Context) context.Context {
+	for name, value := range s.Values {
+		if setter, ok := contextSetters[name]; ok {
+			ctx = setter(ctx, value)
+			continue
+		}
+
+		if key := strings.TrimPrefix(name, ctxVariable.Name); key != name {
+			ctx = context.WithValue(ctx, key, value)
+		}
+	}
+
+	if !s.Now.IsZero() {
+		ctx = filterContext.WithNow(ctx, s.Now)
+	}
+	return ctx
+}
+
+var contextSetters = map[string]func(context.Context, interface{}) context.Context{
+	"ip":       filterContext.WithIP,
+	"version":  filterContext.WithVersion,
+	"platform": filterContext.WithPlatform,
+	uid.Name:   filterContext.WithUserID,
+	"device":   filterContext.WithDevice,
+	"channel":  filterContext.WithChannel,
+	"ua":       filterContext.WithUA,
+	"referer":  filterContext.WithReferer,
+	"user_tag": filterContext.WithUserTag,
+}
+
+var timeVariables = map[string]struct{}{
+	timeVariable.TimestampName: {},
+	timeVariable.TsSimpleName:  {},
+	timeVariable.SecondName:    {},
+	timeVariable.MinuteName:    {},
+	timeVariable.HourName:      {},
+	timeVariable.DayName:       {},
+	timeVariable.MonthName:     {},
+	timeVariable.YearName:      {},
+	timeVariable.WdayName:      {},
+	timeVariable.DateName:      {},
+	timeVariable.TimeName:      {},
+}
+
+// uncontrollable 无法直接构造的变量，返回对应的说明
+func uncontrollable(name string) (string, bool) {
+	switch {
+	case name == area.CountryName || name == area.ProvinceName || name == area.CityName:
+		return fmt.Sprintf("[%s] is resolved from ip and can't be set directly", name), true
+	case strings.HasPrefix(name, freq.Name):
+		return fmt.Sprintf("[%s] depends on the frequency service in context", name), true
+	case strings.HasPrefix(name, calc.Name):
+		return fmt.Sprintf("[%s] depends on the calculator in context", name), true
+	case name == randVariable.Name:
+		return fmt.Sprintf("[%s] is random, the example only matches part of the time", name), true
+	case name == success.Name || name == isLogin.Name:
+		return "", false
+	case strings.HasPrefix(name, data.Name) || strings.HasPrefix(name, ctxVariable.Name):
+		return "", false
+	}
+
+	if _, ok := contextSetters[name]; ok {
+		return "", false
+	}
+
+	if _, ok := timeVariables[name]; ok {
+		return "", false
+	}
+	return fmt.Sprintf("[%s] can't be generated", name), true
+}
+
+// GenerateExample 根据条件树生成一个可以命中的请求示例，条件无法满足时返回 *UnsatisfiableError。
+// 依赖ip解析、频率、计算以及随机数的变量无法直接构造，生成的示例不保证命中，Verified 为false并且在 Notes 中说明
+func GenerateExample(ctx context.Context, cond condition.Condition) (*Example, error) {
+	s := &solver{}
+	terms, ok := s.expand(cond, false)
+	if !ok {
+		return nil, ErrTooComplex
+	}
+
+	var (
+		reasons    []string
+		unverified *Example
+	)
+
+	for _, t := range terms {
+		if name, conflict := s.conflict(t); conflict {
+			reasons = appendReason(reasons, explain(t, name, "contradictory constraints"))
+			continue
+		}
+
+		example, reason := generate(ctx, t)
+		if example == nil {
+			reasons = appendReason(reasons, reason)
+			continue
+		}
+
+		example.Verified, _ = cond.IsConditionOk(example.Context(ctx), example.Data, cache.NewCache())
+		if example.Verified {
+			return example, nil
+		}
+
+		if len(example.Notes) > 0 {
+			if unverified == nil {
+				unverified = example
+			}
+			continue
+		}
+		reasons = appendReason(reasons, "generated example doesn't match: "+describe(t))
+	}
+
+	if unverified != nil {
+		return unverified, nil
+	}
+	return nil, &UnsatisfiableError{Reasons: reasons}
+}
+
+func appendReason(reasons []string, reason string) []string {
+	if len(reasons) >= maxReasons {
+		return reasons
+	}
+
+	for _, r := range reasons {
+		if r == reason {
+			return reasons
+		}
+	}
+	return append(reasons, reason)
+}
+
+func explain(t term, name, msg string) string {
+	var conditions []string
+	for _, a := range t {
+		if a.variable == name {
+			conditions = append(conditions, describeAtom(a))
+		}
+	}
+	return fmt.Sprintf("%s for [%s]: %s", msg, baseName(name), strings.Join(conditions, " and "))
+}
+
+func describe(t term) string {
+	conditions := make([]string, 0, len(t))
+	for _, a := range t {
+		conditions = append(conditions, describeAtom(a))
+	}
+	return strings.Join(conditions, " and ")
+}
+
+func describeAtom(a atom) string {
+	if a.absent {
+		return baseName(a.variable) + " is missing"
+	}
+
+	str := fmt.Sprintf("%s %s %s", baseName(a.variable), a.op.Name(), formatValue(a.value))
+	if a.negate {
+		return "not(" + str + ")"
+	}
+	return str
+}
+
+func baseName(name string) string {
+	if index := strings.LastIndexByte(name, '#'); index > 0 {
+		return name[:index]
+	}
+	return name
+}
+
+// generate 为合取项中的每一个变量选择满足所有条件的取值
+func generate(ctx context.Context, t term) (*Example, string) {
+	var (
+		order   []string
+		atoms   = make(map[string][]atom)
+		domains = (&solver{}).domains(t)
+		example = &Example{
+			Data:   make(map[string]interface{}),
+			Values: make(map[string]interface{}),
+		}
+	)
+
+	for _, a := range t {
+		if _, ok := atoms[a.variable]; !ok {
+			order = append(order, a.variable)
+		}
+		atoms[a.variable] = append(atoms[a.variable], a)
+	}
+
+	var timeAtoms []atom
+	for _, name := range order {
+		base := baseName(name)
+		if note, ok := uncontrollable(base); ok {
+			example.Notes = append(example.Notes, note)
+			continue
+		}
+
+		// 变量不存在时不设置取值，只有data、ctx以及上下文中的变量可以不存在
+		if domains[name].absent {
+			_, setter := contextSetters[base]
+			if !setter && !strings.HasPrefix(base, data.Name) && !strings.HasPrefix(base, ctxVariable.Name) {
+				return nil, explain(t, name, "variable can't be missing")
+			}
+			continue
+		}
+
+		if _, ok := timeVariables[base]; ok {
+			timeAtoms = append(timeAtoms, atoms[name]...)
+			continue
+		}
+
+		if base == success.Name {
+			continue
+		}
+
+		value, ok := choose(ctx, atoms[name], candidates(domains[name], atoms[name]))
+		if !ok {
+			return nil, explain(t, name, "no value found")
+		}
+
+		switch {
+		case base == isLogin.Name:
+			if _, exists := example.Values[uid.Name]; types.GetBool(value) && !exists {
+				example.Values[uid.Name] = defaultLoginUid
+			}
+		case strings.HasPrefix(base, data.Name):
+			if !setPath(example.Data, strings.TrimPrefix(base, data.Name), value) {
+				return nil, explain(t, name, "conflicting data path")
+			}
+		default:
+			example.Values[base] = value
+		}
+	}
+
+	now, ok := chooseTime(ctx, timeAtoms, domains)
+	if !ok {
+		return nil, "no time found: " + describe(timeAtoms)
+	}
+	example.Now = now
+	return example, ""
+}
+
+// probe 用于校验候选值的变量
+type probe struct {
+	name  string
+	value interface{}
+}
+
+func (s *probe) Name() string    { return s.name }
+func (s *probe) Cacheable() bool { return false }
+func (s *probe) Value(context.Context, interface{}, *cache.Cache) (interface{}, error) {
+	return s.value, nil
+}
+
+// check 使用真实的操作校验候选值是否满足原子条件
+func check(ctx context.Context, a atom, variable variables.Variable) bool {
+	ok, err := a.op.Run(ctx, variable, a.value, nil, nil)
+	if err != nil {
+		return false
+	}
+	return ok != a.negate
+}
+
+func choose(ctx context.Context, atoms []atom, values []interface{}) (interface{}, bool) {
+	for _, value := range values {
+		variable := &probe{name: baseName(atoms[0].variable), value: value}
+		matched := true
+		for _, a := range atoms {
+			if !check(ctx, a, variable) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// candidates 根据约束以及比较值生成候选值
+func candidates(d *domain, atoms []atom) []interface{} {
+	var (
+		values  []interface{}
+		seen    = make(map[string]struct{})
+		samples []string
+	)
+
+	add := func(value interface{}) {
+		key := fmt.Sprintf("%T:%v", value, value)
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		values = append(values, value)
+	}
+
+	addNumber := func(number float64) {
+		for _, delta := range []float64{0, 1, -1, 0.5} {
+			add(number + delta)
+		}
+	}
+
+	strs := d.strings()
+	sort.Strings(strs)
+	for _, str := range strs {
+		add(str)
+	}
+
+	numbers := d.numbers()
+	sort.Float64s(numbers)
+	for _, number := range numbers {
+		add(number)
+	}
+
+	if number, ok := d.pickNumber(); ok && d.strs == nil {
+		add(number)
+	}
+
+	for _, a := range atoms {
+		for _, element := range elements(a.value) {
+			switch v := element.(type) {
+			case *regexp.Regexp:
+				if sample, ok := sampleRegexp(v); ok {
+					add(sample)
+					samples = append(samples, sample)
+				}
+			case utils.IPRange:
+				add(utils.IntToIP(v.Start()).String())
+				add(utils.IntToIP(v.End()).String())
+				if v.End() < math.MaxUint32 {
+					add(utils.IntToIP(v.End() + 1).String())
+				}
+				if v.Start() > 0 {
+					add(utils.IntToIP(v.Start() - 1).String())
+				}
+			case string:
+				add(v)
+				samples = append(samples, v)
+				if number, err := strconv.ParseFloat(v, 64); err == nil {
+					addNumber(number)
+				}
+				add(nextVersion(v))
+				if previous, ok := previousVersion(v); ok {
+					add(previous)
+				}
+			default:
+				add(v)
+				if types.GetFilterType(v) == types.NUMBER {
+					addNumber(types.GetFloat(v))
+				}
+			}
+		}
+	}
+
+	// 多个正则或者包含条件同时存在时尝试拼接
+	if len(samples) > 1 {
+		add(strings.Join(samples, ""))
+		add(strings.Join(samples, ","))
+	}
+
+	for _, value := range []interface{}{"a", "", "0", 0.0, 1.0, true, false, "127.0.0.1"} {
+		add(value)
+	}
+	return values
+}
+
+func elements(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case *utils.ValueSet:
+		return v.Values()
+	case []utils.IPRange:
+		result := make([]interface{}, 0, len(v))
+		for _, r := range v {
+			result = append(result, r)
+		}
+		return result
+	case nil:
+		return nil
+	}
+	return []interface{}{value}
+}
+
+// setPath 按照 a.b.c 的路径在对象中设置值
+func setPath(object map[string]interface{}, path string, value interface{}) bool {
+	segments := strings.Split(path, ".")
+	for _, segment := range segments[:len(segments)-1] {
+		child, exists := object[segment]
+		if !exists {
+			next := make(map[string]interface{})
+			object[segment] = next
+			object = next
+			continue
+		}
+
+		next, ok := child.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		object = next
+	}
+
+	last := segments[len(segments)-1]
+	if _, exists := object[last]; exists {
+		return false
+	}
+	object[last] = value
+	return true
+}
+
+// timeLayouts 可以直接确定时间的变量，后面的优先级更高
+var timeLayouts = []struct {
+	name   string
+	layout string
+}{
+	{name: timeVariable.DateName, layout: "2006-01-02"},
+	{name: timeVariable.TimeName, layout: "2006-01-02 15:04:05"},
+	{name: timeVariable.TsSimpleName, layout: "20060102150405"},
+	{name: timeVariable.TimestampName},
+}
+
+// chooseTime 选择满足所有时间条件的固定时间
+func chooseTime(ctx context.Context, atoms []atom, domains map[string]*domain) (time.Time, bool) {
+	byName := make(map[string][]atom)
+	for _, a := range atoms {
+		byName[a.variable] = append(byName[a.variable], a)
+	}
+
+	component := func(name string, fallback int) int {
+		if len(byName[name]) == 0 {
+			return fallback
+		}
+
+		// 时间变量的取值都是整数，只使用数字候选值，并且补充完整的取值范围
+		var numbers []interface{}
+		for _, value := range candidates(domains[name], byName[name]) {
+			if number, ok := integer(value); ok {
+				numbers = append(numbers, number)
+			}
+		}
+
+		if bounds, ok := variableRanges[name]; ok {
+			for number := bounds[0]; number <= bounds[1]; number++ {
+				numbers = append(numbers, number)
+			}
+		}
+
+		value, ok := choose(ctx, byName[name], numbers)
+		if !ok {
+			return fallback
+		}
+		return int(value.(float64))
+	}
+
+	now := time.Date(
+		component(timeVariable.YearName, 2024),
+		time.Month(component(timeVariable.MonthName, 1)),
+		component(timeVariable.DayName, 1),
+		component(timeVariable.HourName, 0),
+		component(timeVariable.MinuteName, 0),
+		component(timeVariable.SecondName, 0),
+		0,
+		time.UTC,
+	)
+
+	for _, layout := range timeLayouts {
+		name := layout.name
+		if len(byName[name]) == 0 {
+			continue
+		}
+
+		value, ok := choose(ctx, byName[name], candidates(domains[name], byName[name]))
+		if !ok {
+			return time.Time{}, false
+		}
+
+		if layout.layout == "" {
+			now = time.Unix(int64(types.GetFloat(value)), 0).UTC()
+			continue
+		}
+
+		str := types.GetString(value)
+		if types.GetFilterType(value) == types.NUMBER {
+			str = formatNumber(types.GetFloat(value))
+		}
+
+		if parsed, err := time.Parse(layout.layout, str); err == nil {
+			if name == timeVariable.DateName {
+				parsed = parsed.Add(time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute + time.Duration(now.Second())*time.Second)
+			}
+			now = parsed
+		}
+	}
+
+	// 星期等条件无法直接构造，在之后的十年中逐天查找
+	for offset := 0; offset < 3660; offset++ {
+		candidate := now.AddDate(0, 0, offset)
+		if timeMatched(ctx, atoms, candidate) {
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func integer(value interface{}) (float64, bool) {
+	var number float64
+	switch types.GetFilterType(value) {
+	case types.NUMBER:
+		number = types.GetFloat(value)
+	case types.STRING:
+		parsed, err := strconv.ParseFloat(value.(string), 64)
+		if err != nil {
+			return 0, false
+		}
+		number = parsed
+	default:
+		return 0, false
+	}
+	return number, number == math.Trunc(number)
+}
+
+func timeMatched(ctx context.Context, atoms []atom, now time.Time) bool {
+	ctx = filterContext.WithNow(ctx, now)
+	for _, a := range atoms {
+		variable, ok := variables.Get(a.variable)
+		if !ok || !check(ctx, a, variable) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,192 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/airunny/filter/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateExample(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		items  []interface{}
+		data   map[string]interface{}
+		values map[string]interface{}
+	}{
+		{
+			items: []interface{}{
+				[]interface{}{"data.user.age", ">", 18.0},
+				[]interface{}{"data.user.age", "<", 30.0},
+				[]interface{}{"data.name", "=", "golang"},
+			},
+			data: map[string]interface{}{
+				"user": map[string]interface{}{"age": 19.0},
+				"name": "golang",
+			},
+			values: map[string]interface{}{},
+		},
+		{
+			items: []interface{}{
+				[]interface{}{"version", "vgte", "3.4.5"},
+				[]interface{}{"version", "vlt", "4"},
+				[]interface{}{"platform", "in", "ios,android"},
+				[]interface{}{"not", "=>", []interface{}{
+					[]interface{}{"platform", "=", "ios"},
+				}},
+				[]interface{}{"ip", "iir", "10.0.0.0/8"},
+				[]interface{}{"ip", "niir", "10.0.0.0/24"},
+				[]interface{}{"ua", "~", `/Chrome\/\d+/`},
+				[]interface{}{"is_login", "=", true},
+				[]interface{}{"ctx.tenant", "=", "a"},
+			},
+			data: map[string]interface{}{},
+			values: map[string]interface{}{
+				"version":    "3.4.5",
+				"platform":   "android",
+				"ip":         "10.255.255.255",
+				"ua":         "Chrome/0",
+				"uid":        defaultLoginUid,
+				"ctx.tenant": "a",
+			},
+		},
+		{
+			items: []interface{}{
+				[]interface{}{"or", "=>", []interface{}{
+					[]interface{}{"uid", "=", 1.0},
+					[]interface{}{"uid", "=", 2.0},
+				}},
+				[]interface{}{"uid", "nin", []interface{}{1.0}},
+			},
+			data:   map[string]interface{}{},
+			values: map[string]interface{}{"uid": 2.0},
+		},
+	}
+
+	for index, c := range cases {
+		cond := buildCondition(t, c.items...)
+		example, err := GenerateExample(ctx, cond)
+		assert.Nil(t, err, index)
+		assert.True(t, example.Verified, index)
+		assert.Empty(t, example.Notes, index)
+		assert.Equal(t, c.data, example.Data, index)
+		assert.Equal(t, c.values, example.Values, index)
+
+		ok, err := cond.IsConditionOk(example.Context(ctx), example.Data, cache.NewCache())
+		assert.Nil(t, err, index)
+		assert.True(t, ok, index)
+	}
+}
+
+// 带有缺失策略的条件可以通过不设置变量命中
+func TestGenerateExampleMissing(t *testing.T) {
+	ctx := context.Background()
+	cases := [][]interface{}{
+		{
+			[]interface{}{"data.a?true", ">", 5.0},
+			[]interface{}{"data.a?true", "<", 3.0},
+			[]interface{}{"platform?true", "=", "ios"},
+			[]interface{}{"platform?true", "=", "android"},
+		},
+		{
+			[]interface{}{"not", "=>", []interface{}{
+				[]interface{}{"data.a?false", ">", 5.0},
+				[]interface{}{"data.a?false", "<=", 5.0},
+			}},
+		},
+	}
+
+	for index, items := range cases {
+		cond := buildCondition(t, items...)
+		example, err := GenerateExample(ctx, cond)
+		assert.Nil(t, err, index)
+		assert.True(t, example.Verified, index)
+		assert.Empty(t, example.Data, index)
+		assert.Empty(t, example.Values, index)
+	}
+
+	// 时间等总是存在的变量无法通过不设置命中
+	_, err := GenerateExample(ctx, buildCondition(t,
+		[]interface{}{"hour?true", ">", 30.0},
+	))
+	var unsatisfiable *UnsatisfiableError
+	assert.True(t, errors.As(err, &unsatisfiable))
+	assert.Equal(t, []string{"variable can't be missing for [hour]: hour is missing", "contradictory constraints for [hour]: hour > 30"}, unsatisfiable.Reasons)
+}
+
+func TestGenerateExampleTime(t *testing.T) {
+	cond := buildCondition(t,
+		[]interface{}{"hour", "between", "10,19"},
+		[]interface{}{"minute", ">", 30.0},
+		[]interface{}{"wday", "=", 6.0},
+		[]interface{}{"month", "in", []interface{}{3.0}},
+	)
+
+	example, err := GenerateExample(context.Background(), cond)
+	assert.Nil(t, err)
+	assert.True(t, example.Verified)
+	assert.Equal(t, time.Date(2024, 3, 2, 10, 31, 0, 0, time.UTC), example.Now)
+}
+
+func TestGenerateExampleUnsatisfiable(t *testing.T) {
+	cond := buildCondition(t,
+		[]interface{}{"hour", ">", 20.0},
+		[]interface{}{"hour", "<", 5.0},
+	)
+
+	_, err := GenerateExample(context.Background(), cond)
+	var unsatisfiable *UnsatisfiableError
+	assert.True(t, errors.As(err, &unsatisfiable))
+	assert.Equal(t, []string{"contradictory constraints for [hour]: hour > 20 and hour < 5"}, unsatisfiable.Reasons)
+
+	cond = buildCondition(t,
+		[]interface{}{"ua", "~", "/^a+$/"},
+		[]interface{}{"ua", "!~", "/a/"},
+	)
+	_, err = GenerateExample(context.Background(), cond)
+	assert.True(t, errors.As(err, &unsatisfiable))
+	assert.Equal(t, []string{`no value found for [ua]: ua ~ /^a+$/ and ua !~ /a/`}, unsatisfiable.Reasons)
+}
+
+func TestGenerateExampleNotes(t *testing.T) {
+	cond := buildCondition(t,
+		[]interface{}{"city", "=", "北京"},
+		[]interface{}{"channel", "=", "store"},
+	)
+
+	example, err := GenerateExample(context.Background(), cond)
+	assert.Nil(t, err)
+	assert.False(t, example.Verified)
+	assert.Equal(t, []string{"[city] is resolved from ip and can't be set directly"}, example.Notes)
+	assert.Equal(t, map[string]interface{}{"channel": "store"}, example.Values)
+}
+
+func TestSampleRegexp(t *testing.T) {
+	cases := []string{
+		`^abc$`,
+		`\d{3}-\d+`,
+		`(foo|bar)baz?`,
+		`[^a-z]+x`,
+		`(?i)chrome`,
+		`.*iPhone OS 1[0-9]_`,
+	}
+
+	for _, c := range cases {
+		sample, ok := sampleRegexp(regexp.MustCompile(c))
+		assert.True(t, ok, c)
+		assert.Regexp(t, c, sample)
+	}
+}
+
+func TestVersionNeighbours(t *testing.T) {
+	assert.Equal(t, "1.2.1", nextVersion("1.2"))
+	previous, ok := previousVersion("1.2.0")
+	assert.True(t, ok)
+	assert.Equal(t, "1.1", previous)
+	_, ok = previousVersion("0.0")
+	assert.False(t, ok)
+}
@@ -0,0 +1,145 @@
+package analysis
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+
+	"github.com/airunny/filter/types"
+	"github.com/airunny/filter/utils"
+)
+
+// sampleRegexp 根据正则的语法树构造一个可以匹配的字符串
+func sampleRegexp(reg *regexp.Regexp) (string, bool) {
+	tree, err := syntax.Parse(reg.String(), syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+
+	var builder strings.Builder
+	if !writeSample(&builder, tree.Simplify()) {
+		return "", false
+	}
+
+	sample := builder.String()
+	return sample, reg.MatchString(sample)
+}
+
+func writeSample(builder *strings.Builder, re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return false
+	case syntax.OpLiteral:
+		builder.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		r, ok := pickRune(re.Rune)
+		if !ok {
+			return false
+		}
+		builder.WriteRune(r)
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		builder.WriteByte('a')
+	case syntax.OpCapture:
+		return writeSample(builder, re.Sub[0])
+	case syntax.OpPlus:
+		return writeSample(builder, re.Sub[0])
+	case syntax.OpRepeat:
+		for i := 0; i < re.Min; i++ {
+			if !writeSample(builder, re.Sub[0]) {
+				return false
+			}
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !writeSample(builder, sub) {
+				return false
+			}
+		}
+	case syntax.OpAlternate:
+		return writeSample(builder, re.Sub[0])
+	}
+	// 其他的都可以匹配空字符串，例如 ^ $ \b * ?
+	return true
+}
+
+// pickRune 优先选择可以打印的字符
+func pickRune(ranges []rune) (rune, bool) {
+	for _, preferred := range []rune{'a', '0', 'A', ' '} {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if ranges[i] <= preferred && preferred <= ranges[i+1] {
+				return preferred, true
+			}
+		}
+	}
+
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if ranges[i+1] >= '!' {
+			if ranges[i] >= '!' {
+				return ranges[i], true
+			}
+			return '!', true
+		}
+	}
+
+	if len(ranges) >= 2 {
+		return ranges[0], true
+	}
+	return 0, false
+}
+
+// nextVersion 比version大的版本号
+func nextVersion(version string) string {
+	if version == "" {
+		return "1"
+	}
+	return version + ".1"
+}
+
+// previousVersion 比version小的版本号，不存在时返回false
+func previousVersion(version string) (string, bool) {
+	segments := strings.Split(strings.TrimPrefix(strings.ToLower(version), "v"), ".")
+	for i := len(segments) - 1; i >= 0; i-- {
+		number, err := strconv.Atoi(segments[i])
+		if err != nil || number == 0 {
+			continue
+		}
+
+		segments[i] = strconv.Itoa(number - 1)
+		previous := strings.Join(segments[:i+1], ".")
+		if utils.VersionCompare(previous, version) < 0 {
+			return previous, true
+		}
+	}
+	return "", false
+}
+
+// formatValue 将预处理过的比较值转化为便于阅读的字符串
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case *regexp.Regexp:
+		return "/" + v.String() + "/"
+	case []utils.IPRange:
+		ranges := make([]string, 0, len(v))
+		for _, r := range v {
+			ranges = append(ranges, r.String())
+		}
+		return strings.Join(ranges, ",")
+	case *utils.ValueSet:
+		return formatValue(v.Values())
+	case []interface{}:
+		elements := make([]string, 0, len(v))
+		for _, element := range v {
+			elements = append(elements, formatValue(element))
+		}
+		return strings.Join(elements, ",")
+	case string:
+		return strconv.Quote(v)
+	}
+
+	if types.GetFilterType(value) == types.NUMBER {
+		return formatNumber(types.GetFloat(value))
+	}
+	return fmt.Sprint(value)
+}
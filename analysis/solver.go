@@ -0,0 +1,450 @@
+package analysis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/airunny/filter/condition"
+	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/types"
+	"github.com/airunny/filter/utils"
+	"github.com/airunny/filter/variables"
+)
+
+// maxTerms 条件展开为析取范式之后最多保留的合取项，超过之后认为无法分析
+const maxTerms = 256
+
+// volatileVariables 每次取值都可能不同的变量，同一个条件中多次出现时互相独立
+var volatileVariables = map[string]struct{}{
+	"rand": {},
+}
+
+// variableRanges 内置变量天然的取值范围
+var variableRanges = map[string][2]float64{
+	"second": {0, 59},
+	"minute": {0, 59},
+	"hour":   {0, 23},
+	"day":    {1, 31},
+	"month":  {1, 12},
+	"wday":   {0, 6},
+	"rand":   {1, 100},
+}
+
+// atom 原子条件，operation 为用于约束的内置操作名，为空时不参与约束
+type atom struct {
+	variable  string
+	operation string
+	op        operations.Operation
+	value     interface{}
+	bound     interface{} // between 取反之后拆分出来的边界
+	negate    bool
+	absent    bool // 变量不存在，带有缺失策略的条件在变量不存在时也可能成立，参见 missingTerms
+}
+
+// constraint 参与约束的比较值
+func (s atom) constraint() interface{} {
+	if s.bound != nil {
+		return s.bound
+	}
+	return s.value
+}
+
+// term 合取项，所有原子条件都需要满足
+type term []atom
+
+type solver struct {
+	volatile int
+}
+
+// Satisfiable 条件是否存在满足的取值，无法分析的条件认为可以满足
+func Satisfiable(cond condition.Condition) bool {
+	s := &solver{}
+	terms, ok := s.expand(cond, false)
+	if !ok {
+		return true
+	}
+	return s.satisfiable(terms)
+}
+
+// Tautology 条件是否恒成立
+func Tautology(cond condition.Condition) bool {
+	s := &solver{}
+	terms, ok := s.expand(cond, true)
+	if !ok {
+		return false
+	}
+	return !s.satisfiable(terms)
+}
+
+// Overlap 两个条件是否可以同时满足
+func Overlap(a, b condition.Condition) bool {
+	s := &solver{}
+	left, ok := s.expand(a, false)
+	if !ok {
+		return true
+	}
+
+	right, ok := s.expand(b, false)
+	if !ok {
+		return true
+	}
+
+	terms, ok := product(left, right)
+	if !ok {
+		return true
+	}
+	return s.satisfiable(terms)
+}
+
+func (s *solver) satisfiable(terms []term) bool {
+	for _, t := range terms {
+		if s.termSatisfiable(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *solver) termSatisfiable(t term) bool {
+	_, ok := s.conflict(t)
+	return !ok
+}
+
+// conflict 返回合取项中取值范围为空的变量
+func (s *solver) conflict(t term) (string, bool) {
+	domains := s.domains(t)
+	for _, a := range t {
+		if domains[a.variable].empty() {
+			return a.variable, true
+		}
+	}
+	return "", false
+}
+
+func (s *solver) domains(t term) map[string]*domain {
+	domains := make(map[string]*domain, len(t))
+	for _, a := range t {
+		d, ok := domains[a.variable]
+		if !ok {
+			d = seedDomain(a.variable)
+			domains[a.variable] = d
+		}
+
+		// 除了变量不存在之外的原子条件都要求变量存在
+		if a.absent {
+			d.absent = true
+			continue
+		}
+		d.present = true
+		apply(d, a.operation, a.constraint())
+	}
+	return domains
+}
+
+// expand 将条件展开为析取范式，negate 为 true 时展开条件的否定
+func (s *solver) expand(cond condition.Condition, negate bool) ([]term, bool) {
+	switch c := cond.(type) {
+	case *condition.BaseCondition:
+		if c.Operand() != nil {
+			// 比较值引用其它变量时不做约束
+			return []term{{}}, true
+		}
+		return s.expandBase(c, negate), true
+	case *condition.Segment:
+		return s.expand(c.Condition(), negate)
+	case *condition.Group:
+		children := c.Conditions()
+		switch c.Logic() {
+		case condition.LogicAnd:
+			if negate {
+				return s.any(children, true)
+			}
+			return s.all(children, false)
+		case condition.LogicOr:
+			if len(children) == 0 {
+				// 空的或条件恒成立
+				if negate {
+					return nil, true
+				}
+				return []term{{}}, true
+			}
+
+			if negate {
+				return s.all(children, true)
+			}
+			return s.any(children, false)
+		case condition.LogicNot:
+			if negate {
+				if len(children) == 0 {
+					return nil, true
+				}
+				return s.any(children, false)
+			}
+			return s.all(children, true)
+		case condition.LogicNand:
+			if negate {
+				return s.all(children, false)
+			}
+			return s.any(children, true)
+		}
+	}
+	// 未知的条件以及 xor、atleast、atmost 等按数量判断的分组不做任何约束
+	return []term{{}}, true
+}
+
+func (s *solver) all(children []condition.Condition, negate bool) ([]term, bool) {
+	terms := []term{{}}
+	for _, child := range children {
+		childTerms, ok := s.expand(child, negate)
+		if !ok {
+			return nil, false
+		}
+
+		terms, ok = product(terms, childTerms)
+		if !ok {
+			return nil, false
+		}
+	}
+	return terms, true
+}
+
+func (s *solver) any(children []condition.Condition, negate bool) ([]term, bool) {
+	var terms []term
+	for _, child := range children {
+		childTerms, ok := s.expand(child, negate)
+		if !ok {
+			return nil, false
+		}
+
+		terms = append(terms, childTerms...)
+		if len(terms) > maxTerms {
+			return nil, false
+		}
+	}
+	return terms, true
+}
+
+func product(left, right []term) ([]term, bool) {
+	if len(left)*len(right) > maxTerms {
+		return nil, false
+	}
+
+	terms := make([]term, 0, len(left)*len(right))
+	for _, l := range left {
+		for _, r := range right {
+			t := make(term, 0, len(l)+len(r))
+			t = append(t, l...)
+			t = append(t, r...)
+			terms = append(terms, t)
+		}
+	}
+	return terms, true
+}
+
+func (s *solver) expandBase(c *condition.BaseCondition, negate bool) []term {
+	name := c.Variable().Name()
+	if _, ok := volatileVariables[name]; ok {
+		s.volatile++
+		name = name + "#" + strconv.Itoa(s.volatile)
+	}
+
+	// !op 等价于对 op 取反
+	op := c.Operation()
+	if negated, ok := op.(*operations.Negated); ok {
+		op, negate = negated.Unwrap(), !negate
+	}
+
+	base := atom{
+		variable:  name,
+		operation: canonical(op.Name()),
+		op:        op,
+		value:     c.Value(),
+		negate:    negate,
+	}
+
+	terms := s.missingTerms(c, name, negate)
+	if !negate {
+		return append(terms, term{base})
+	}
+
+	if base.operation == "between" {
+		bounds, ok := c.Value().([]interface{})
+		if ok && len(bounds) == 2 {
+			lt, gt := base, base
+			lt.operation, lt.bound = "lt", bounds[0]
+			gt.operation, gt.bound = "gt", bounds[1]
+			return append(terms, term{lt}, term{gt})
+		}
+	}
+
+	// 无法取反的操作只保留原始条件用于校验，不参与约束
+	base.operation = negations[base.operation]
+	return append(terms, term{base})
+}
+
+// missingTerms 变量不存在时条件（negate 为true时为条件的否定）成立的合取项，
+// ?true、?false 分别在变量不存在时成立、不成立，?=默认值 按照默认值判断；
+// 默认的策略返回错误，?（unknown）的结果未知，条件以及条件的否定都不成立
+func (s *solver) missingTerms(c *condition.BaseCondition, name string, negate bool) []term {
+	var holds bool
+	switch policy := c.Missing(); policy.Action {
+	case variables.MissingTrue:
+		holds = !negate
+	case variables.MissingFalse:
+		holds = negate
+	case variables.MissingDefault:
+		ok, err := c.Operation().Run(context.Background(), &probe{name: c.Variable().Name(), value: policy.Default}, c.Value(), nil, nil)
+		holds = err == nil && ok != negate
+	}
+
+	if !holds {
+		return nil
+	}
+	return []term{{{variable: name, op: c.Operation(), value: c.Value(), negate: negate, absent: true}}}
+}
+
+// operationAliases 可以分析的内置操作
+var operationAliases = map[string]string{
+	"=":       "eq",
+	"eq":      "eq",
+	"!=":      "ne",
+	"<>":      "ne",
+	"ne":      "ne",
+	">":       "gt",
+	"gt":      "gt",
+	">=":      "gte",
+	"gte":     "gte",
+	"<":       "lt",
+	"lt":      "lt",
+	"<=":      "lte",
+	"lte":     "lte",
+	"in":      "in",
+	"nin":     "nin",
+	"between": "between",
+	"vgt":     "vgt",
+	"vgte":    "vgte",
+	"vlt":     "vlt",
+	"vlte":    "vlte",
+}
+
+var negations = map[string]string{
+	"eq":   "ne",
+	"ne":   "eq",
+	"gt":   "lte",
+	"gte":  "lt",
+	"lt":   "gte",
+	"lte":  "gt",
+	"in":   "nin",
+	"nin":  "in",
+	"vgt":  "vlte",
+	"vgte": "vlt",
+	"vlt":  "vgte",
+	"vlte": "vgt",
+}
+
+func canonical(operation string) string {
+	return operationAliases[operation]
+}
+
+func seedDomain(name string) *domain {
+	d := newDomain()
+	if index := strings.LastIndexByte(name, '#'); index > 0 {
+		name = name[:index]
+	}
+
+	if bounds, ok := variableRanges[name]; ok {
+		d.integer = true
+		d.lower(bounds[0], false)
+		d.upper(bounds[1], false)
+	}
+	return d
+}
+
+// apply 将原子条件的约束合并到变量的取值范围中。
+// 跟字符串比较时认为变量取值为字符串，跟数字比较时按照数字比较，与 utils.ObjectCompare 保持一致
+func apply(d *domain, operation string, value interface{}) {
+	switch canonical(operation) {
+	case "eq":
+		switch types.GetFilterType(value) {
+		case types.NUMBER, types.BOOL:
+			d.points = intersect(d.points, []float64{types.GetFloat(value)})
+		case types.STRING:
+			d.strs = intersect(d.strs, []string{value.(string)})
+		}
+	case "ne":
+		switch types.GetFilterType(value) {
+		case types.NUMBER, types.BOOL:
+			d.notPoints = addAll(d.notPoints, []float64{types.GetFloat(value)})
+		case types.STRING:
+			d.notStrs = addAll(d.notStrs, []string{value.(string)})
+		}
+	case "in":
+		numbers, strs, ok := split(value)
+		if !ok {
+			return
+		}
+
+		if len(strs) == 0 {
+			d.points = intersect(d.points, numbers)
+		} else if len(numbers) == 0 {
+			d.strs = intersect(d.strs, strs)
+		}
+	case "nin":
+		numbers, strs, _ := split(value)
+		d.notPoints = addAll(d.notPoints, numbers)
+		d.notStrs = addAll(d.notStrs, strs)
+	case "gt", "gte", "lt", "lte":
+		if !isNumber(value) {
+			return
+		}
+
+		number := types.GetFloat(value)
+		switch canonical(operation) {
+		case "gt":
+			d.lower(number, true)
+		case "gte":
+			d.lower(number, false)
+		case "lt":
+			d.upper(number, true)
+		case "lte":
+			d.upper(number, false)
+		}
+	case "between":
+		bounds, ok := value.([]interface{})
+		if !ok || len(bounds) != 2 || !isNumber(bounds[0]) || !isNumber(bounds[1]) {
+			return
+		}
+		d.lower(types.GetFloat(bounds[0]), false)
+		d.upper(types.GetFloat(bounds[1]), false)
+	case "vgt":
+		d.versionLower(types.GetString(value), true)
+	case "vgte":
+		d.versionLower(types.GetString(value), false)
+	case "vlt":
+		d.versionUpper(types.GetString(value), true)
+	case "vlte":
+		d.versionUpper(types.GetString(value), false)
+	}
+}
+
+// split 将集合中的元素按照数字和字符串分开，存在其他类型时返回false
+func split(value interface{}) (numbers []float64, strs []string, ok bool) {
+	ok = true
+	for _, element := range utils.ParseTargetArrayValue(value) {
+		switch types.GetFilterType(element) {
+		case types.NUMBER, types.BOOL:
+			numbers = append(numbers, types.GetFloat(element))
+		case types.STRING:
+			strs = append(strs, element.(string))
+		default:
+			ok = false
+		}
+	}
+	return
+}
+
+func isNumber(value interface{}) bool {
+	filterType := types.GetFilterType(value)
+	return filterType == types.NUMBER || filterType == types.BOOL
+}
@@ -0,0 +1,65 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/airunny/filter/analysis"
+	"github.com/airunny/filter/condition"
+)
+
+// Analyze 编译配置并做静态分析，返回永远不会命中、被覆盖、id重复以及批量模式下赋值冲突的过滤器
+func (s *Config) Analyze(ctx context.Context) ([]analysis.Issue, error) {
+	ctx, err := s.segmentContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := make([]analysis.Filter, 0, len(s.Filters))
+	for _, filterCnf := range s.Filters {
+		filter, err := buildSingleFilter(ctx, filterCnf.Id, filterCnf.Weight, filterCnf.Priority, filterCnf.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("filter [%s]: %w", filterCnf.Id, err)
+		}
+
+		filters = append(filters, analysis.Filter{
+			Id:        filter.id,
+			Weight:    filter.weight,
+			Priority:  filter.priority,
+			Condition: filter.condition,
+			Executor:  filter.executor,
+		})
+	}
+	return analysis.Analyze(filters, s.Batch), nil
+}
+
+// Example 为指定id的过滤器生成一个可以命中的请求示例
+func (s *Config) Example(ctx context.Context, id string) (*analysis.Example, error) {
+	ctx, err := s.segmentContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, filterCnf := range s.Filters {
+		if filterCnf.Id != id {
+			continue
+		}
+
+		filter, err := buildSingleFilter(ctx, filterCnf.Id, filterCnf.Weight, filterCnf.Priority, filterCnf.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("filter [%s]: %w", filterCnf.Id, err)
+		}
+		return analysis.GenerateExample(ctx, filter.condition)
+	}
+	return nil, fmt.Errorf("filter [%s] not found", id)
+}
+
+// segmentContext 跟构建过滤器一样先构建分群，filter中可以引用分群
+func (s *Config) segmentContext(ctx context.Context) (context.Context, error) {
+	segments := condition.NewSegments(s.Segments)
+	ctx = condition.WithSegments(ctx, segments)
+	if err := segments.Build(ctx); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
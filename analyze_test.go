@@ -0,0 +1,86 @@
+package filter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/airunny/filter/analysis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigAnalyze(t *testing.T) {
+	cnf := NewConfig(false,
+		When(Var("hour").Gt(20), Var("hour").Lt(5)).Then(Set("a", 1)).Id("1").Priority(1),
+		When(Var("city").In("北京", "上海"), Var("city").In("广州")).Then(Set("a", 1)).Id("2").Priority(1),
+		When(Var("hour").Gte(0)).Then(Set("a", 1)).Id("3").Priority(2),
+		When(Var("city").Eq("北京")).Then(Set("a", 1)).Id("4").Priority(3),
+	)
+
+	issues, err := cnf.Analyze(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, issues, 3)
+	assert.Equal(t, analysis.KindUnreachable, issues[0].Kind)
+	assert.Equal(t, []string{"1"}, issues[0].FilterIds)
+	assert.Equal(t, analysis.KindUnreachable, issues[1].Kind)
+	assert.Equal(t, []string{"2"}, issues[1].FilterIds)
+	assert.Equal(t, analysis.KindShadowed, issues[2].Kind)
+	assert.Equal(t, []string{"4", "3"}, issues[2].FilterIds)
+
+	cnf.Filters[0].Filter = []interface{}{[]interface{}{"hour", "unknown", 1}, []interface{}{"data.a", "=", 1}}
+	_, err = cnf.Analyze(context.Background())
+	assert.NotNil(t, err)
+}
+
+func TestConfigExample(t *testing.T) {
+	ctx := context.Background()
+	cnf := NewConfig(false,
+		When(Var("hour").Between(10, 19), Var("platform").In("ios", "android"), Var("data.price").Gte(100)).
+			Then(Set("discount", 0.8)).Id("1"),
+	)
+
+	example, err := cnf.Example(ctx, "1")
+	assert.Nil(t, err)
+	assert.True(t, example.Verified)
+
+	f, err := NewFilterFromConfig(ctx, cnf, nil)
+	assert.Nil(t, err)
+
+	data, err := f.Execute(example.Context(ctx), example.Data)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.8, data.(map[string]interface{})["discount"])
+
+	_, err = cnf.Example(ctx, "2")
+	assert.EqualError(t, err, "filter [2] not found")
+}
+
+func TestConfigAnalyzeSegments(t *testing.T) {
+	ctx := context.Background()
+	cnf := NewConfig(false,
+		When(Segment("ios"), Var("platform").Eq("android")).Then(Set("a", 1)).Id("1").Priority(1),
+		When(Segment("ios"), Var("data.price").Gte(100)).Then(Set("a", 1)).Id("2").Priority(1),
+	)
+	cnf.Segment("ios", Var("platform").Eq("ios"))
+
+	issues, err := cnf.Analyze(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, issues, 1)
+	assert.Equal(t, analysis.KindUnreachable, issues[0].Kind)
+	assert.Equal(t, []string{"1"}, issues[0].FilterIds)
+
+	example, err := cnf.Example(ctx, "2")
+	assert.Nil(t, err)
+	assert.True(t, example.Verified)
+
+	f, err := NewFilterFromConfig(ctx, cnf, nil)
+	assert.Nil(t, err)
+	data, err := f.Execute(example.Context(ctx), example.Data)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, data.(map[string]interface{})["a"])
+
+	// 分群有误时返回错误
+	cnf.Segment("broken", Var("hour").Op("unknown", 1))
+	_, err = cnf.Analyze(ctx)
+	assert.NotNil(t, err)
+	_, err = cnf.Example(ctx, "2")
+	assert.NotNil(t, err)
+}
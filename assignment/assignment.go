@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
 )
 
@@ -59,10 +60,26 @@ func (s *factory) Get(name string) (Assignment, bool) {
 	return operation, ok
 }
 
+func (s *factory) Names() []string {
+	s.Lock()
+	defer s.Unlock()
+	names := make([]string, 0, len(s.assignments))
+	for name := range s.assignments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func Register(operation Assignment) {
 	defaultFactory.Register(operation)
 }
 
+// Names 返回所有已注册的赋值操作
+func Names() []string {
+	return defaultFactory.Names()
+}
+
 func Get(name string) (Assignment, bool) {
 	return defaultFactory.Get(name)
 }
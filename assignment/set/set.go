@@ -75,11 +75,7 @@ func (s *Set) Run(ctx context.Context, data interface{}, key string, val interfa
 		metaData := dataValue.Index(int(index))
 		utils.SetValue(metaData, val)
 	case reflect.Ptr:
-		var (
-			dataValue = reflect.ValueOf(data).Elem()
-			dataType  = reflect.TypeOf(data).Elem()
-		)
-
+		dataValue := reflect.ValueOf(data).Elem()
 		if !dataValue.CanSet() {
 			return fmt.Errorf("[%s] %v path value %v can not set",
 				Name,
@@ -87,21 +83,23 @@ func (s *Set) Run(ctx context.Context, data interface{}, key string, val interfa
 				reflect.TypeOf(data).String())
 		}
 
-		f := dataValue.FieldByName(key)
-		if !f.IsValid() {
-			for i := 0; i < dataType.NumField(); i++ {
-				tf := dataType.Field(i)
-				if tf.Tag.Get("json") == key {
-					utils.SetValue(dataValue.Field(i), val)
-					return nil
-				}
-			}
-
+		index, ok := utils.FieldIndex(dataValue.Type(), key)
+		if !ok {
 			return fmt.Errorf("[%s] %v path value not exists key %s",
 				Name,
 				reflect.TypeOf(originData).String(),
 				key)
 		}
+
+		f, err := dataValue.FieldByIndexErr(index)
+		if err != nil {
+			return fmt.Errorf("[%s] %v path value %v can not set %s: %v",
+				Name,
+				reflect.TypeOf(originData).String(),
+				reflect.TypeOf(data).String(),
+				key,
+				err)
+		}
 		utils.SetValue(f, val)
 	default:
 		return fmt.Errorf("[%s] assignent not supported %v", Name, reflect.TypeOf(originData).String())
@@ -39,6 +39,11 @@ type SubData struct {
 	Data *SubData `json:"data"`
 }
 
+type EmbeddedData struct {
+	*SubData
+	Count int `json:"count"`
+}
+
 func TestSet(t *testing.T) {
 	ass, ok := assignment.Get(Name)
 	assert.True(t, ok)
@@ -416,6 +421,34 @@ func TestSet(t *testing.T) {
 			Data:         []string{"0", "0"},
 			ExpectedData: []string{"0", "1"},
 		},
+		// embedded
+		{
+			Key:          "count",
+			Value:        1,
+			ParsedValue:  1,
+			Data:         &EmbeddedData{},
+			ExpectedData: &EmbeddedData{Count: 1},
+		},
+		{
+			Key:          "Name",
+			Value:        "a",
+			ParsedValue:  "a",
+			Data:         &EmbeddedData{SubData: &SubData{}},
+			ExpectedData: &EmbeddedData{SubData: &SubData{Name: "a"}},
+		},
+		{
+			Key:         "Name",
+			Value:       "a",
+			ParsedValue: "a",
+			ResultErr: fmt.Errorf("[%s] %v path value %v can not set %s: %v",
+				Name,
+				reflect.TypeOf(&EmbeddedData{}).String(),
+				reflect.TypeOf(&EmbeddedData{}).String(),
+				"Name",
+				errors.New("reflect: indirection through nil pointer to embedded struct field SubData")),
+			Data:    &EmbeddedData{},
+			ErrData: &EmbeddedData{},
+		},
 	}
 
 	for index, tt := range cases {
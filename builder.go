@@ -0,0 +1,332 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/airunny/filter/condition"
+	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/variables"
+)
+
+// Expr 条件项，Items 返回与json配置等价的条件数组
+type Expr interface {
+	Items() []interface{}
+}
+
+// Action 执行项，Items 返回与json配置等价的执行数组
+type Action interface {
+	Items() []interface{}
+}
+
+type items []interface{}
+
+func (s items) Items() []interface{} { return s }
+
+// Raw 直接使用json格式的条件数组或执行数组
+func Raw(values ...interface{}) items {
+	return values
+}
+
+// ============================== condition ==========================
+
+type VarExpr struct {
+	name string
+}
+
+// Var 条件项中的变量，例如 Var("city").In("上海","北京")
+func Var(name string) VarExpr {
+	return VarExpr{name: name}
+}
+
+func (s VarExpr) Op(operation string, value interface{}) Expr {
+	return items{s.name, operation, value}
+}
+
+// NotOp 对操作取反，例如 Var("hour").NotOp("between", "10,19")
+func (s VarExpr) NotOp(operation string, value interface{}) Expr {
+	return s.Op(operations.NegatePrefix+operation, value)
+}
+
+// Optional 变量不存在时条件的结果未知，例如 Var("version").Optional().VersionGt("3.0")，参见 condition.ErrUnknown
+func (s VarExpr) Optional() VarExpr {
+	return VarExpr{name: s.name + "?"}
+}
+
+// OrElse 变量不存在时使用默认值，例如 Var("data.age").OrElse(0).Gte(18)
+func (s VarExpr) OrElse(value interface{}) VarExpr {
+	return VarExpr{name: s.name + "?" + variables.MissingPolicy{Action: variables.MissingDefault, Default: value}.String()}
+}
+
+// Ref 在比较值中引用该变量，例如 Var("data.balance").Gte(Var("data.price").Ref())
+func (s VarExpr) Ref() string {
+	return "$" + s.name
+}
+
+func (s VarExpr) Eq(value interface{}) Expr         { return s.Op("=", value) }
+func (s VarExpr) Ne(value interface{}) Expr         { return s.Op("!=", value) }
+func (s VarExpr) Gt(value interface{}) Expr         { return s.Op(">", value) }
+func (s VarExpr) Gte(value interface{}) Expr        { return s.Op(">=", value) }
+func (s VarExpr) Lt(value interface{}) Expr         { return s.Op("<", value) }
+func (s VarExpr) Lte(value interface{}) Expr        { return s.Op("<=", value) }
+func (s VarExpr) Match(value string) Expr           { return s.Op("~", value) }
+func (s VarExpr) NotMatch(value string) Expr        { return s.Op("!~", value) }
+func (s VarExpr) MatchAny(values ...string) Expr    { return s.Op("~*", stringsValue(values)) }
+func (s VarExpr) MatchNone(values ...string) Expr   { return s.Op("!~*", stringsValue(values)) }
+func (s VarExpr) In(values ...interface{}) Expr     { return s.Op("in", listValue(values)) }
+func (s VarExpr) NotIn(values ...interface{}) Expr  { return s.Op("nin", listValue(values)) }
+func (s VarExpr) Any(values ...interface{}) Expr    { return s.Op("any", listValue(values)) }
+func (s VarExpr) Has(values ...interface{}) Expr    { return s.Op("has", listValue(values)) }
+func (s VarExpr) None(values ...interface{}) Expr   { return s.Op("not", listValue(values)) }
+func (s VarExpr) VersionGt(version string) Expr     { return s.Op("vgt", version) }
+func (s VarExpr) VersionGte(version string) Expr    { return s.Op("vgte", version) }
+func (s VarExpr) VersionLt(version string) Expr     { return s.Op("vlt", version) }
+func (s VarExpr) VersionLte(version string) Expr    { return s.Op("vlte", version) }
+func (s VarExpr) InIPRange(cidrs ...string) Expr    { return s.Op("iir", stringsValue(cidrs)) }
+func (s VarExpr) NotInIPRange(cidrs ...string) Expr { return s.Op("niir", stringsValue(cidrs)) }
+func (s VarExpr) Between(start, end interface{}) Expr {
+	return s.Op("between", []interface{}{start, end})
+}
+
+// AnyOf 数组中至少一个元素满足所有条件，条件中的变量名为元素的字段，例如 Var("data.orders").AnyOf(Var("status").Eq("paid"))
+func (s VarExpr) AnyOf(exprs ...Expr) Expr { return s.Op(condition.QuantifierAny, children(exprs)) }
+
+// AllOf 数组中所有元素都满足所有条件，参考 AnyOf
+func (s VarExpr) AllOf(exprs ...Expr) Expr { return s.Op(condition.QuantifierAll, children(exprs)) }
+
+// NoneOf 数组中没有元素满足所有条件，参考 AnyOf
+func (s VarExpr) NoneOf(exprs ...Expr) Expr { return s.Op(condition.QuantifierNone, children(exprs)) }
+
+func listValue(values []interface{}) interface{} {
+	list := make([]interface{}, len(values))
+	copy(list, values)
+	return list
+}
+
+func stringsValue(values []string) interface{} {
+	if len(values) == 1 {
+		return values[0]
+	}
+
+	list := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		list = append(list, value)
+	}
+	return list
+}
+
+func children(exprs []Expr) []interface{} {
+	list := make([]interface{}, 0, len(exprs))
+	for _, expr := range exprs {
+		list = append(list, expr.Items())
+	}
+	return list
+}
+
+func group(logic string, exprs []Expr) Expr {
+	return items{logic, "=>", children(exprs)}
+}
+
+// And 所有条件都成立
+func And(exprs ...Expr) Expr { return group("and", exprs) }
+
+// Or 任意一个条件成立
+func Or(exprs ...Expr) Expr { return group("or", exprs) }
+
+// Not 所有条件都不成立
+func Not(exprs ...Expr) Expr { return group("not", exprs) }
+
+// Nor 所有条件都不成立，与 Not 相同
+func Nor(exprs ...Expr) Expr { return group("nor", exprs) }
+
+// Xor 有且只有一个条件成立
+func Xor(exprs ...Expr) Expr { return group("xor", exprs) }
+
+// Nand 至少一个条件不成立
+func Nand(exprs ...Expr) Expr { return group("nand", exprs) }
+
+// AtLeast 至少n个条件成立
+func AtLeast(n int, exprs ...Expr) Expr { return group(fmt.Sprintf("atleast:%d", n), exprs) }
+
+// AtMost 最多n个条件成立
+func AtMost(n int, exprs ...Expr) Expr { return group(fmt.Sprintf("atmost:%d", n), exprs) }
+
+// Segment 引用 Config.Segments 中的分群，参考 Config.Segment
+func Segment(name string) Expr { return items{condition.SegmentVariable, "=", name} }
+
+// Ordered 开启 Config.Optimize 时分组中的条件仍然按照书写顺序执行
+func Ordered(expr Expr) Expr {
+	values := expr.Items()
+	if len(values) < 3 {
+		return expr
+	}
+
+	logic, ok := values[0].(string)
+	if !ok {
+		return expr
+	}
+
+	if _, _, ok, _ = condition.ParseLogic(logic); !ok {
+		return expr
+	}
+	return withOption(values, "ordered")
+}
+
+// Cached 同一次执行中所有filter里结构相同的条件只计算一次，例如 Cached(Or(Var("data.age").Gte(18), Var("data.vip").Eq(true)))，
+// 参见 condition.GroupOptions.Cached
+func Cached(expr Expr) Expr {
+	values := expr.Items()
+	if len(values) < 3 {
+		return expr
+	}
+
+	if _, ok := values[0].(string); !ok {
+		return expr
+	}
+	return withOption(values, "cached")
+}
+
+// withOption 在条件的第四个元素中开启选项，已有的选项保持不变
+func withOption(values []interface{}, option string) Expr {
+	opts := map[string]interface{}{option: true}
+	if len(values) == 4 {
+		if exists, ok := values[3].(map[string]interface{}); ok {
+			for key, value := range exists {
+				opts[key] = value
+			}
+			opts[option] = true
+		}
+	}
+	return items{values[0], values[1], values[2], opts}
+}
+
+// ============================== executor ==========================
+
+func Assign(key, assignment string, value interface{}) Action {
+	return items{key, assignment, value}
+}
+
+func Set(key string, value interface{}) Action { return Assign(key, "=", value) }
+func Del(key string) Action                    { return Assign(key, "del", nil) }
+
+// ============================== rule ==========================
+
+// Rule 使用代码定义的单个filter
+//
+//	filter.When(Var("city").In("上海","北京"), Var("hour").Between(10,19)).Then(Set("name","golang"))
+type Rule struct {
+	id       string
+	weight   int64
+	priority int64
+	exprs    []Expr
+	actions  []Action
+}
+
+func When(exprs ...Expr) *Rule {
+	return &Rule{exprs: exprs}
+}
+
+func (s *Rule) Then(actions ...Action) *Rule {
+	s.actions = append(s.actions, actions...)
+	return s
+}
+
+func (s *Rule) Id(id string) *Rule {
+	s.id = id
+	return s
+}
+
+func (s *Rule) Weight(weight int64) *Rule {
+	s.weight = weight
+	return s
+}
+
+func (s *Rule) Priority(priority int64) *Rule {
+	s.priority = priority
+	return s
+}
+
+// Items 返回与json配置等价的filter数组，最后一项为执行项
+func (s *Rule) Items() []interface{} {
+	filter := make([]interface{}, 0, len(s.exprs)+1)
+	for _, expr := range s.exprs {
+		filter = append(filter, expr.Items())
+	}
+
+	if len(s.actions) == 1 {
+		filter = append(filter, s.actions[0].Items())
+	} else if len(s.actions) > 1 {
+		actions := make([]interface{}, 0, len(s.actions))
+		for _, action := range s.actions {
+			actions = append(actions, action.Items())
+		}
+		filter = append(filter, actions)
+	}
+	return filter
+}
+
+func (s *Rule) Config() FilterConfig {
+	return FilterConfig{
+		Id:       s.id,
+		Weight:   s.weight,
+		Priority: s.priority,
+		Filter:   s.Items(),
+	}
+}
+
+func (s *Rule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Items())
+}
+
+// JSON 返回filter数组的json格式，不会转义 =>、< 等字符
+func (s *Rule) JSON() (string, error) {
+	return marshalJSON(s.Items())
+}
+
+// NewConfig 使用代码定义的filter生成配置
+func NewConfig(batch bool, rules ...*Rule) *Config {
+	cnf := &Config{
+		Batch: batch,
+	}
+	cnf.Add(rules...)
+	return cnf
+}
+
+// JSON 返回配置的json格式，不会转义 =>、< 等字符
+func (s Config) JSON() (string, error) {
+	return marshalJSON(s)
+}
+
+func (s *Config) Add(rules ...*Rule) {
+	for _, rule := range rules {
+		s.Filters = append(s.Filters, rule.Config())
+	}
+}
+
+// Segment 定义分群，所有条件都成立时属于该分群，例如
+//
+//	cnf.Segment("vip_android", Var("platform").Eq("android"), Var("data.vip").Eq(true))
+func (s *Config) Segment(name string, exprs ...Expr) {
+	if s.Segments == nil {
+		s.Segments = make(map[string][]interface{})
+	}
+
+	conditions := make([]interface{}, 0, len(exprs))
+	for _, expr := range exprs {
+		conditions = append(conditions, expr.Items())
+	}
+	s.Segments[name] = conditions
+}
+
+func marshalJSON(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
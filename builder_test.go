@@ -0,0 +1,127 @@
+package filter
+
+import (
+	"context"
+	"testing"
+
+	filterContext "github.com/airunny/filter/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRule(t *testing.T) {
+	cases := []struct {
+		Rule *Rule
+		Json string
+	}{
+		{
+			Rule: When(Var("city").In("上海", "北京"), Var("hour").Between(10, 19)).Then(Set("name", "golang")),
+			Json: `[["city","in",["上海","北京"]],["hour","between",[10,19]],["name","=","golang"]]`,
+		},
+		{
+			Rule: When(
+				Or(
+					And(Var("platform").Eq("ios"), Var("version").VersionGte("3.4.5")),
+					Var("ip").InIPRange("192.168.1.1/24", "10.0.0.1/8"),
+				),
+				Not(Var("ua").MatchAny("/spider/", "bot")),
+				Raw("uid", "nin", "1,2"),
+			).Then(Set("name", "golang"), Del("desc")),
+			Json: `[["or","=>",[["and","=>",[["platform","=","ios"],["version","vgte","3.4.5"]]],["ip","iir",["192.168.1.1/24","10.0.0.1/8"]]]],["not","=>",[["ua","~*",["/spider/","bot"]]]],["uid","nin","1,2"],[["name","=","golang"],["desc","del",null]]]`,
+		},
+		{
+			Rule: When(Ordered(Or(Var("platform").Eq("ios"), Var("freq.view").Gt(1))), Ordered(Var("uid").Eq(1))).Then(Set("name", "golang")),
+			Json: `[["or","=>",[["platform","=","ios"],["freq.view",">",1]],{"ordered":true}],["uid","=",1],["name","=","golang"]]`,
+		},
+		{
+			Rule: When(
+				AtLeast(2, Var("platform").Eq("ios"), Var("channel").Eq("ads"), Var("uid").Gt(10)),
+				Ordered(Xor(Var("hour").Lt(10), Var("city").Eq("上海"))),
+				Nand(Var("uid").Eq(1), Var("uid").Eq(2)),
+			).Then(Set("name", "golang")),
+			Json: `[["atleast:2","=>",[["platform","=","ios"],["channel","=","ads"],["uid",">",10]]],["xor","=>",[["hour","<",10],["city","=","上海"]],{"ordered":true}],["nand","=>",[["uid","=",1],["uid","=",2]]],["name","=","golang"]]`,
+		},
+		{
+			Rule: When(Var("data.balance").Gte(Var("data.price").Ref()), Var("version").VersionGte(Var("data.min_version").Ref())).Then(Set("name", "golang")),
+			Json: `[["data.balance",">=","$data.price"],["version","vgte","$data.min_version"],["name","=","golang"]]`,
+		},
+		{
+			Rule: When(Not(Var("version").Optional().VersionGt("3.0")), Var("data.age").OrElse(0).Gte(18), Var("version").OrElse("1.0.0").VersionGte("2.0")).Then(Set("name", "golang")),
+			Json: `[["not","=>",[["version?","vgt","3.0"]]],["data.age?=0",">=",18],["version?=1.0.0","vgte","2.0"],["name","=","golang"]]`,
+		},
+		{
+			Rule: When(Var("hour").NotOp("between", "10,19"), Var("data.tags").NotOp("has", []interface{}{"blocked"})).Then(Set("name", "golang")),
+			Json: `[["hour","!between","10,19"],["data.tags","!has",["blocked"]],["name","=","golang"]]`,
+		},
+		{
+			Rule: When(Var("data.orders").AnyOf(Var("status").Eq("paid"), Var("amount").Gt(100)), Var("data.orders").NoneOf(Var("status").Eq("refund"))).Then(Set("name", "golang")),
+			Json: `[["data.orders","anyof",[["status","=","paid"],["amount",">",100]]],["data.orders","noneof",[["status","=","refund"]]],["name","=","golang"]]`,
+		},
+		{
+			Rule: When(Cached(Ordered(Or(Var("data.age").Gte(18), Var("uid").Eq(1)))), Cached(Var("data.vip").Eq(true))).Then(Set("name", "golang")),
+			Json: `[["or","=>",[["data.age",">=",18],["uid","=",1]],{"cached":true,"ordered":true}],["data.vip","=",true,{"cached":true}],["name","=","golang"]]`,
+		},
+		{
+			Rule: When(Var("success").Eq(1)).Then(Assign("count", "=", 1)),
+			Json: `[["success","=",1],["count","=",1]]`,
+		},
+	}
+
+	for index, tt := range cases {
+		content, err := tt.Rule.JSON()
+		assert.Nil(t, err, index)
+		assert.Equal(t, tt.Json, content, index)
+	}
+}
+
+func TestNewConfig(t *testing.T) {
+	ctx := context.Background()
+	cnf := NewConfig(false,
+		When(Var("platform").Eq("ios")).Then(Set("name", "ios")).Id("1").Priority(1),
+		When(Var("success").Eq(1)).Then(Set("name", "default")).Id("2").Priority(2).Weight(1),
+	)
+
+	content, err := cnf.JSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"filters":[{"id":"1","weight":0,"priority":1,"filter":[["platform","=","ios"],["name","=","ios"]]},{"id":"2","weight":1,"priority":2,"filter":[["success","=",1],["name","=","default"]]}],"batch":false}`, content)
+
+	var ids []string
+	reporter := ReportFunc(func(ctx context.Context, data interface{}, filterIds []string) {
+		ids = filterIds
+	})
+
+	// 代码定义的配置跟序列化之后的json配置执行结果一致
+	fromConfig, err := NewFilterFromConfig(ctx, cnf, reporter)
+	assert.Nil(t, err)
+	fromJson, err := NewFilter(ctx, content, reporter)
+	assert.Nil(t, err)
+
+	for _, f := range []*Filter{fromConfig, fromJson} {
+		data, err := f.Execute(filterContext.WithPlatform(ctx, "ios"), nil)
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]interface{}{"name": "ios"}, data)
+		assert.Equal(t, []string{"1"}, ids)
+
+		data, err = f.Execute(filterContext.WithPlatform(ctx, "android"), nil)
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]interface{}{"name": "default"}, data)
+		assert.Equal(t, []string{"2"}, ids)
+	}
+
+	err = fromConfig.RefreshConfig(ctx, NewConfig(false, When(Var("golang").Eq(1)).Then(Set("name", "golang"))))
+	assert.EqualError(t, err, "condition not exists variable [golang]")
+}
+
+func TestConfigSegment(t *testing.T) {
+	cnf := NewConfig(false, When(Segment("ios_adult")).Then(Set("adult", true)))
+	cnf.Segment("ios_adult", Var("platform").Eq("ios"), Var("data.age").Gte(18))
+
+	content, err := cnf.JSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"filters":[{"id":"","weight":0,"priority":0,"filter":[["segment","=","ios_adult"],["adult","=",true]]}],"batch":false,"segments":{"ios_adult":[["platform","=","ios"],["data.age",">=",18]]}}`, content)
+
+	f, err := NewFilter(context.Background(), content, nil)
+	assert.Nil(t, err)
+	data, err := f.Execute(filterContext.WithPlatform(context.Background(), "ios"), map[string]interface{}{"age": 20})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"age": 20, "adult": true}, data)
+}
@@ -2,35 +2,190 @@ package cache
 
 import "sync"
 
+// Scope 缓存的作用域，不同作用域的key互不影响
+type Scope uint8
+
+const (
+	// ScopeRequest 只依赖请求上下文的值，例如 platform、ip，整个请求中都不会变化
+	ScopeRequest Scope = iota
+	// ScopeData 依赖data的值，例如 data.x，执行赋值之后失效
+	ScopeData
+
+	scopes
+)
+
+// Stat 单个key的命中统计
+type Stat struct {
+	Hits   int
+	Misses int
+}
+
+// StatKey 命中统计的key，不同作用域中相同的key分开统计
+type StatKey struct {
+	Scope Scope
+	Key   string
+}
+
+// Cache 单次执行中的缓存，默认只能在一个goroutine中使用，并发使用时通过 NewConcurrentCache 创建
 type Cache struct {
 	Enable bool
-	data   *sync.Map
-	vars   *sync.Map
+	// CollectStats 为true时记录每个key的命中统计，参见 Stats，默认不记录
+	CollectStats bool
+	values       [scopes]map[string]interface{}
+	stats        map[StatKey]Stat
+	parent       *Cache      // 不为空时 ScopeRequest 的值保存在parent中，参见 Child
+	mu           *sync.Mutex // 不为空时并发安全
 }
 
-func (s *Cache) Set(key string, value interface{}) {
-	if s == nil {
+var pool = sync.Pool{
+	New: func() interface{} {
+		return NewCache()
+	},
+}
+
+func NewCache() *Cache {
+	return &Cache{
+		Enable: true,
+	}
+}
+
+// NewConcurrentCache 可以在多个goroutine中同时使用的缓存
+func NewConcurrentCache() *Cache {
+	return &Cache{
+		Enable: true,
+		mu:     &sync.Mutex{},
+	}
+}
+
+// Acquire 从缓存池中获取，使用完之后通过 Release 放回
+func Acquire() *Cache {
+	return pool.Get().(*Cache)
+}
+
+// Release 清空缓存并放回缓存池，之后不能再使用
+func Release(c *Cache) {
+	if c == nil || c.mu != nil || c.parent != nil {
 		return
 	}
 
-	if s.Enable {
-		s.data.Store(key, value)
+	for _, values := range c.values {
+		clear(values)
 	}
+	clear(c.stats)
+	c.Enable = true
+	c.CollectStats = false
+	pool.Put(c)
+}
+
+// Child 共享 ScopeRequest 中的值，ScopeData 中的值独立，用于同一个请求中的多个data；s为nil时返回nil
+func (s *Cache) Child() *Cache {
+	if s == nil {
+		return nil
+	}
+
+	return &Cache{
+		Enable:       s.Enable,
+		CollectStats: s.CollectStats,
+		parent:       s,
+	}
+}
+
+func (s *Cache) Set(key string, value interface{}) {
+	s.SetScoped(ScopeRequest, key, value)
 }
 
 func (s *Cache) Get(key string) (interface{}, bool) {
+	return s.GetScoped(ScopeRequest, key)
+}
+
+func (s *Cache) SetScoped(scope Scope, key string, value interface{}) {
+	if s == nil || !s.Enable {
+		return
+	}
+
+	if scope == ScopeRequest && s.parent != nil {
+		s.parent.SetScoped(scope, key, value)
+		return
+	}
+
+	if s.mu != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	if s.values[scope] == nil {
+		s.values[scope] = make(map[string]interface{})
+	}
+	s.values[scope][key] = value
+}
+
+func (s *Cache) GetScoped(scope Scope, key string) (interface{}, bool) {
 	if s == nil {
 		return nil, false
 	}
 
-	elem, ok := s.data.Load(key)
-	return elem, ok
+	if scope == ScopeRequest && s.parent != nil {
+		return s.parent.GetScoped(scope, key)
+	}
+
+	if s.mu != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	value, ok := s.values[scope][key]
+	if s.CollectStats {
+		s.record(StatKey{Scope: scope, Key: key}, ok)
+	}
+	return value, ok
 }
 
-func NewCache() *Cache {
-	return &Cache{
-		Enable: true,
-		data:   &sync.Map{},
-		vars:   &sync.Map{},
+func (s *Cache) record(key StatKey, hit bool) {
+	if s.stats == nil {
+		s.stats = make(map[StatKey]Stat)
+	}
+
+	stat := s.stats[key]
+	if hit {
+		stat.Hits++
+	} else {
+		stat.Misses++
+	}
+	s.stats[key] = stat
+}
+
+// Invalidate 清空作用域中的所有值，例如执行赋值之后清空 ScopeData
+func (s *Cache) Invalidate(scope Scope) {
+	if s == nil {
+		return
+	}
+
+	if scope == ScopeRequest && s.parent != nil {
+		s.parent.Invalidate(scope)
+		return
+	}
+
+	if s.mu != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+	clear(s.values[scope])
+}
+
+// Stats 每个key的命中统计，CollectStats 为true时才会记录，Child 中只包含 ScopeData 的统计
+func (s *Cache) Stats() map[StatKey]Stat {
+	if s == nil {
+		return nil
+	}
+
+	if s.mu != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	stats := make(map[StatKey]Stat, len(s.stats))
+	for key, stat := range s.stats {
+		stats[key] = stat
 	}
+	return stats
 }
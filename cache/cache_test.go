@@ -55,3 +55,151 @@ func TestCache_Set(t *testing.T) {
 		assert.Equal(t, value, v.Value)
 	}
 }
+
+func TestCacheScope(t *testing.T) {
+	c := NewCache()
+	c.CollectStats = true
+	c.Set("platform", "ios")
+	c.SetScoped(ScopeData, "data.x", 1)
+	c.SetScoped(ScopeData, "platform", "data")
+
+	value, ok := c.GetScoped(ScopeRequest, "platform")
+	assert.True(t, ok)
+	assert.Equal(t, "ios", value)
+
+	value, ok = c.GetScoped(ScopeData, "platform")
+	assert.True(t, ok)
+	assert.Equal(t, "data", value)
+
+	c.Invalidate(ScopeData)
+	_, ok = c.GetScoped(ScopeData, "data.x")
+	assert.False(t, ok)
+
+	value, ok = c.Get("platform")
+	assert.True(t, ok)
+	assert.Equal(t, "ios", value)
+
+	// 不同作用域中相同的key分开统计
+	assert.Equal(t, map[StatKey]Stat{
+		{Scope: ScopeRequest, Key: "platform"}: {Hits: 2},
+		{Scope: ScopeData, Key: "platform"}:    {Hits: 1},
+		{Scope: ScopeData, Key: "data.x"}:      {Misses: 1},
+	}, c.Stats())
+
+	// 默认不记录命中统计
+	c = NewCache()
+	c.Set("platform", "ios")
+	_, ok = c.Get("platform")
+	assert.True(t, ok)
+	assert.Empty(t, c.Stats())
+
+	// 关闭之后不再写入
+	c.Enable = false
+	c.Set("channel", "store")
+	_, ok = c.Get("channel")
+	assert.False(t, ok)
+
+	var empty *Cache
+	empty.Set("a", 1)
+	empty.Invalidate(ScopeData)
+	_, ok = empty.Get("a")
+	assert.False(t, ok)
+	assert.Nil(t, empty.Stats())
+}
+
+func TestCacheChild(t *testing.T) {
+	parent := NewConcurrentCache()
+	parent.CollectStats = true
+	a, b := parent.Child(), parent.Child()
+
+	a.Set("platform", "ios")
+	a.SetScoped(ScopeData, "data.x", 1)
+
+	value, ok := b.Get("platform")
+	assert.True(t, ok)
+	assert.Equal(t, "ios", value)
+
+	_, ok = b.GetScoped(ScopeData, "data.x")
+	assert.False(t, ok)
+
+	value, ok = a.GetScoped(ScopeData, "data.x")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, map[StatKey]Stat{{Scope: ScopeRequest, Key: "platform"}: {Hits: 1}}, parent.Stats())
+	assert.Equal(t, map[StatKey]Stat{{Scope: ScopeData, Key: "data.x"}: {Hits: 1}}, a.Stats())
+
+	// 不使用缓存时子缓存同样为nil
+	var empty *Cache
+	assert.Nil(t, empty.Child())
+}
+
+func TestCachePool(t *testing.T) {
+	c := Acquire()
+	c.Set("platform", "ios")
+	c.Enable = false
+	c.CollectStats = true
+	Release(c)
+
+	c = Acquire()
+	assert.True(t, c.Enable)
+	assert.False(t, c.CollectStats)
+	_, ok := c.Get("platform")
+	assert.False(t, ok)
+	Release(c)
+}
+
+func TestConcurrentCache(t *testing.T) {
+	c := NewConcurrentCache()
+	c.CollectStats = true
+	done := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		go func(i int) {
+			defer func() { done <- struct{}{} }()
+			for j := 0; j < 100; j++ {
+				c.Set("key", i)
+				c.Get("key")
+				c.SetScoped(ScopeData, "data", j)
+				c.Invalidate(ScopeData)
+			}
+		}(i)
+	}
+
+	for i := 0; i < 8; i++ {
+		<-done
+	}
+	assert.Equal(t, 800, c.Stats()[StatKey{Scope: ScopeRequest, Key: "key"}].Hits)
+}
+
+// use 模拟一次执行：缓存几个变量，其中data中的变量在赋值之后失效
+func use(c *Cache) {
+	for _, key := range []string{"platform", "channel", "ip", "version"} {
+		if _, ok := c.Get(key); !ok {
+			c.Set(key, key)
+		}
+	}
+	c.SetScoped(ScopeData, "data.x", 1)
+	c.Invalidate(ScopeData)
+}
+
+func BenchmarkCache(b *testing.B) {
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			use(NewCache())
+		}
+	})
+	b.Run("pool", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c := Acquire()
+			use(c)
+			Release(c)
+		}
+	})
+	b.Run("concurrent", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			use(NewConcurrentCache())
+		}
+	})
+}
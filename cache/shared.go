@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Shared 跨请求共享的缓存，支持过期时间、容量上限（淘汰最久未使用的key）以及并发未命中时只加载一次
+type Shared struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	size  int
+	items map[string]*list.Element
+	lru   *list.List
+	calls map[string]*call
+	now   func() time.Time
+}
+
+type entry struct {
+	key      string
+	value    interface{}
+	expireAt time.Time
+}
+
+// call 正在加载中的key，其它请求等待加载完成之后直接使用结果
+type call struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+var errLoadPanicked = errors.New("shared cache load panicked")
+
+type SharedOption func(s *Shared)
+
+// WithTTL 过期时间，为0时不过期
+func WithTTL(ttl time.Duration) SharedOption {
+	return func(s *Shared) {
+		s.ttl = ttl
+	}
+}
+
+// WithSize 最多缓存的key数量，为0时不限制
+func WithSize(size int) SharedOption {
+	return func(s *Shared) {
+		s.size = size
+	}
+}
+
+func withClock(now func() time.Time) SharedOption {
+	return func(s *Shared) {
+		s.now = now
+	}
+}
+
+func NewShared(opts ...SharedOption) *Shared {
+	s := &Shared{
+		items: make(map[string]*list.Element),
+		lru:   list.New(),
+		calls: make(map[string]*call),
+		now:   time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Shared) Get(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(key)
+}
+
+func (s *Shared) get(key string) (interface{}, bool) {
+	element, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := element.Value.(*entry)
+	if s.ttl > 0 && !s.now().Before(e.expireAt) {
+		s.remove(element)
+		return nil, false
+	}
+
+	s.lru.MoveToFront(element)
+	return e.value, true
+}
+
+func (s *Shared) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set(key, value)
+}
+
+func (s *Shared) set(key string, value interface{}) {
+	var expireAt time.Time
+	if s.ttl > 0 {
+		expireAt = s.now().Add(s.ttl)
+	}
+
+	if element, ok := s.items[key]; ok {
+		e := element.Value.(*entry)
+		e.value, e.expireAt = value, expireAt
+		s.lru.MoveToFront(element)
+		return
+	}
+
+	s.items[key] = s.lru.PushFront(&entry{key: key, value: value, expireAt: expireAt})
+	if s.size > 0 && s.lru.Len() > s.size {
+		s.remove(s.lru.Back())
+	}
+}
+
+func (s *Shared) remove(element *list.Element) {
+	s.lru.Remove(element)
+	delete(s.items, element.Value.(*entry).key)
+}
+
+func (s *Shared) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		s.remove(element)
+	}
+}
+
+// Len 当前缓存的key数量，包含已经过期但是还没有被清理的key
+func (s *Shared) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+// Load 返回缓存中的值，不存在时调用load加载并缓存，同一个key同时只有一个load在执行。load返回错误时不缓存
+func (s *Shared) Load(key string, load func() (interface{}, error)) (interface{}, error) {
+	s.mu.Lock()
+	if value, ok := s.get(key); ok {
+		s.mu.Unlock()
+		return value, nil
+	}
+
+	if c, ok := s.calls[key]; ok {
+		s.mu.Unlock()
+		<-c.done
+		return c.value, c.err
+	}
+
+	c := &call{done: make(chan struct{})}
+	s.calls[key] = c
+	s.mu.Unlock()
+
+	completed := false
+	defer func() {
+		// load panic时等待中的请求返回错误
+		if !completed {
+			c.err = errLoadPanicked
+		}
+
+		s.mu.Lock()
+		if c.err == nil {
+			s.set(key, c.value)
+		}
+		delete(s.calls, key)
+		s.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.value, c.err = load()
+	completed = true
+	return c.value, c.err
+}
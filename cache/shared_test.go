@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedTTL(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := NewShared(WithTTL(time.Minute), withClock(func() time.Time { return now }))
+
+	s.Set("a", 1)
+	value, ok := s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	now = now.Add(59 * time.Second)
+	_, ok = s.Get("a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok = s.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, s.Len())
+
+	// 重新设置之后重新计算过期时间
+	s.Set("a", 2)
+	now = now.Add(30 * time.Second)
+	s.Set("a", 3)
+	now = now.Add(45 * time.Second)
+	value, ok = s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+}
+
+func TestSharedLRU(t *testing.T) {
+	s := NewShared(WithSize(2))
+	s.Set("a", 1)
+	s.Set("b", 2)
+	s.Get("a")
+	s.Set("c", 3)
+
+	_, ok := s.Get("b")
+	assert.False(t, ok)
+	_, ok = s.Get("a")
+	assert.True(t, ok)
+	_, ok = s.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 2, s.Len())
+
+	s.Delete("a")
+	_, ok = s.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, s.Len())
+}
+
+func TestSharedLoad(t *testing.T) {
+	var (
+		s       = NewShared()
+		loads   int32
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := s.Load("a", func() (interface{}, error) {
+				atomic.AddInt32(&loads, 1)
+				<-release
+				return "value", nil
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, "value", value)
+		}()
+	}
+
+	// 等待所有请求都在等待同一次加载
+	for atomic.LoadInt32(&loads) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), loads)
+
+	// 加载失败时不缓存
+	_, err := s.Load("b", func() (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	assert.Equal(t, "failed", err.Error())
+	_, ok := s.Get("b")
+	assert.False(t, ok)
+
+	assert.Panics(t, func() {
+		_, _ = s.Load("c", func() (interface{}, error) {
+			panic("load")
+		})
+	})
+	_, ok = s.Get("c")
+	assert.False(t, ok)
+
+	value, err := s.Load("c", func() (interface{}, error) {
+		return 1, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, value)
+}
@@ -0,0 +1,73 @@
+// filtergen 把json或者yaml格式的过滤器配置生成为Go代码，例如
+//
+//	//go:generate go run github.com/airunny/filter/cmd/filtergen -in rules.json -out rules.go -pkg rules
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/airunny/filter"
+	"github.com/airunny/filter/codegen"
+)
+
+func main() {
+	var (
+		in   = flag.String("in", "", "过滤器配置文件，json或者yaml格式")
+		out  = flag.String("out", "", "生成的Go文件，为空时输出到标准输出")
+		pkg  = flag.String("pkg", "", "生成代码的包名，默认为输出文件所在的目录名")
+		name = flag.String("name", "Filter", "生成的 *codegen.Program 变量名")
+	)
+	flag.Parse()
+
+	if err := run(*in, *out, *pkg, *name); err != nil {
+		fmt.Fprintln(os.Stderr, "filtergen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg, name string) error {
+	if in == "" {
+		return fmt.Errorf("-in is required")
+	}
+
+	content, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+
+	cnf, err := filter.ParseConfig(string(content))
+	if err != nil {
+		return err
+	}
+
+	if pkg == "" {
+		if out == "" {
+			return fmt.Errorf("-pkg is required when -out is empty")
+		}
+
+		abs, err := filepath.Abs(out)
+		if err != nil {
+			return err
+		}
+		pkg = filepath.Base(filepath.Dir(abs))
+	}
+
+	code, err := cnf.Generate(context.Background(), codegen.Options{
+		Package: pkg,
+		Name:    name,
+		Source:  filepath.Base(in),
+	})
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(out, code, 0o644)
+}
@@ -0,0 +1,504 @@
+// Package codegen 把过滤器配置生成为Go代码：分组条件生成为普通的控制流，条件项以及执行项仍然使用已注册的变量、操作以及赋值实现，
+// 适合把很少变化并且执行频繁的配置固定在代码中，由编译器检查并且省去运行时解析配置的开销
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/condition"
+	"github.com/airunny/filter/executor"
+	"github.com/airunny/filter/lists"
+	"github.com/airunny/filter/variables"
+)
+
+// Filter 需要生成代码的filter，按照执行顺序排列
+type Filter struct {
+	Id        string
+	Weight    int64
+	Priority  int64
+	Condition condition.Condition
+	Executor  executor.Executor
+}
+
+type Options struct {
+	Package string // 生成代码的包名
+	Name    string // 生成的 *codegen.Program 变量名，默认为 Filter
+	Source  string // 配置来源，写在生成代码的注释中
+}
+
+type generator struct {
+	buf         bytes.Buffer
+	funcs       bytes.Buffer
+	vars        bytes.Buffer
+	conditions  map[condition.Condition]string
+	executors   map[executor.Executor]string
+	leaves      int
+	groups      int
+	segments    int
+	cached      int
+	unknown     map[condition.Condition]bool // 结果可能未知的条件，参见 condition.ErrUnknown
+	threeValued bool                         // 生成了按照三值逻辑执行的分组，需要引用 condition 包
+}
+
+// Generate 生成实现同样逻辑的Go代码，filters 需要按照优先级排序
+func Generate(filters []Filter, batch bool, opts Options) ([]byte, error) {
+	if opts.Package == "" {
+		return nil, fmt.Errorf("codegen: package name is empty")
+	}
+
+	if opts.Name == "" {
+		opts.Name = "Filter"
+	}
+
+	g := &generator{
+		conditions: make(map[condition.Condition]string),
+		executors:  make(map[executor.Executor]string),
+		unknown:    make(map[condition.Condition]bool),
+	}
+
+	rules := make([]string, 0, len(filters))
+	for index, filter := range filters {
+		cond, err := g.condition(filter.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("filter [%s]: %w", filter.Id, err)
+		}
+
+		execute, err := g.executor(index, filter.Executor)
+		if err != nil {
+			return nil, fmt.Errorf("filter [%s]: %w", filter.Id, err)
+		}
+
+		rules = append(rules, fmt.Sprintf("codegen.Rule{Id: %s, Weight: %d, Priority: %d, Condition: %s, Execute: %s},",
+			strconv.Quote(filter.Id), filter.Weight, filter.Priority, cond, execute))
+	}
+
+	source := ""
+	if opts.Source != "" {
+		source = " from " + opts.Source
+	}
+	fmt.Fprintf(&g.buf, "// Code generated by filtergen%s. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&g.buf, "package %s\n\n", opts.Package)
+	if len(filters) == 0 {
+		g.buf.WriteString("import \"github.com/airunny/filter/codegen\"\n\n")
+	} else {
+		g.buf.WriteString("import (\n\t\"context\"\n\n")
+		g.buf.WriteString("\t\"github.com/airunny/filter/cache\"\n\t\"github.com/airunny/filter/codegen\"\n")
+		if g.threeValued {
+			g.buf.WriteString("\t\"github.com/airunny/filter/condition\"\n")
+		}
+		g.buf.WriteString(")\n\n")
+	}
+	fmt.Fprintf(&g.buf, "var %s = codegen.NewProgram(%t,\n", opts.Name, batch)
+	for _, rule := range rules {
+		fmt.Fprintf(&g.buf, "\t%s\n", rule)
+	}
+	g.buf.WriteString(")\n\n")
+
+	if g.vars.Len() > 0 {
+		g.buf.WriteString("var (\n")
+		g.buf.Write(g.vars.Bytes())
+		g.buf.WriteString(")\n\n")
+	}
+	g.buf.Write(g.funcs.Bytes())
+
+	code, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("codegen: format generated code: %w", err)
+	}
+	return code, nil
+}
+
+// condition 返回执行条件的函数，条件项为变量的 IsConditionOk 方法，分组为生成的函数，同一个条件只生成一次
+func (s *generator) condition(cond condition.Condition) (string, error) {
+	if name, ok := s.conditions[cond]; ok {
+		return name, nil
+	}
+
+	// 配置了 {"cached": true} 的条件跟解释执行一样缓存结果
+	if scope, key, ok := condition.Cached(cond); ok {
+		call, err := s.generate(cond)
+		if err != nil {
+			return "", err
+		}
+
+		name := fmt.Sprintf("cached%d", s.cached)
+		s.cached++
+		s.conditions[cond] = name
+		s.memoize(name, call, scope, key)
+		return name, nil
+	}
+	return s.generate(cond)
+}
+
+func (s *generator) generate(cond condition.Condition) (string, error) {
+	switch c := cond.(type) {
+	case *condition.BaseCondition:
+		// 名单在运行时注册，生成代码的包初始化时还不存在，执行时再查找
+		if list, ok := c.Value().(*lists.List); ok {
+			name := fmt.Sprintf("c%d", s.leaves)
+			s.leaves++
+			fmt.Fprintf(&s.vars, "\t%s = codegen.ListCondition(%s, %s, %s)\n",
+				name, strconv.Quote(c.Key()), strconv.Quote(c.Operation().Name()), strconv.Quote(list.Name()))
+			s.conditions[cond] = name + ".IsConditionOk"
+			return s.conditions[cond], nil
+		}
+
+		value, err := literal(c.Raw())
+		if err != nil {
+			return "", err
+		}
+
+		name := fmt.Sprintf("c%d", s.leaves)
+		s.leaves++
+		fmt.Fprintf(&s.vars, "\t%s = codegen.MustCondition(%s, %s, %s)\n",
+			name, strconv.Quote(c.Key()), strconv.Quote(c.Operation().Name()), value)
+		s.conditions[cond] = name + ".IsConditionOk"
+		return s.conditions[cond], nil
+	case *condition.Quantifier:
+		// 元素的条件在包初始化时构建，不能引用分群以及名单
+		if containsSegment(c.Condition()) {
+			return "", fmt.Errorf("codegen: segment in [%s] condition is not supported", c.Name())
+		}
+		if containsList(c.Condition()) {
+			return "", fmt.Errorf("codegen: list in [%s] condition is not supported", c.Name())
+		}
+
+		value, err := literal(c.Raw())
+		if err != nil {
+			return "", err
+		}
+
+		name := fmt.Sprintf("c%d", s.leaves)
+		s.leaves++
+		fmt.Fprintf(&s.vars, "\t%s = codegen.MustCondition(%s, %s, %s)\n",
+			name, strconv.Quote(c.Key()), strconv.Quote(c.Name()), value)
+		s.conditions[cond] = name + ".IsConditionOk"
+		return s.conditions[cond], nil
+	case *condition.Group:
+		return s.group(c)
+	case *condition.Segment:
+		return s.segment(c)
+	}
+	return "", fmt.Errorf("codegen: unsupported condition type %T", cond)
+}
+
+func containsSegment(cond condition.Condition) bool {
+	switch c := cond.(type) {
+	case *condition.Segment:
+		return true
+	case *condition.Quantifier:
+		return containsSegment(c.Condition())
+	case *condition.Group:
+		for _, child := range c.Conditions() {
+			if containsSegment(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsList(cond condition.Condition) bool {
+	switch c := cond.(type) {
+	case *condition.BaseCondition:
+		_, ok := c.Value().(*lists.List)
+		return ok
+	case *condition.Quantifier:
+		return containsList(c.Condition())
+	case *condition.Group:
+		for _, child := range c.Conditions() {
+			if containsList(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var scopeNames = map[cache.Scope]string{
+	cache.ScopeRequest: "cache.ScopeRequest",
+	cache.ScopeData:    "cache.ScopeData",
+}
+
+// segment 分群生成为单独的函数，结果跟解释执行一样缓存在 cache.Cache 中
+func (s *generator) segment(segment *condition.Segment) (string, error) {
+	call, err := s.condition(segment.Condition())
+	if err != nil {
+		return "", err
+	}
+
+	scope, key := segment.Key()
+	name := fmt.Sprintf("segment%d", s.segments)
+	s.segments++
+	s.conditions[segment] = name
+	s.memoize(name, call, scope, key)
+	return name, nil
+}
+
+// memoize 生成把 call 的结果缓存在 cache.Cache 中的函数
+func (s *generator) memoize(name, call string, scope cache.Scope, key string) {
+	fmt.Fprintf(&s.funcs, "func %s(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {\n", name)
+	fmt.Fprintf(&s.funcs, "\tif value, ok := c.GetScoped(%s, %s); ok {\n\t\treturn value.(bool), nil\n\t}\n", scopeNames[scope], strconv.Quote(key))
+	fmt.Fprintf(&s.funcs, "\tok, err := %s(ctx, data, c)\n", call)
+	fmt.Fprintf(&s.funcs, "\tif err == nil {\n\t\tc.SetScoped(%s, %s, ok)\n\t}\n", scopeNames[scope], strconv.Quote(key))
+	s.funcs.WriteString("\treturn ok, err\n}\n\n")
+}
+
+func (s *generator) group(group *condition.Group) (string, error) {
+	calls := make([]string, 0, len(group.Conditions()))
+	for _, child := range group.Conditions() {
+		name, err := s.condition(child)
+		if err != nil {
+			return "", err
+		}
+		calls = append(calls, name+"(ctx, data, c)")
+	}
+
+	var body bytes.Buffer
+	switch {
+	case s.mayBeUnknown(group):
+		if _, ok := logicNames[group.Logic()]; !ok {
+			return "", fmt.Errorf("codegen: unsupported group logic [%d]", group.Logic())
+		}
+		s.threeValued = true
+		threeValued(&body, group.Logic(), group.Threshold(), calls)
+	case group.Logic() == condition.LogicAnd:
+		for _, call := range calls {
+			fmt.Fprintf(&body, "\tif ok, err := %s; err != nil || !ok {\n\t\treturn false, err\n\t}\n", call)
+		}
+		body.WriteString("\treturn true, nil\n")
+	case group.Logic() == condition.LogicOr:
+		for _, call := range calls {
+			fmt.Fprintf(&body, "\tif ok, err := %s; err != nil || ok {\n\t\treturn err == nil, err\n\t}\n", call)
+		}
+		fmt.Fprintf(&body, "\treturn %t, nil\n", len(calls) == 0)
+	case group.Logic() == condition.LogicNot:
+		for _, call := range calls {
+			fmt.Fprintf(&body, "\tif ok, err := %s; err != nil || ok {\n\t\treturn false, err\n\t}\n", call)
+		}
+		body.WriteString("\treturn true, nil\n")
+	case group.Logic() == condition.LogicNand:
+		for _, call := range calls {
+			fmt.Fprintf(&body, "\tif ok, err := %s; err != nil || !ok {\n\t\treturn err == nil, err\n\t}\n", call)
+		}
+		body.WriteString("\treturn false, nil\n")
+	case group.Logic() == condition.LogicXor, group.Logic() == condition.LogicAtLeast, group.Logic() == condition.LogicAtMost:
+		count(&body, group.Logic(), group.Threshold(), calls)
+	default:
+		return "", fmt.Errorf("codegen: unsupported group logic [%d]", group.Logic())
+	}
+
+	name := fmt.Sprintf("group%d", s.groups)
+	s.groups++
+	s.conditions[group] = name
+	fmt.Fprintf(&s.funcs, "func %s(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {\n", name)
+	s.funcs.Write(body.Bytes())
+	s.funcs.WriteString("}\n\n")
+	return name, nil
+}
+
+// count 生成统计成立的子条件数量的分组，结果确定之后不再执行剩余的子条件
+func count(body *bytes.Buffer, logic condition.Logic, threshold int, calls []string) {
+	// decide 根据成立的数量n以及剩余的子条件数量生成提前返回的判断，已经执行了 evaluated 个子条件，n 不会大于 evaluated
+	decide := func(evaluated, remaining int) {
+		switch logic {
+		case condition.LogicXor:
+			if evaluated > 1 {
+				body.WriteString("\tif n > 1 {\n\t\treturn false, nil\n\t}\n")
+			}
+		case condition.LogicAtLeast:
+			if evaluated >= threshold {
+				fmt.Fprintf(body, "\tif n >= %d {\n\t\treturn true, nil\n\t}\n", threshold)
+			}
+			if remaining < threshold {
+				fmt.Fprintf(body, "\tif n+%d < %d {\n\t\treturn false, nil\n\t}\n", remaining, threshold)
+			}
+		case condition.LogicAtMost:
+			if evaluated > threshold {
+				fmt.Fprintf(body, "\tif n > %d {\n\t\treturn false, nil\n\t}\n", threshold)
+			}
+			if remaining <= threshold {
+				fmt.Fprintf(body, "\tif n+%d <= %d {\n\t\treturn true, nil\n\t}\n", remaining, threshold)
+			}
+		}
+	}
+
+	body.WriteString("\tn := 0\n")
+	for index, call := range calls {
+		decide(index, len(calls)-index)
+		fmt.Fprintf(body, "\tif ok, err := %s; err != nil {\n\t\treturn false, err\n\t} else if ok {\n\t\tn++\n\t}\n", call)
+	}
+
+	switch logic {
+	case condition.LogicXor:
+		body.WriteString("\treturn n == 1, nil\n")
+	case condition.LogicAtLeast:
+		fmt.Fprintf(body, "\treturn n >= %d, nil\n", threshold)
+	case condition.LogicAtMost:
+		fmt.Fprintf(body, "\treturn n <= %d, nil\n", threshold)
+	}
+}
+
+var logicNames = map[condition.Logic]string{
+	condition.LogicAnd:     "condition.LogicAnd",
+	condition.LogicOr:      "condition.LogicOr",
+	condition.LogicNot:     "condition.LogicNot",
+	condition.LogicXor:     "condition.LogicXor",
+	condition.LogicNand:    "condition.LogicNand",
+	condition.LogicAtLeast: "condition.LogicAtLeast",
+	condition.LogicAtMost:  "condition.LogicAtMost",
+}
+
+// mayBeUnknown 条件中是否有缺失策略为 variables.MissingUnknown 的条件项，这些条件的结果可能未知
+func (s *generator) mayBeUnknown(cond condition.Condition) bool {
+	if unknown, ok := s.unknown[cond]; ok {
+		return unknown
+	}
+
+	unknown := false
+	switch c := cond.(type) {
+	case *condition.BaseCondition:
+		unknown = c.Missing().Action == variables.MissingUnknown
+	case *condition.Segment:
+		unknown = s.mayBeUnknown(c.Condition())
+	case *condition.Quantifier:
+		unknown = c.Missing().Action == variables.MissingUnknown || s.mayBeUnknown(c.Condition())
+	case *condition.Group:
+		for _, child := range c.Conditions() {
+			if s.mayBeUnknown(child) {
+				unknown = true
+				break
+			}
+		}
+	}
+	s.unknown[cond] = unknown
+	return unknown
+}
+
+// threeValued 生成按照三值逻辑执行的分组，分别统计成立以及结果未知的子条件数量，使用 condition.Decide 判断结果
+func threeValued(body *bytes.Buffer, logic condition.Logic, threshold int, calls []string) {
+	decide := fmt.Sprintf("condition.Decide(%s, %d, %d", logicNames[logic], threshold, len(calls))
+	body.WriteString("\tn, u := 0, 0\n")
+	for index, call := range calls {
+		if index > 0 {
+			fmt.Fprintf(body, "\tif ok, known := %s, n, n+u+%d); known {\n\t\treturn ok, nil\n\t}\n", decide, len(calls)-index)
+		}
+		fmt.Fprintf(body, "\tif ok, err := %s; condition.IsUnknown(err) {\n\t\tu++\n\t} else if err != nil {\n\t\treturn false, err\n\t} else if ok {\n\t\tn++\n\t}\n", call)
+	}
+	fmt.Fprintf(body, "\tif ok, known := %s, n, n+u); known {\n\t\treturn ok, nil\n\t}\n", decide)
+	body.WriteString("\treturn false, condition.ErrUnknown\n")
+}
+
+// executor 生成filter的执行函数，分组中的执行项按照顺序执行，返回错误时停止
+func (s *generator) executor(index int, exec executor.Executor) (string, error) {
+	var names []string
+	var walk func(exec executor.Executor) error
+	walk = func(exec executor.Executor) error {
+		switch e := exec.(type) {
+		case *executor.BaseExecutor:
+			name, ok := s.executors[exec]
+			if !ok {
+				value, err := literal(e.Raw())
+				if err != nil {
+					return err
+				}
+
+				name = fmt.Sprintf("e%d", len(s.executors))
+				fmt.Fprintf(&s.vars, "\t%s = codegen.MustExecutor(%s, %s, %s)\n",
+					name, strconv.Quote(e.Key()), strconv.Quote(e.Assignment().Name()), value)
+				s.executors[exec] = name
+			}
+			names = append(names, name)
+		case *executor.Group:
+			for _, child := range e.Executors() {
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("codegen: unsupported executor type %T", exec)
+		}
+		return nil
+	}
+
+	if err := walk(exec); err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("execute%d", index)
+	fmt.Fprintf(&s.funcs, "func %s(ctx context.Context, data interface{}) error {\n", name)
+	for _, executorName := range names {
+		fmt.Fprintf(&s.funcs, "\tif err := %s.Execute(ctx, data); err != nil {\n\t\treturn err\n\t}\n", executorName)
+	}
+	s.funcs.WriteString("\treturn nil\n}\n\n")
+	return name, nil
+}
+
+// literal 把配置中的值转换为Go代码，保留原有的类型
+func literal(value interface{}) (string, error) {
+	if value == nil {
+		return "nil", nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return fmt.Sprintf("float64(%s)", strconv.FormatFloat(v, 'g', -1, 64)), nil
+	case []interface{}:
+		var buf bytes.Buffer
+		buf.WriteString("[]interface{}{")
+		for index, element := range v {
+			code, err := literal(element)
+			if err != nil {
+				return "", err
+			}
+
+			if index > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(code)
+		}
+		buf.WriteString("}")
+		return buf.String(), nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var buf bytes.Buffer
+		buf.WriteString("map[string]interface{}{")
+		for index, key := range keys {
+			code, err := literal(v[key])
+			if err != nil {
+				return "", err
+			}
+
+			if index > 0 {
+				buf.WriteString(", ")
+			}
+			fmt.Fprintf(&buf, "%s: %s", strconv.Quote(key), code)
+		}
+		buf.WriteString("}")
+		return buf.String(), nil
+	}
+
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32:
+		if reflect.TypeOf(value).PkgPath() == "" {
+			return fmt.Sprintf("%s(%v)", reflect.TypeOf(value), value), nil
+		}
+	}
+	return "", fmt.Errorf("codegen: unsupported value type %T", value)
+}
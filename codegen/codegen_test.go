@@ -0,0 +1,226 @@
+package codegen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/condition"
+	filterContext "github.com/airunny/filter/context"
+	"github.com/airunny/filter/executor"
+	"github.com/airunny/filter/lists"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiteral(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, "nil"},
+		{"a\"b", `"a\"b"`},
+		{true, "true"},
+		{float64(1), "float64(1)"},
+		{0.5, "float64(0.5)"},
+		{int64(3), "int64(3)"},
+		{uint8(3), "uint8(3)"},
+		{[]interface{}{"a", float64(1), nil}, `[]interface{}{"a", float64(1), nil}`},
+		{map[string]interface{}{"b": "x", "a": []interface{}{}}, `map[string]interface{}{"a": []interface{}{}, "b": "x"}`},
+	}
+
+	for _, c := range cases {
+		code, err := literal(c.value)
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, code)
+	}
+
+	_, err := literal(struct{}{})
+	assert.Equal(t, "codegen: unsupported value type struct {}", err.Error())
+}
+
+func TestGenerate(t *testing.T) {
+	ctx := context.Background()
+	cond, err := condition.BuildCondition(ctx, []interface{}{
+		[]interface{}{"or", "=>", []interface{}{
+			[]interface{}{"platform", "=", "ios"},
+			[]interface{}{"not", "=>", []interface{}{[]interface{}{"uid", ">", 10}}},
+		}},
+	}, condition.LogicAnd)
+	assert.Nil(t, err)
+
+	exec, err := executor.BuildExecutor(ctx, []interface{}{[]interface{}{"a", "=", 1}, []interface{}{"b", "del", ""}})
+	assert.Nil(t, err)
+
+	code, err := Generate([]Filter{{Id: "1", Priority: 1, Condition: cond, Executor: exec}}, false, Options{Package: "rules"})
+	assert.Nil(t, err)
+	for _, expected := range []string{
+		"var Filter = codegen.NewProgram(false,",
+		`codegen.Rule{Id: "1", Weight: 0, Priority: 1, Condition: group2, Execute: execute0},`,
+		`c0 = codegen.MustCondition("platform", "=", "ios")`,
+		`c1 = codegen.MustCondition("uid", ">", int(10))`,
+		`e0 = codegen.MustExecutor("a", "=", int(1))`,
+		"return err == nil, err",
+	} {
+		assert.True(t, strings.Contains(string(code), expected), expected)
+	}
+
+	// 结果可能未知的分组按照三值逻辑生成
+	cond, err = condition.BuildCondition(ctx, []interface{}{
+		[]interface{}{"not", "=>", []interface{}{[]interface{}{"version?", "vgt", "3.0"}}},
+		[]interface{}{"platform?false", "=", "ios"},
+	}, condition.LogicAnd)
+	assert.Nil(t, err)
+
+	code, err = Generate([]Filter{{Id: "1", Priority: 1, Condition: cond, Executor: exec}}, false, Options{Package: "rules"})
+	assert.Nil(t, err)
+	for _, expected := range []string{
+		"\"github.com/airunny/filter/condition\"",
+		`c0 = codegen.MustCondition("version?", "vgt", "3.0")`,
+		`c1 = codegen.MustCondition("platform?false", "=", "ios")`,
+		"condition.Decide(condition.LogicNot, 0, 1, n, n+u)",
+		"condition.Decide(condition.LogicAnd, 0, 2, n, n+u+1)",
+		"return false, condition.ErrUnknown",
+	} {
+		assert.True(t, strings.Contains(string(code), expected), expected)
+	}
+
+	// 量词条件作为一个叶子生成
+	cond, err = condition.BuildCondition(ctx, []interface{}{
+		[]interface{}{"data.orders", "anyof", []interface{}{[]interface{}{"status", "=", "paid"}}},
+	}, condition.LogicAnd)
+	assert.Nil(t, err)
+
+	code, err = Generate([]Filter{{Id: "1", Priority: 1, Condition: cond, Executor: exec}}, false, Options{Package: "rules"})
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(code), `c0 = codegen.MustCondition("data.orders", "anyof", []interface{}{[]interface{}{"status", "=", "paid"}})`), string(code))
+
+	// {"cached": true} 的条件生成为缓存结果的函数
+	cond, err = condition.BuildCondition(ctx, []interface{}{
+		[]interface{}{"or", "=>", []interface{}{[]interface{}{"data.age", ">", 18}, []interface{}{"uid", ">", 10}}, map[string]interface{}{"cached": true}},
+	}, condition.LogicAnd)
+	assert.Nil(t, err)
+	scope, key, ok := condition.Cached(cond.(*condition.Group).Conditions()[0])
+	assert.True(t, ok)
+	assert.Equal(t, cache.ScopeData, scope)
+
+	code, err = Generate([]Filter{{Id: "1", Priority: 1, Condition: cond, Executor: exec}}, false, Options{Package: "rules"})
+	assert.Nil(t, err)
+	for _, expected := range []string{
+		"if ok, err := cached0(ctx, data, c); err != nil || !ok {",
+		fmt.Sprintf("if value, ok := c.GetScoped(cache.ScopeData, %q); ok {", key),
+		"ok, err := group0(ctx, data, c)",
+	} {
+		assert.True(t, strings.Contains(string(code), expected), expected)
+	}
+
+	// 名单在运行时注册，执行时再查找
+	registry := lists.NewRegistry()
+	_, err = registry.Register(ctx, "codegen_list", lists.ProviderFunc(func(context.Context) ([]string, error) {
+		return []string{"1"}, nil
+	}))
+	assert.Nil(t, err)
+	listCtx := lists.NewContext(ctx, registry)
+	cond, err = condition.BuildCondition(listCtx, []interface{}{"uid", "in", "@list:codegen_list"}, condition.LogicAnd)
+	assert.Nil(t, err)
+	code, err = Generate([]Filter{{Id: "1", Priority: 1, Condition: cond, Executor: exec}}, false, Options{Package: "rules"})
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(code), `c0 = codegen.ListCondition("uid", "in", "codegen_list")`), string(code))
+
+	// 量词中的条件在包初始化时构建，不能引用名单
+	cond, err = condition.BuildCondition(listCtx, []interface{}{
+		"data.orders", "anyof", []interface{}{[]interface{}{"status", "in", "@list:codegen_list"}},
+	}, condition.LogicAnd)
+	assert.Nil(t, err)
+	_, err = Generate([]Filter{{Id: "1", Priority: 1, Condition: cond, Executor: exec}}, false, Options{Package: "rules"})
+	assert.EqualError(t, err, "filter [1]: codegen: list in [anyof] condition is not supported")
+
+	_, err = Generate(nil, false, Options{})
+	assert.Equal(t, "codegen: package name is empty", err.Error())
+
+	code, err = Generate(nil, true, Options{Package: "rules", Name: "Rules"})
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(code), "var Rules = codegen.NewProgram(true)"))
+}
+
+func TestListCondition(t *testing.T) {
+	ctx := context.Background()
+	registry := lists.NewRegistry()
+	values := []string{"1001"}
+	list, err := registry.Register(ctx, "codegen_whitelist", lists.ProviderFunc(func(context.Context) ([]string, error) {
+		return values, nil
+	}))
+	assert.Nil(t, err)
+
+	cond := ListCondition("uid", "in", "codegen_whitelist")
+	_, err = cond.IsConditionOk(filterContext.WithUserID(ctx, 1001), nil, cache.NewCache())
+	assert.EqualError(t, err, "condition operand not exists list [codegen_whitelist]")
+
+	listCtx := lists.NewContext(ctx, registry)
+	ok, err := cond.IsConditionOk(filterContext.WithUserID(listCtx, 1001), nil, cache.NewCache())
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// 重新加载名单之后不需要重新构建
+	values = []string{"1002"}
+	assert.Nil(t, list.Reload(ctx))
+	ok, err = cond.IsConditionOk(filterContext.WithUserID(listCtx, 1001), nil, cache.NewCache())
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func rule(id string, priority int64, ok bool, key string) Rule {
+	return Rule{
+		Id:       id,
+		Priority: priority,
+		Condition: func(context.Context, interface{}, *cache.Cache) (bool, error) {
+			return ok, nil
+		},
+		Execute: func(_ context.Context, data interface{}) error {
+			data.(map[string]interface{})[key] = id
+			return nil
+		},
+	}
+}
+
+func TestProgram(t *testing.T) {
+	program := NewProgram(true, rule("1", 1, true, "a"), rule("2", 1, false, "b"), rule("3", 2, true, "a"))
+	assert.Equal(t, []int{2, 3}, program.boundaries)
+
+	number, ids, err := program.Run(context.Background(), map[string]interface{}{}, cache.NewCache())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, number)
+	assert.Equal(t, []string{"1", "3"}, ids)
+
+	data, err := NewProgram(false, rule("1", 1, false, "a"), rule("2", 2, true, "a"), rule("3", 3, true, "a")).Execute(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "2"}, data)
+
+	// 权重为0的filter排在最后
+	weighted := NewProgram(true, rule("1", 1, true, "a"), rule("2", 1, true, "b"))
+	weighted.rules[1].Weight = 1
+	weighted.weights[0] = 1
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, []int{1, 0}, weighted.order())
+	}
+}
+
+type runnerFunc func(ctx context.Context, data interface{}) (interface{}, error)
+
+func (f runnerFunc) Execute(ctx context.Context, data interface{}) (interface{}, error) {
+	return f(ctx, data)
+}
+
+func TestConform(t *testing.T) {
+	program := NewProgram(true, rule("1", 1, true, "a"))
+	cases := []Case{{Data: map[string]interface{}{"b": 1}}, {}}
+	assert.Nil(t, Conform(program, program, cases))
+
+	failed := runnerFunc(func(context.Context, interface{}) (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	assert.Equal(t, "case 0: interpreter returned (map[a:1 b:1], <nil>) but generated code returned (<nil>, failed)",
+		Conform(program, failed, cases).Error())
+}
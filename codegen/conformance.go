@@ -0,0 +1,53 @@
+package codegen
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/airunny/filter/utils"
+)
+
+// Runner filter.Filter 以及 Program 都实现了该接口
+type Runner interface {
+	Execute(ctx context.Context, data interface{}) (interface{}, error)
+}
+
+// Case 一次对比执行的请求，Data 在每次执行之前都会复制
+type Case struct {
+	Ctx  context.Context
+	Data map[string]interface{}
+}
+
+// Conform 使用相同的请求分别执行解释器以及生成的代码，返回第一处结果不一致的请求。
+// 配置中有权重或者使用了 rand 等随机变量时每次执行的结果可能不同，不适合直接对比
+func Conform(interpreter, generated Runner, cases []Case) error {
+	for index, c := range cases {
+		ctx := c.Ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		want, wantErr := interpreter.Execute(ctx, clone(c.Data))
+		got, gotErr := generated.Execute(ctx, clone(c.Data))
+		if errorString(wantErr) != errorString(gotErr) || !reflect.DeepEqual(want, got) {
+			return fmt.Errorf("case %d: interpreter returned (%v, %v) but generated code returned (%v, %v)",
+				index, want, wantErr, got, gotErr)
+		}
+	}
+	return nil
+}
+
+func clone(data map[string]interface{}) interface{} {
+	if data == nil {
+		return nil
+	}
+	return utils.Clone(data)
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
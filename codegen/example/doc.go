@@ -0,0 +1,4 @@
+// Package example 由 rules.json 生成的过滤器，用于演示代码生成并且跟解释执行的结果做一致性检查
+package example
+
+//go:generate go run github.com/airunny/filter/cmd/filtergen -in rules.json -out rules.go -pkg example
@@ -0,0 +1,333 @@
+// Code generated by filtergen from rules.json. DO NOT EDIT.
+
+package example
+
+import (
+	"context"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/codegen"
+	"github.com/airunny/filter/condition"
+)
+
+var Filter = codegen.NewProgram(true,
+	codegen.Rule{Id: "ios-new", Weight: 0, Priority: 1, Condition: group0, Execute: execute0},
+	codegen.Rule{Id: "chrome", Weight: 0, Priority: 1, Condition: group2, Execute: execute1},
+	codegen.Rule{Id: "adult", Weight: 0, Priority: 2, Condition: group6, Execute: execute2},
+	codegen.Rule{Id: "engaged", Weight: 0, Priority: 2, Condition: group11, Execute: execute3},
+	codegen.Rule{Id: "cleanup", Weight: 0, Priority: 3, Condition: group12, Execute: execute4},
+)
+
+var (
+	c0  = codegen.MustCondition("platform?false", "=", "ios")
+	c1  = codegen.MustCondition("version?=1.0.0", "vgte", "2.0")
+	e0  = codegen.MustExecutor("banner", "=", "new")
+	e1  = codegen.MustExecutor("discount", "=", float64(0.8))
+	c2  = codegen.MustCondition("ua", "~", "/(?i)chrome/")
+	c3  = codegen.MustCondition("channel", "in", []interface{}{"store", "ads"})
+	c4  = codegen.MustCondition("ip", "iir", []interface{}{"10.0.0.0/8"})
+	e2  = codegen.MustExecutor("browser", "=", "chrome")
+	c5  = codegen.MustCondition("platform", "=", "ios")
+	c6  = codegen.MustCondition("data.age", "between", []interface{}{float64(18), float64(60)})
+	c7  = codegen.MustCondition("data.tags?", "has", []interface{}{"blocked"})
+	e3  = codegen.MustExecutor("level", "=", "adult")
+	c8  = codegen.MustCondition("channel", "=", "ads")
+	c9  = codegen.MustCondition("data.age", ">=", float64(30))
+	c10 = codegen.MustCondition("ua", "~", "/Safari/")
+	c11 = codegen.MustCondition("version?", "vgte", "3.0")
+	c12 = codegen.MustCondition("channel", "=", "push")
+	c13 = codegen.MustCondition("platform?", "=", "android")
+	e4  = codegen.MustExecutor("engaged", "=", true)
+	c14 = codegen.MustCondition("data.age", "<", float64(18))
+	e5  = codegen.MustExecutor("discount", "del", "")
+)
+
+func group0(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	if ok, err := c0.IsConditionOk(ctx, data, c); err != nil || !ok {
+		return false, err
+	}
+	if ok, err := c1.IsConditionOk(ctx, data, c); err != nil || !ok {
+		return false, err
+	}
+	return true, nil
+}
+
+func execute0(ctx context.Context, data interface{}) error {
+	if err := e0.Execute(ctx, data); err != nil {
+		return err
+	}
+	if err := e1.Execute(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func group1(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	if ok, err := c3.IsConditionOk(ctx, data, c); err != nil || ok {
+		return err == nil, err
+	}
+	if ok, err := c4.IsConditionOk(ctx, data, c); err != nil || ok {
+		return err == nil, err
+	}
+	return false, nil
+}
+
+func group2(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	if ok, err := c2.IsConditionOk(ctx, data, c); err != nil || !ok {
+		return false, err
+	}
+	if ok, err := group1(ctx, data, c); err != nil || !ok {
+		return false, err
+	}
+	return true, nil
+}
+
+func execute1(ctx context.Context, data interface{}) error {
+	if err := e2.Execute(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func group3(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	if ok, err := c6.IsConditionOk(ctx, data, c); err != nil || !ok {
+		return false, err
+	}
+	return true, nil
+}
+
+func segment0(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	if value, ok := c.GetScoped(cache.ScopeData, "\x00segment.adult"); ok {
+		return value.(bool), nil
+	}
+	ok, err := group3(ctx, data, c)
+	if err == nil {
+		c.SetScoped(cache.ScopeData, "\x00segment.adult", ok)
+	}
+	return ok, err
+}
+
+func group4(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	if ok, err := c5.IsConditionOk(ctx, data, c); err != nil || !ok {
+		return false, err
+	}
+	if ok, err := segment0(ctx, data, c); err != nil || !ok {
+		return false, err
+	}
+	return true, nil
+}
+
+func segment1(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	if value, ok := c.GetScoped(cache.ScopeData, "\x00segment.ios_adult"); ok {
+		return value.(bool), nil
+	}
+	ok, err := group4(ctx, data, c)
+	if err == nil {
+		c.SetScoped(cache.ScopeData, "\x00segment.ios_adult", ok)
+	}
+	return ok, err
+}
+
+func group5(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	n, u := 0, 0
+	if ok, err := c7.IsConditionOk(ctx, data, c); condition.IsUnknown(err) {
+		u++
+	} else if err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	if ok, known := condition.Decide(condition.LogicNot, 0, 1, n, n+u); known {
+		return ok, nil
+	}
+	return false, condition.ErrUnknown
+}
+
+func group6(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	n, u := 0, 0
+	if ok, err := segment1(ctx, data, c); condition.IsUnknown(err) {
+		u++
+	} else if err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	if ok, known := condition.Decide(condition.LogicAnd, 0, 2, n, n+u+1); known {
+		return ok, nil
+	}
+	if ok, err := group5(ctx, data, c); condition.IsUnknown(err) {
+		u++
+	} else if err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	if ok, known := condition.Decide(condition.LogicAnd, 0, 2, n, n+u); known {
+		return ok, nil
+	}
+	return false, condition.ErrUnknown
+}
+
+func execute2(ctx context.Context, data interface{}) error {
+	if err := e3.Execute(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func group7(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	n := 0
+	if ok, err := c5.IsConditionOk(ctx, data, c); err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	if ok, err := c8.IsConditionOk(ctx, data, c); err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	if n >= 2 {
+		return true, nil
+	}
+	if n+1 < 2 {
+		return false, nil
+	}
+	if ok, err := c9.IsConditionOk(ctx, data, c); err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	return n >= 2, nil
+}
+
+func group8(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	n := 0
+	if ok, err := c10.IsConditionOk(ctx, data, c); err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	if ok, err := c4.IsConditionOk(ctx, data, c); err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	return n == 1, nil
+}
+
+func group9(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	n, u := 0, 0
+	if ok, err := c11.IsConditionOk(ctx, data, c); condition.IsUnknown(err) {
+		u++
+	} else if err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	if ok, known := condition.Decide(condition.LogicAtMost, 1, 2, n, n+u+1); known {
+		return ok, nil
+	}
+	if ok, err := segment0(ctx, data, c); condition.IsUnknown(err) {
+		u++
+	} else if err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	if ok, known := condition.Decide(condition.LogicAtMost, 1, 2, n, n+u); known {
+		return ok, nil
+	}
+	return false, condition.ErrUnknown
+}
+
+func group10(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	n, u := 0, 0
+	if ok, err := c12.IsConditionOk(ctx, data, c); condition.IsUnknown(err) {
+		u++
+	} else if err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	if ok, known := condition.Decide(condition.LogicNand, 0, 2, n, n+u+1); known {
+		return ok, nil
+	}
+	if ok, err := c13.IsConditionOk(ctx, data, c); condition.IsUnknown(err) {
+		u++
+	} else if err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	if ok, known := condition.Decide(condition.LogicNand, 0, 2, n, n+u); known {
+		return ok, nil
+	}
+	return false, condition.ErrUnknown
+}
+
+func group11(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	n, u := 0, 0
+	if ok, err := group7(ctx, data, c); condition.IsUnknown(err) {
+		u++
+	} else if err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	if ok, known := condition.Decide(condition.LogicAnd, 0, 4, n, n+u+3); known {
+		return ok, nil
+	}
+	if ok, err := group8(ctx, data, c); condition.IsUnknown(err) {
+		u++
+	} else if err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	if ok, known := condition.Decide(condition.LogicAnd, 0, 4, n, n+u+2); known {
+		return ok, nil
+	}
+	if ok, err := group9(ctx, data, c); condition.IsUnknown(err) {
+		u++
+	} else if err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	if ok, known := condition.Decide(condition.LogicAnd, 0, 4, n, n+u+1); known {
+		return ok, nil
+	}
+	if ok, err := group10(ctx, data, c); condition.IsUnknown(err) {
+		u++
+	} else if err != nil {
+		return false, err
+	} else if ok {
+		n++
+	}
+	if ok, known := condition.Decide(condition.LogicAnd, 0, 4, n, n+u); known {
+		return ok, nil
+	}
+	return false, condition.ErrUnknown
+}
+
+func execute3(ctx context.Context, data interface{}) error {
+	if err := e4.Execute(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func group12(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	if ok, err := c14.IsConditionOk(ctx, data, c); err != nil || !ok {
+		return false, err
+	}
+	return true, nil
+}
+
+func execute4(ctx context.Context, data interface{}) error {
+	if err := e5.Execute(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package example
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/airunny/filter"
+	"github.com/airunny/filter/codegen"
+	filterContext "github.com/airunny/filter/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadConfig(t *testing.T) *filter.Config {
+	content, err := os.ReadFile("rules.json")
+	assert.Nil(t, err)
+
+	cnf, err := filter.ParseConfig(string(content))
+	assert.Nil(t, err)
+	return cnf
+}
+
+// TestGenerated 配置修改之后需要执行 go generate 重新生成代码
+func TestGenerated(t *testing.T) {
+	code, err := loadConfig(t).Generate(context.Background(), codegen.Options{
+		Package: "example",
+		Source:  "rules.json",
+	})
+	assert.Nil(t, err)
+
+	generated, err := os.ReadFile("rules.go")
+	assert.Nil(t, err)
+	assert.Equal(t, string(code), string(generated))
+}
+
+func TestConformance(t *testing.T) {
+	interpreter, err := filter.NewFilterFromConfig(context.Background(), loadConfig(t), nil)
+	assert.Nil(t, err)
+
+	var (
+		r     = rand.New(rand.NewSource(1))
+		cases []codegen.Case
+	)
+	for i := 0; i < 1000; i++ {
+		ctx := context.Background()
+		if r.Intn(10) != 0 {
+			ctx = filterContext.WithPlatform(ctx, []string{"ios", "android"}[r.Intn(2)])
+		}
+		if r.Intn(10) != 0 {
+			ctx = filterContext.WithVersion(ctx, fmt.Sprintf("%d.%d", r.Intn(4), r.Intn(3)))
+		}
+		ctx = filterContext.WithUA(ctx, []string{"Mozilla/5.0 Chrome/120.0", "Safari", "chrome-lite"}[r.Intn(3)])
+		ctx = filterContext.WithChannel(ctx, []string{"store", "ads", "push"}[r.Intn(3)])
+		ctx = filterContext.WithIP(ctx, fmt.Sprintf("%d.1.2.3", []int{10, 192}[r.Intn(2)]))
+
+		data := map[string]interface{}{
+			"age":      float64(r.Intn(80)),
+			"discount": 1,
+		}
+		if r.Intn(2) == 0 {
+			data["tags"] = []interface{}{"vip", []string{"blocked", "new"}[r.Intn(2)]}
+		}
+		cases = append(cases, codegen.Case{Ctx: ctx, Data: data})
+	}
+
+	assert.Nil(t, codegen.Conform(interpreter, Filter, cases))
+}
@@ -0,0 +1,213 @@
+package codegen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/condition"
+	"github.com/airunny/filter/executor"
+	"github.com/airunny/filter/hook"
+	"github.com/airunny/filter/lists"
+)
+
+// MustCondition 生成代码中的条件项，跟配置中的 [variable, operation, value] 一致，构建失败时panic
+func MustCondition(variable, operation string, value interface{}) condition.Condition {
+	cond, err := condition.BuildCondition(context.Background(), []interface{}{variable, operation, value}, condition.LogicAnd)
+	if err != nil {
+		panic(fmt.Sprintf("codegen: condition [%s %s %v]: %v", variable, operation, value, err))
+	}
+	return cond
+}
+
+// ListCondition 生成代码中引用名单的条件项，例如 ["uid", "in", "@list:whitelist"]。名单在运行时注册，
+// 执行时通过 lists.Lookup 查找，可以使用 lists.NewContext 指定注册表，构建的条件按照名单缓存
+func ListCondition(variable, operation, name string) *ListItem {
+	return &ListItem{
+		variable:  variable,
+		operation: operation,
+		name:      name,
+	}
+}
+
+type ListItem struct {
+	variable  string
+	operation string
+	name      string
+	built     sync.Map // *lists.List -> condition.Condition
+}
+
+func (s *ListItem) IsConditionOk(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	list, ok := lists.Lookup(ctx, s.name)
+	if !ok {
+		return false, fmt.Errorf("condition operand not exists list [%s]", s.name)
+	}
+
+	cond, ok := s.built.Load(list)
+	if !ok {
+		// 使用执行时的ctx构建，跟上面查找的是同一个注册表
+		built, err := condition.BuildCondition(ctx, []interface{}{s.variable, s.operation, lists.Prefix + s.name}, condition.LogicAnd)
+		if err != nil {
+			return false, err
+		}
+		cond, _ = s.built.LoadOrStore(list, built)
+	}
+	return cond.(condition.Condition).IsConditionOk(ctx, data, c)
+}
+
+// MustExecutor 生成代码中的执行项，跟配置中的 [key, assignment, value] 一致，构建失败时panic
+func MustExecutor(key, assignment string, value interface{}) executor.Executor {
+	exec, err := executor.BuildExecutor(context.Background(), []interface{}{key, assignment, value})
+	if err != nil {
+		panic(fmt.Sprintf("codegen: executor [%s %s %v]: %v", key, assignment, value, err))
+	}
+	return exec
+}
+
+// Rule 生成代码中的单个filter
+type Rule struct {
+	Id        string
+	Weight    int64
+	Priority  int64
+	Condition func(ctx context.Context, data interface{}, c *cache.Cache) (bool, error)
+	Execute   func(ctx context.Context, data interface{}) error
+}
+
+func (s *Rule) run(ctx context.Context, data interface{}, c *cache.Cache) (ok bool, err error) {
+	if h, exists := hook.FromContext(ctx); exists {
+		ctx = h.BeforeFilter(ctx, s.Id, data)
+		defer func() {
+			h.AfterFilter(ctx, s.Id, data, ok, err)
+		}()
+	}
+
+	ok, err = s.Condition(ctx, data, c)
+	// 结果未知时filter不成立
+	if condition.IsUnknown(err) {
+		return false, nil
+	}
+
+	if err != nil || !ok {
+		return false, err
+	}
+
+	err = s.Execute(ctx, data)
+	// 赋值之后依赖data的缓存失效
+	c.Invalidate(cache.ScopeData)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Program 生成代码中的过滤器，执行结果跟使用同一份配置构建的 filter.Filter 一致
+type Program struct {
+	rules      []Rule
+	batch      bool
+	boundaries []int   // 每个优先级最后一个filter之后的位置
+	weights    []int64 // 每个优先级的权重之和
+}
+
+// NewProgram rules 需要已经按照优先级排序，生成的代码会保证这一点
+func NewProgram(batch bool, rules ...Rule) *Program {
+	program := &Program{
+		rules: rules,
+		batch: batch,
+	}
+
+	for index, rule := range rules {
+		if index == 0 || rule.Priority != rules[index-1].Priority {
+			if index != 0 {
+				program.boundaries = append(program.boundaries, index)
+			}
+			program.weights = append(program.weights, 0)
+		}
+		program.weights[len(program.weights)-1] += rule.Weight
+	}
+	program.boundaries = append(program.boundaries, len(rules))
+	return program
+}
+
+// order 执行顺序，同一个优先级中按照权重打乱
+func (s *Program) order() []int {
+	order := make([]int, len(s.rules))
+	for index := range order {
+		order[index] = index
+	}
+
+	last := 0
+	for index, boundary := range s.boundaries {
+		if s.weights[index] > 0 {
+			s.shuffle(order[last:boundary], s.weights[index])
+		}
+		last = boundary
+	}
+	return order
+}
+
+func (s *Program) shuffle(order []int, totalWeight int64) {
+	// 剩余的filter权重都为0时保持原有顺序
+	for current := 0; current < len(order)-1 && totalWeight > 0; current++ {
+		var (
+			choose = rand.Int63n(totalWeight) + 1
+			line   = int64(0)
+			chosen = current
+		)
+
+		for index := current; index < len(order); index++ {
+			line += s.rules[order[index]].Weight
+			if choose <= line {
+				chosen = index
+				break
+			}
+		}
+
+		totalWeight -= s.rules[order[chosen]].Weight
+		order[current], order[chosen] = order[chosen], order[current]
+	}
+}
+
+func (s *Program) Run(ctx context.Context, data interface{}, c *cache.Cache) (successNumber int, filterIds []string, err error) {
+	for _, index := range s.order() {
+		rule := &s.rules[index]
+		var ok bool
+		ok, err = rule.run(ctx, data, c)
+		if err != nil {
+			return
+		}
+
+		if !ok {
+			continue
+		}
+
+		filterIds = append(filterIds, rule.Id)
+		successNumber++
+		if !s.batch {
+			break
+		}
+	}
+	return
+}
+
+// Execute 跟 filter.Filter.Execute 一致，data为nil时使用空map
+func (s *Program) Execute(ctx context.Context, data interface{}) (interface{}, error) {
+	if s == nil {
+		return nil, errors.New("invalid Program")
+	}
+
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+
+	c := cache.Acquire()
+	defer cache.Release(c)
+
+	_, _, err := s.Run(ctx, data, c)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
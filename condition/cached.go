@@ -0,0 +1,80 @@
+package condition
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/airunny/filter/cache"
+)
+
+// cachedPrefix 配置了 {"cached": true} 的条件在 cache.Cache 中的key前缀，之后为条件结构的哈希
+const cachedPrefix = "\x00cached."
+
+// ConditionOptions 条件项的第四个元素，例如 ["data.age", ">", 18, {"cached": true}]
+type ConditionOptions struct {
+	// Cached 参见 GroupOptions.Cached
+	Cached bool
+}
+
+func ParseConditionOptions(value interface{}) (ConditionOptions, error) {
+	var opts ConditionOptions
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return opts, fmt.Errorf("condition options [%v] is not object", value)
+	}
+
+	for key, v := range values {
+		switch key {
+		case "cached":
+			cached, ok := v.(bool)
+			if !ok {
+				return opts, fmt.Errorf("condition option [%s] should be bool", key)
+			}
+			opts.Cached = cached
+		default:
+			return opts, fmt.Errorf("unknown condition option [%s]", key)
+		}
+	}
+	return opts, nil
+}
+
+// cacheResult 把条件的结果缓存在 cache.Cache 中，key为条件结构的哈希，所有filter中结构相同的条件共享同一个结果，
+// 作用域为条件依赖的变量中最大的作用域，依赖 data.、calc. 等不可缓存变量的结果在赋值之后重新计算
+func cacheResult(cond Condition, key string) Condition {
+	sum := sha1.Sum([]byte(key))
+	setMemo(cond, cachedPrefix+hex.EncodeToString(sum[:]), scopeOf(cond))
+	return cond
+}
+
+func setMemo(cond Condition, memo string, scope cache.Scope) {
+	switch c := cond.(type) {
+	case *BaseCondition:
+		c.memo, c.memoScope = memo, scope
+	case *Group:
+		c.memo, c.memoScope = memo, scope
+	case *Quantifier:
+		c.memo, c.memoScope = memo, scope
+	}
+}
+
+func memoOf(cond Condition) (string, cache.Scope) {
+	switch c := cond.(type) {
+	case *BaseCondition:
+		return c.memo, c.memoScope
+	case *Group:
+		return c.memo, c.memoScope
+	case *Quantifier:
+		return c.memo, c.memoScope
+	}
+	return "", cache.ScopeRequest
+}
+
+// Cached 配置了 {"cached": true} 的条件的结果在 cache.Cache 中的作用域以及key
+func Cached(cond Condition) (cache.Scope, string, bool) {
+	memo, scope := memoOf(cond)
+	if len(memo) < len(cachedPrefix) || memo[:len(cachedPrefix)] != cachedPrefix {
+		return scope, "", false
+	}
+	return scope, memo, true
+}
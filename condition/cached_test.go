@@ -0,0 +1,105 @@
+package condition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/hook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCached(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		adult = []interface{}{"or", "=>", []interface{}{
+			[]interface{}{"data.age", ">=", 18},
+			[]interface{}{"data.vip?false", "=", true},
+		}, map[string]interface{}{"cached": true}}
+	)
+
+	// 两个filter中结构相同的条件共享同一个结果
+	var conditions []Condition
+	for _, items := range [][]interface{}{
+		{adult, []interface{}{"platform?true", "=", "ios"}},
+		{[]interface{}{"uid?true", ">", 0}, adult},
+	} {
+		cond, err := BuildCondition(ctx, items, LogicAnd)
+		assert.Nil(t, err)
+		conditions = append(conditions, cond)
+	}
+
+	scope, key, ok := Cached(conditions[0].(*Group).Conditions()[0])
+	assert.True(t, ok)
+	assert.Equal(t, cache.ScopeData, scope)
+	scope2, key2, ok := Cached(conditions[1].(*Group).Conditions()[1])
+	assert.True(t, ok)
+	assert.Equal(t, scope, scope2)
+	assert.Equal(t, key, key2)
+
+	var (
+		h    = &valueHook{}
+		c    = cache.NewCache()
+		data = map[string]interface{}{"age": 20}
+	)
+	for _, cond := range conditions {
+		ok, err := cond.IsConditionOk(hook.NewContext(ctx, h), data, c)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	}
+	// data.age 只执行一次
+	assert.Equal(t, 3, len(h.values))
+
+	// 赋值之后重新计算
+	data["age"] = 10
+	c.Invalidate(cache.ScopeData)
+	ok, err := conditions[1].IsConditionOk(ctx, data, c)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// 条件项
+	cond, err := BuildCondition(ctx, []interface{}{"data.age", ">=", 18, map[string]interface{}{"cached": true}}, LogicAnd)
+	assert.Nil(t, err)
+	_, _, ok = Cached(cond)
+	assert.True(t, ok)
+
+	cond, err = BuildCondition(ctx, []interface{}{"data.age", ">=", 18}, LogicAnd)
+	assert.Nil(t, err)
+	_, _, ok = Cached(cond)
+	assert.False(t, ok)
+
+	for _, tt := range []struct {
+		Items []interface{}
+		Err   string
+	}{
+		{Items: []interface{}{"data.age", ">=", 18, map[string]interface{}{"cached": 1}}, Err: "condition [data.age]: condition option [cached] should be bool"},
+		{Items: []interface{}{"data.age", ">=", 18, map[string]interface{}{"ordered": true}}, Err: "condition [data.age]: unknown condition option [ordered]"},
+		{Items: []interface{}{"data.age", ">=", 18, true}, Err: "condition [data.age]: condition options [true] is not object"},
+		{Items: []interface{}{"and", "=>", []interface{}{}, map[string]interface{}{"cached": "true"}}, Err: "group condition [and]: group option [cached] should be bool"},
+	} {
+		_, err = BuildCondition(ctx, tt.Items, LogicAnd)
+		assert.EqualError(t, err, tt.Err)
+	}
+}
+
+func TestInternCached(t *testing.T) {
+	in := NewInterner()
+	ctx := WithInterner(context.Background(), in)
+
+	var conditions []Condition
+	for _, items := range [][]interface{}{
+		{"platform", "=", "ios"},
+		{"platform", "=", "ios", map[string]interface{}{"cached": true}},
+	} {
+		cond, err := BuildCondition(ctx, items, LogicAnd)
+		assert.Nil(t, err)
+		conditions = append(conditions, cond)
+	}
+	assert.Same(t, conditions[0], conditions[1])
+	in.Finish()
+
+	// Finish 不会覆盖 {"cached": true} 的key
+	scope, _, ok := Cached(conditions[0])
+	assert.True(t, ok)
+	assert.Equal(t, cache.ScopeRequest, scope)
+}
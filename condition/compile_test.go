@@ -0,0 +1,81 @@
+package condition
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/airunny/filter/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompiledCondition(t *testing.T) {
+	conditions := [][]interface{}{
+		{"data.v", "=", 1},
+		{"data.v", "=", "a"},
+		{"data.v", "eq", true},
+		{"data.v", ">", "1"},
+		{"data.v", "gt", 1.5},
+		{"data.v", ">=", 2},
+		{"data.v", "<", "b"},
+		{"data.v", "<=", 0},
+		{"data.v", "between", []interface{}{1, "2"}},
+		{"data.v", "in", []interface{}{"a", 1, "2"}},
+		{"data.v", "in", "a,b,1"},
+		{"data.v", "nin", []interface{}{"a", 2}},
+		{"data.v", "~", "/^a/"},
+		{"data.v", "~", "b"},
+		{"data.v", "!~", "/^a/"},
+	}
+	values := []interface{}{
+		nil, "", "a", "b", "ab", "1", "2", "1,2", "a,1", `["a",1]`, `null`,
+		0, 1, 2, 1.5, true, false, []interface{}{"a", 1}, map[string]interface{}{"a": 1},
+	}
+
+	for _, items := range conditions {
+		cond, err := BuildCondition(context.Background(), items, LogicAnd)
+		assert.Nil(t, err)
+
+		base := cond.(*BaseCondition)
+		assert.NotNil(t, base.matcher, fmt.Sprint(items))
+		for _, value := range values {
+			data := map[string]interface{}{"v": value}
+			compiledOk, compiledErr := base.IsConditionOk(context.Background(), data, cache.NewCache())
+			ok, err := base.operation.Run(context.Background(), base.variable, base.value, data, cache.NewCache())
+			assert.Equal(t, err, compiledErr, fmt.Sprintf("%v %#v", items, value))
+			assert.Equal(t, ok, compiledOk, fmt.Sprintf("%v %#v", items, value))
+		}
+	}
+
+	// 未实现 Compiler 的操作仍然使用 Run
+	cond, err := BuildCondition(context.Background(), []interface{}{"data.v", "any", []interface{}{1}}, LogicAnd)
+	assert.Nil(t, err)
+	assert.Nil(t, cond.(*BaseCondition).matcher)
+}
+
+func BenchmarkBaseCondition(b *testing.B) {
+	conditions := map[string][]interface{}{
+		"eq": {"data.v", "=", 10},
+		"in": {"data.v", "in", []interface{}{"a", "b", "c", "d", "e", "f", "g", "h"}},
+	}
+	data := map[string]interface{}{"v": "h"}
+
+	for name, items := range conditions {
+		cond, err := BuildCondition(context.Background(), items, LogicAnd)
+		if err != nil {
+			b.Fatal(err)
+		}
+		base := cond.(*BaseCondition)
+
+		b.Run(name+"-run", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = base.operation.Run(context.Background(), base.variable, base.value, data, nil)
+			}
+		})
+		b.Run(name+"-compiled", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = base.IsConditionOk(context.Background(), data, nil)
+			}
+		})
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/hook"
 	"github.com/airunny/filter/operations"
 	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/variables"
@@ -64,23 +66,122 @@ type Logic int
 const (
 	LogicAnd Logic = iota
 	LogicOr
-	LogicNot
+	LogicNot     // 所有子条件均不成立
+	LogicXor     // 有且只有一个子条件成立
+	LogicNand    // 至少一个子条件不成立
+	LogicAtLeast // 至少 Group.Threshold 个子条件成立，例如 ["atleast:2", "=>", [...]]
+	LogicAtMost  // 最多 Group.Threshold 个子条件成立，例如 ["atmost:1", "=>", [...]]
+
+	LogicNor = LogicNot
 )
 
 var groupLogicKeys = map[string]Logic{
-	"and": LogicAnd,
-	"or":  LogicOr,
-	"not": LogicNot,
+	"and":  LogicAnd,
+	"or":   LogicOr,
+	"not":  LogicNot,
+	"nor":  LogicNor,
+	"xor":  LogicXor,
+	"nand": LogicNand,
+}
+
+var thresholdLogicKeys = map[string]Logic{
+	"atleast": LogicAtLeast,
+	"atmost":  LogicAtMost,
+}
+
+// ParseLogic 解析分组条件的逻辑，atleast:N、atmost:N 同时返回N，key不是分组逻辑时ok为false
+func ParseLogic(key string) (logic Logic, threshold int, ok bool, err error) {
+	key = strings.ToLower(key)
+	if logic, ok = groupLogicKeys[key]; ok {
+		return logic, 0, true, nil
+	}
+
+	name, n, found := strings.Cut(key, ":")
+	if logic, ok = thresholdLogicKeys[name]; !ok || !found {
+		return 0, 0, false, nil
+	}
+
+	threshold, err = strconv.Atoi(strings.TrimSpace(n))
+	if err != nil || threshold < 0 {
+		return logic, 0, true, fmt.Errorf("group condition [%s] threshold should be non-negative integer", key)
+	}
+	return logic, threshold, true, nil
 }
 
 type BaseCondition struct {
 	variable  variables.Variable
 	operation operations.Operation
 	value     interface{}
+	raw       interface{}
+	memo      string // 不为空时结果缓存在 cache.Cache 中，参见 Interner
+	memoScope cache.Scope
+	matcher   operations.Matcher // 操作实现了 operations.Compiler 时编译之后的结果
+	operand   variables.Variable // 比较值引用的变量，参见 ParseOperand
+	prepared  *cache.Shared      // 比较值引用变量时准备之后的结果，参见 operandValue
+	source    variables.Variable // 执行时取值的变量，缺失策略为 variables.MissingDefault 时返回默认值
+
+	missingPolicy variables.MissingPolicy
 }
 
+func (s *BaseCondition) Variable() variables.Variable    { return s.variable }
+func (s *BaseCondition) Operation() operations.Operation { return s.operation }
+func (s *BaseCondition) Value() interface{}              { return s.value }
+
+// Operand 比较值引用的变量，比较值为普通的值时为nil
+func (s *BaseCondition) Operand() variables.Variable { return s.operand }
+
+// Missing 变量不存在时的策略，来自变量名的?后缀或者 variables.SetMissing
+func (s *BaseCondition) Missing() variables.MissingPolicy { return s.missingPolicy }
+
+// Key 配置中的变量名，缺失策略不是 variables.MissingError 时带有?后缀
+func (s *BaseCondition) Key() string { return missingKey(s.variable.Name(), s.missingPolicy) }
+
+// Raw 配置中未经过 PrepareValue 的比较值
+func (s *BaseCondition) Raw() interface{} { return s.raw }
+
 func (s *BaseCondition) IsConditionOk(ctx context.Context, data interface{}, cache *cache.Cache) (bool, error) {
-	return s.operation.Run(ctx, s.variable, s.value, data, cache)
+	if s.memo == "" {
+		return s.run(ctx, data, cache)
+	}
+
+	if value, ok := cache.GetScoped(s.memoScope, s.memo); ok {
+		return value.(bool), nil
+	}
+
+	ok, err := s.run(ctx, data, cache)
+	if err == nil {
+		cache.SetScoped(s.memoScope, s.memo, ok)
+	}
+	return ok, err
+}
+
+func (s *BaseCondition) run(ctx context.Context, data interface{}, cache *cache.Cache) (bool, error) {
+	var (
+		ok             bool
+		err            error
+		operationValue = s.value
+	)
+	switch {
+	case s.operand != nil:
+		operationValue, err = s.operandValue(ctx, data, cache)
+		if err == nil {
+			ok, err = s.operation.Run(ctx, s.source, operationValue, data, cache)
+		}
+	case s.matcher != nil:
+		var value interface{}
+		value, err = variables.GetValue(ctx, s.source, data, cache)
+		if err == nil {
+			ok, err = s.matcher(value)
+		}
+	default:
+		ok, err = s.operation.Run(ctx, s.source, s.value, data, cache)
+	}
+	ok, err = missingResult(s.missingPolicy, ok, err)
+
+	if h, exists := hook.FromContext(ctx); exists {
+		h.OnCondition(ctx, s.variable.Name(), s.operation.Name(), operationValue, ok, err)
+	}
+	return ok, err
 }
 
 func BuildCondition(ctx context.Context, items []interface{}, logic Logic) (Condition, error) {
@@ -93,6 +194,25 @@ func BuildCondition(ctx context.Context, items []interface{}, logic Logic) (Cond
 		return BuildGroup(ctx, items, logic)
 	}
 
+	key, _ := items[0].(string)
+	if logicKey, threshold, ok, err := ParseLogic(key); ok && (len(items) == 3 || len(items) == 4) {
+		if err != nil {
+			return nil, err
+		}
+		return buildLogicGroup(ctx, key, logicKey, threshold, items)
+	}
+
+	// 第四个元素为可选的 ConditionOptions
+	var opts ConditionOptions
+	if len(items) == 4 && types.IsString(items[0]) {
+		var err error
+		opts, err = ParseConditionOptions(items[3])
+		if err != nil {
+			return nil, fmt.Errorf("condition [%s]: %w", key, err)
+		}
+		items = items[:3]
+	}
+
 	if len(items) != 3 {
 		return nil, errors.New("condition item must contains three element")
 	}
@@ -101,17 +221,36 @@ func BuildCondition(ctx context.Context, items []interface{}, logic Logic) (Cond
 		return nil, fmt.Errorf("condition item 1st element[%v] is not string", items[0])
 	}
 
-	key := items[0].(string)
-	if logicKey, ok := groupLogicKeys[strings.ToLower(key)]; ok {
-		if !types.IsArray(items[2]) {
-			return nil, fmt.Errorf("group condition [%s] 3rd element is not array", key)
-		}
-		return BuildCondition(ctx, items[2].([]interface{}), logicKey)
+	cond, err := buildItem(ctx, key, items)
+	if err != nil || !opts.Cached {
+		return cond, err
+	}
+
+	cacheKey, ok := baseKey(items)
+	if !ok {
+		return nil, fmt.Errorf("condition [%s] can't be cached, value can't be marshaled", key)
+	}
+	return cacheResult(cond, cacheKey), nil
+}
+
+// buildItem 构建 [变量, 操作, 比较值] 形式的条件项
+func buildItem(ctx context.Context, key string, items []interface{}) (Condition, error) {
+
+	if key == SegmentVariable {
+		return buildSegment(ctx, items)
+	}
+
+	name, missing, ok := ParseMissing(key)
+	if !ok {
+		missing = variables.MissingOf(name)
 	}
 
-	variable, ok := variables.Get(key)
+	variable, ok := variables.Get(name)
 	if !ok {
-		return nil, fmt.Errorf("condition not exists variable [%s]", key)
+		variable, ok = elementVariable(ctx, name)
+	}
+	if !ok {
+		return nil, fmt.Errorf("condition not exists variable [%s]", name)
 	}
 
 	if !types.IsString(items[1]) {
@@ -119,19 +258,65 @@ func BuildCondition(ctx context.Context, items []interface{}, logic Logic) (Cond
 	}
 
 	operationName := items[1].(string)
+	if IsQuantifier(operationName) {
+		return buildQuantifier(ctx, key, variable, missing, items)
+	}
+
 	operation, ok := operations.Get(operationName)
 	if !ok {
 		return nil, fmt.Errorf("condition not exists operation [%s]", operationName)
 	}
 
-	operationValue, err := operation.PrepareValue(items[2])
+	var (
+		in        = interner(ctx)
+		internKey string
+		shared    bool
+	)
+	if in != nil {
+		internKey, shared = baseKey(items)
+	}
+	if shared {
+		if cond, ok := in.lookup(internKey); ok {
+			return cond, nil
+		}
+	}
+
+	operand, value, err := parseOperand(ctx, items[2])
 	if err != nil {
 		return nil, err
 	}
 
-	return &BaseCondition{
+	if err = checkSignature(name, operation, operand, value); err != nil {
+		return nil, err
+	}
+
+	cond := &BaseCondition{
 		variable:  variable,
 		operation: operation,
-		value:     operationValue,
-	}, nil
+		raw:       items[2],
+		operand:   operand,
+		source:    variable,
+
+		missingPolicy: missing,
+	}
+	if missing.Action == variables.MissingDefault {
+		cond.source = &defaultVariable{Variable: variable, value: missing.Default}
+	}
+	if operand != nil {
+		cond.prepared = cache.NewShared(cache.WithSize(operandCacheSize))
+	} else {
+		cond.value, err = operation.PrepareValue(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if compiler, ok := operation.(operations.Compiler); ok {
+			cond.matcher = compiler.Compile(cond.value)
+		}
+	}
+
+	if !shared {
+		return cond, nil
+	}
+	return in.add(internKey, cond), nil
 }
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/airunny/filter/cache"
+	filterContext "github.com/airunny/filter/context"
 	_ "github.com/airunny/filter/operations/equal"
 	_ "github.com/airunny/filter/operations/greater_than"
 	_ "github.com/airunny/filter/operations/less_than"
@@ -377,3 +378,44 @@ func TestBuildCondition(t *testing.T) {
 		}
 	}
 }
+
+func TestNegatedOperation(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		version = filterContext.WithVersion(ctx, "3.1")
+		data    = map[string]interface{}{"age": float64(20), "tags": []interface{}{"vip"}}
+	)
+
+	cases := []struct {
+		Items []interface{}
+		Ctx   context.Context
+		OK    bool
+		Err   string
+	}{
+		{Items: []interface{}{"data.age", "!between", []interface{}{18, 60}}, Ctx: ctx, OK: false},
+		{Items: []interface{}{"data.age", "!between", []interface{}{30, 60}}, Ctx: ctx, OK: true},
+		{Items: []interface{}{"data.tags", "!has", []interface{}{"blocked"}}, Ctx: ctx, OK: true},
+		{Items: []interface{}{"data.age", "!>", 18}, Ctx: ctx, OK: false},
+		{Items: []interface{}{"version", "!vgt", "3.0"}, Ctx: version, OK: false},
+		// 取值失败时不会取反为成立
+		{Items: []interface{}{"version", "!vgt", "3.0"}, Ctx: ctx, Err: "version not found in context"},
+		{Items: []interface{}{"version?false", "!vgt", "3.0"}, Ctx: ctx, OK: false},
+	}
+
+	for index, tt := range cases {
+		cond, err := BuildCondition(ctx, tt.Items, LogicAnd)
+		assert.Nil(t, err, index)
+		assert.Equal(t, tt.Items[1], cond.(*BaseCondition).Operation().Name(), index)
+
+		ok, err := cond.IsConditionOk(tt.Ctx, data, cache.NewCache())
+		if tt.Err != "" {
+			assert.EqualError(t, err, tt.Err, index)
+			continue
+		}
+		assert.Nil(t, err, index)
+		assert.Equal(t, tt.OK, ok, index)
+	}
+
+	_, err := BuildCondition(ctx, []interface{}{"data.age", "!golang", 1}, LogicAnd)
+	assert.EqualError(t, err, "condition not exists operation [!golang]")
+}
@@ -2,7 +2,9 @@ package condition
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/types"
@@ -17,56 +19,158 @@ func NewGroup(logic Logic) *Group {
 
 type Group struct {
 	logic      Logic
+	threshold  int // LogicAtLeast、LogicAtMost 的子条件数量
 	conditions []Condition
+	ordered    bool
+	memo       string // 不为空时结果缓存在 cache.Cache 中，参见 Interner
+	memoScope  cache.Scope
 }
 
+func (s *Group) Logic() Logic            { return s.logic }
+func (s *Group) Threshold() int          { return s.threshold }
+func (s *Group) Conditions() []Condition { return s.conditions }
+func (s *Group) Ordered() bool           { return s.ordered }
+
 func (s *Group) Add(condition Condition) {
 	s.conditions = append(s.conditions, condition)
 }
 
 func (s *Group) IsConditionOk(ctx context.Context, data interface{}, cache *cache.Cache) (bool, error) {
-	result := true
-	for _, condition := range s.conditions {
-		ok, err := condition.IsConditionOk(ctx, data, cache)
+	if s.memo == "" {
+		return s.run(ctx, data, cache)
+	}
+
+	if value, ok := cache.GetScoped(s.memoScope, s.memo); ok {
+		return value.(bool), nil
+	}
+
+	ok, err := s.run(ctx, data, cache)
+	if err == nil {
+		cache.SetScoped(s.memoScope, s.memo, ok)
+	}
+	return ok, err
+}
+
+func (s *Group) run(ctx context.Context, data interface{}, cache *cache.Cache) (bool, error) {
+	var (
+		total     = len(s.conditions)
+		satisfied = 0
+		unknown   = 0
+	)
+	for index := 0; ; index++ {
+		if result, known := Decide(s.logic, s.threshold, total, satisfied, satisfied+unknown+total-index); known {
+			return result, nil
+		}
+
+		// 所有子条件都执行完之后结果仍然无法确定
+		if index == total {
+			return false, ErrUnknown
+		}
+
+		ok, err := s.conditions[index].IsConditionOk(ctx, data, cache)
+		if IsUnknown(err) {
+			unknown++
+			continue
+		}
+
 		if err != nil {
 			return false, err
 		}
 
 		if ok {
-			if s.logic == LogicAnd {
-				continue
-			}
+			satisfied++
+		}
+	}
+}
 
-			if s.logic == LogicOr {
-				result = true
-				break
-			}
+// GroupOptions 分组条件的第四个元素，例如 ["and", "=>", [...], {"ordered": true}]
+type GroupOptions struct {
+	// Ordered 按照配置顺序执行子条件，不参与按照代价重排
+	Ordered bool
+	// Cached 结果缓存在 cache.Cache 中，同一次执行中所有filter里结构相同的条件只计算一次，
+	// 用于依赖 data.、calc. 等不可缓存变量并且被多处引用的条件，依赖data的结果在赋值之后重新计算
+	Cached bool
+}
 
-			if s.logic == LogicNot {
-				result = false
-				break
+func ParseGroupOptions(value interface{}) (GroupOptions, error) {
+	var opts GroupOptions
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return opts, fmt.Errorf("group options [%v] is not object", value)
+	}
+
+	for key, v := range values {
+		switch key {
+		case "ordered":
+			ordered, ok := v.(bool)
+			if !ok {
+				return opts, fmt.Errorf("group option [%s] should be bool", key)
 			}
-		} else {
-			if s.logic == LogicAnd {
-				result = false
-				break
+			opts.Ordered = ordered
+		case "cached":
+			cached, ok := v.(bool)
+			if !ok {
+				return opts, fmt.Errorf("group option [%s] should be bool", key)
 			}
+			opts.Cached = cached
+		default:
+			return opts, fmt.Errorf("unknown group option [%s]", key)
+		}
+	}
+	return opts, nil
+}
 
-			if s.logic == LogicOr {
-				result = false
-				continue
-			}
+// buildLogicGroup 构建 ["and", "=>", [...]] 形式的条件，第四个元素为可选的 GroupOptions
+func buildLogicGroup(ctx context.Context, key string, logic Logic, threshold int, items []interface{}) (Condition, error) {
+	children, ok := items[2].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("group condition [%s] 3rd element is not array", key)
+	}
 
-			if s.logic == LogicNot {
-				continue
-			}
+	var opts GroupOptions
+	if len(items) == 4 {
+		var err error
+		opts, err = ParseGroupOptions(items[3])
+		if err != nil {
+			return nil, fmt.Errorf("group condition [%s]: %w", key, err)
+		}
+	}
+
+	if len(children) > 0 && types.IsArray(children[0]) {
+		if threshold > len(children) {
+			return nil, fmt.Errorf("group condition [%s] threshold is greater than the number of conditions %d", key, len(children))
+		}
+		cond, err := buildGroup(ctx, children, logic, threshold, opts)
+		if err != nil || !opts.Cached {
+			return cond, err
 		}
+		return cachedGroup(key, logic, threshold, children, cond)
+	}
+
+	cond, err := BuildCondition(ctx, children, logic)
+	if err != nil || !opts.Cached {
+		return cond, err
 	}
-	return result, nil
+	return cachedGroup(key, logic, threshold, children, cond)
+}
+
+// cachedGroup 开启分组的结果缓存，结构相同指逻辑、阈值以及子条件的配置相同
+func cachedGroup(key string, logic Logic, threshold int, children []interface{}, cond Condition) (Condition, error) {
+	value, err := json.Marshal(children)
+	if err != nil {
+		return nil, fmt.Errorf("group condition [%s] can't be cached: %w", key, err)
+	}
+	return cacheResult(cond, fmt.Sprintf("%d:%d %s", logic, threshold, value)), nil
 }
 
 func BuildGroup(ctx context.Context, items []interface{}, logic Logic) (Condition, error) {
+	return buildGroup(ctx, items, logic, 0, GroupOptions{})
+}
+
+func buildGroup(ctx context.Context, items []interface{}, logic Logic, threshold int, opts GroupOptions) (Condition, error) {
 	group := NewGroup(logic)
+	group.threshold = threshold
+	group.ordered = opts.Ordered
 	for _, item := range items {
 		if !types.IsArray(item) {
 			return nil, errors.New("condition item is not array")
@@ -78,5 +182,5 @@ func BuildGroup(ctx context.Context, items []interface{}, logic Logic) (Conditio
 		}
 		group.Add(subCondition)
 	}
-	return group, nil
+	return interner(ctx).internGroup(group), nil
 }
@@ -122,3 +122,125 @@ func TestBuildGroup(t *testing.T) {
 		}
 	}
 }
+
+func TestGroupOptions(t *testing.T) {
+	ctx := context.Background()
+	children := []interface{}{
+		[]interface{}{"success", "=", 1},
+		[]interface{}{"timestamp", ">", 1},
+	}
+
+	cond, err := BuildCondition(ctx, []interface{}{"or", "=>", children, map[string]interface{}{"ordered": true}}, LogicAnd)
+	assert.Nil(t, err)
+	group, ok := cond.(*Group)
+	assert.True(t, ok)
+	assert.Equal(t, LogicOr, group.Logic())
+	assert.True(t, group.Ordered())
+
+	cond, err = BuildCondition(ctx, []interface{}{"and", "=>", children}, LogicAnd)
+	assert.Nil(t, err)
+	assert.False(t, cond.(*Group).Ordered())
+
+	_, err = BuildCondition(ctx, []interface{}{"and", "=>", children, "ordered"}, LogicAnd)
+	assert.EqualError(t, err, "group condition [and]: group options [ordered] is not object")
+
+	_, err = BuildCondition(ctx, []interface{}{"and", "=>", children, map[string]interface{}{"ordered": 1}}, LogicAnd)
+	assert.EqualError(t, err, "group condition [and]: group option [ordered] should be bool")
+
+	_, err = BuildCondition(ctx, []interface{}{"and", "=>", children, map[string]interface{}{"unknown": true}}, LogicAnd)
+	assert.EqualError(t, err, "group condition [and]: unknown group option [unknown]")
+}
+
+// countCondition 固定返回ok，记录执行次数
+type countCondition struct {
+	ok    bool
+	calls *int
+}
+
+func (s countCondition) IsConditionOk(context.Context, interface{}, *cache.Cache) (bool, error) {
+	*s.calls++
+	return s.ok, nil
+}
+
+func TestGroupLogic(t *testing.T) {
+	cases := []struct {
+		Logic     Logic
+		Threshold int
+		Children  []bool
+		Result    bool
+		Calls     int
+	}{
+		{Logic: LogicAnd, Children: []bool{true, false, true}, Result: false, Calls: 2},
+		{Logic: LogicAnd, Children: nil, Result: true},
+		{Logic: LogicOr, Children: []bool{false, true, false}, Result: true, Calls: 2},
+		{Logic: LogicOr, Children: nil, Result: true},
+		{Logic: LogicNor, Children: []bool{false, false}, Result: true, Calls: 2},
+		{Logic: LogicNor, Children: []bool{true, false}, Result: false, Calls: 1},
+		// xor
+		{Logic: LogicXor, Children: []bool{false, true, false}, Result: true, Calls: 3},
+		{Logic: LogicXor, Children: []bool{true, true, false}, Result: false, Calls: 2},
+		{Logic: LogicXor, Children: []bool{false, false}, Result: false, Calls: 2},
+		// nand
+		{Logic: LogicNand, Children: []bool{true, false, true}, Result: true, Calls: 2},
+		{Logic: LogicNand, Children: []bool{true, true}, Result: false, Calls: 2},
+		{Logic: LogicNand, Children: nil, Result: false},
+		// atleast
+		{Logic: LogicAtLeast, Threshold: 2, Children: []bool{true, true, false, false}, Result: true, Calls: 2},
+		{Logic: LogicAtLeast, Threshold: 2, Children: []bool{false, false, false, true}, Result: false, Calls: 3},
+		{Logic: LogicAtLeast, Threshold: 3, Children: []bool{true, false, true, true, false}, Result: true, Calls: 4},
+		{Logic: LogicAtLeast, Threshold: 0, Children: []bool{false}, Result: true},
+		// atmost
+		{Logic: LogicAtMost, Threshold: 1, Children: []bool{true, false, true, false}, Result: false, Calls: 3},
+		{Logic: LogicAtMost, Threshold: 1, Children: []bool{false, false, true}, Result: true, Calls: 2},
+		{Logic: LogicAtMost, Threshold: 2, Children: []bool{true, true}, Result: true},
+	}
+
+	ctx := context.Background()
+	for index, tt := range cases {
+		var (
+			calls int
+			group = NewGroup(tt.Logic)
+		)
+		group.threshold = tt.Threshold
+		for _, ok := range tt.Children {
+			group.Add(countCondition{ok: ok, calls: &calls})
+		}
+
+		ok, err := group.IsConditionOk(ctx, nil, cache.NewCache())
+		assert.Nil(t, err)
+		assert.Equal(t, tt.Result, ok, index)
+		assert.Equal(t, tt.Calls, calls, index)
+	}
+}
+
+func TestBuildThresholdGroup(t *testing.T) {
+	ctx := context.Background()
+	children := []interface{}{
+		[]interface{}{"success", "=", 1},
+		[]interface{}{"timestamp", "<", 1},
+		[]interface{}{"timestamp", ">", 1},
+	}
+
+	cond, err := BuildCondition(ctx, []interface{}{"AtLeast:2", "=>", children}, LogicAnd)
+	assert.Nil(t, err)
+	assert.Equal(t, LogicAtLeast, cond.(*Group).Logic())
+	assert.Equal(t, 2, cond.(*Group).Threshold())
+	ok, err := cond.IsConditionOk(ctx, nil, cache.NewCache())
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	cond, err = BuildCondition(ctx, []interface{}{"xor", "=>", children}, LogicAnd)
+	assert.Nil(t, err)
+	ok, err = cond.IsConditionOk(ctx, nil, cache.NewCache())
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, err = BuildCondition(ctx, []interface{}{"atmost:4", "=>", children}, LogicAnd)
+	assert.EqualError(t, err, "group condition [atmost:4] threshold is greater than the number of conditions 3")
+
+	_, err = BuildCondition(ctx, []interface{}{"atleast:-1", "=>", children}, LogicAnd)
+	assert.EqualError(t, err, "group condition [atleast:-1] threshold should be non-negative integer")
+
+	_, err = BuildCondition(ctx, []interface{}{"atleast", "=>", children}, LogicAnd)
+	assert.EqualError(t, err, "condition not exists variable [atleast]")
+}
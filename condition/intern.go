@@ -0,0 +1,162 @@
+package condition
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/variables"
+)
+
+type internerKey struct{}
+
+// Interner 在构建同一份配置的所有filter时共享相同的条件，相同的正则等操作数只准备一次。
+// 被多处引用并且只依赖可缓存变量的条件会把结果记录在 cache.Cache 中，每次执行只计算一次，依赖data的结果在赋值之后重新计算，
+// 依赖不可缓存变量的条件可以使用 {"cached": true} 开启，参见 GroupOptions.Cached
+type Interner struct {
+	conditions map[string]Condition
+	entries    map[Condition]*internEntry
+}
+
+type internEntry struct {
+	key       string
+	refs      int
+	cacheable bool        // 只依赖可缓存的变量，同一次执行中结果不会变化
+	scope     cache.Scope // 依赖的变量中最大的缓存作用域，依赖data的结果在赋值之后失效
+}
+
+func NewInterner() *Interner {
+	return &Interner{
+		conditions: make(map[string]Condition),
+		entries:    make(map[Condition]*internEntry),
+	}
+}
+
+func WithInterner(ctx context.Context, interner *Interner) context.Context {
+	return context.WithValue(ctx, internerKey{}, interner)
+}
+
+func interner(ctx context.Context) *Interner {
+	interner, _ := ctx.Value(internerKey{}).(*Interner)
+	return interner
+}
+
+// baseKey 相同变量、操作以及操作数的条件使用同一个key，操作数无法序列化时不共享
+func baseKey(items []interface{}) (string, bool) {
+	value, err := json.Marshal(items[2])
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%q %q %s", items[0], items[1], value), true
+}
+
+// groupKey 分组的key由逻辑、阈值、选项以及子条件的key组成，子条件无法共享时分组也不共享
+func (s *Interner) groupKey(group *Group) (string, bool) {
+	keys := make([]string, 0, len(group.conditions))
+	for _, child := range group.conditions {
+		entry, ok := s.entries[child]
+		if !ok {
+			return "", false
+		}
+		keys = append(keys, entry.key)
+	}
+	return fmt.Sprintf("%d:%d %t (%s)", group.logic, group.threshold, group.ordered, strings.Join(keys, ", ")), true
+}
+
+func (s *Interner) lookup(key string) (Condition, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	cond, ok := s.conditions[key]
+	if ok {
+		s.entries[cond].refs++
+	}
+	return cond, ok
+}
+
+func (s *Interner) add(key string, cond Condition) Condition {
+	if s == nil {
+		return cond
+	}
+
+	if exists, ok := s.lookup(key); ok {
+		return exists
+	}
+
+	entry := &internEntry{key: key, refs: 1, cacheable: true}
+	switch c := cond.(type) {
+	case *BaseCondition:
+		entry.cacheable = c.variable.Cacheable()
+		entry.scope = variables.Scope(c.variable)
+		if c.operand != nil {
+			entry.cacheable = entry.cacheable && c.operand.Cacheable()
+			if scope := variables.Scope(c.operand); scope > entry.scope {
+				entry.scope = scope
+			}
+		}
+	case *Segment:
+		entry.scope = c.scope
+	case *Quantifier:
+		child := s.entries[c.condition]
+		entry.cacheable = c.variable.Cacheable() && child.cacheable
+		entry.scope = variables.Scope(c.variable)
+		if child.scope > entry.scope {
+			entry.scope = child.scope
+		}
+	case *Group:
+		for _, child := range c.conditions {
+			entry.cacheable = entry.cacheable && s.entries[child].cacheable
+			if s.entries[child].scope > entry.scope {
+				entry.scope = s.entries[child].scope
+			}
+		}
+	}
+	s.conditions[key] = cond
+	s.entries[cond] = entry
+	return cond
+}
+
+// internGroup 共享相同的分组，子条件中有无法共享的条件时原样返回
+func (s *Interner) internGroup(group *Group) Condition {
+	if s == nil {
+		return group
+	}
+
+	key, ok := s.groupKey(group)
+	if !ok {
+		return group
+	}
+	return s.add(key, group)
+}
+
+// Finish 所有filter构建完成之后调用，为被多处引用的可缓存条件开启结果缓存
+func (s *Interner) Finish() {
+	if s == nil {
+		return
+	}
+
+	id := 0
+	for cond, entry := range s.entries {
+		// 配置了 {"cached": true} 的条件已经开启了结果缓存
+		if memo, _ := memoOf(cond); entry.refs < 2 || !entry.cacheable || memo != "" {
+			continue
+		}
+
+		id++
+		setMemo(cond, fmt.Sprintf("\x00condition.%d", id), entry.scope)
+	}
+}
+
+// Shared 被多处引用的条件数量
+func (s *Interner) Shared() int {
+	shared := 0
+	for _, entry := range s.entries {
+		if entry.refs > 1 {
+			shared++
+		}
+	}
+	return shared
+}
@@ -0,0 +1,133 @@
+package condition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/airunny/filter/cache"
+	filterContext "github.com/airunny/filter/context"
+	"github.com/airunny/filter/hook"
+	"github.com/stretchr/testify/assert"
+)
+
+type countHook struct {
+	hook.Base
+	conditions int
+}
+
+func (s *countHook) OnCondition(context.Context, string, string, interface{}, bool, error) {
+	s.conditions++
+}
+
+func TestInterner(t *testing.T) {
+	interner := NewInterner()
+	ctx := WithInterner(context.Background(), interner)
+
+	build := func(items []interface{}) Condition {
+		cond, err := BuildCondition(ctx, items, LogicAnd)
+		assert.Nil(t, err)
+		return cond
+	}
+
+	a := build([]interface{}{
+		[]interface{}{"platform", "=", "ios"},
+		[]interface{}{"or", "=>", []interface{}{
+			[]interface{}{"ua", "~", "/(?i)chrome/"},
+			[]interface{}{"uid", ">", 10},
+		}},
+		[]interface{}{"hour", ">", 18},
+	})
+	b := build([]interface{}{
+		[]interface{}{"platform", "=", "ios"},
+		[]interface{}{"or", "=>", []interface{}{
+			[]interface{}{"ua", "~", "/(?i)chrome/"},
+			[]interface{}{"uid", ">", 10},
+		}},
+		[]interface{}{"hour", ">", 18},
+		[]interface{}{"channel", "=", "store"},
+	})
+	c := build([]interface{}{
+		[]interface{}{"or", "=>", []interface{}{
+			[]interface{}{"ua", "~", "/(?i)chrome/"},
+			[]interface{}{"uid", ">", 10},
+		}, map[string]interface{}{"ordered": true}},
+	})
+	interner.Finish()
+
+	groupA, groupB := a.(*Group), b.(*Group)
+	assert.NotSame(t, groupA, groupB)
+	for i := 0; i < 3; i++ {
+		assert.Same(t, groupA.conditions[i], groupB.conditions[i])
+	}
+
+	// 选项不同的分组不共享，子条件仍然共享
+	ordered := c.(*Group).conditions[0].(*Group)
+	assert.NotSame(t, groupA.conditions[1], ordered)
+	assert.Same(t, groupA.conditions[1].(*Group).conditions[0], ordered.conditions[0])
+
+	// 只依赖可缓存变量的共享条件才缓存结果
+	assert.NotEmpty(t, groupA.conditions[0].(*BaseCondition).memo)
+	assert.NotEmpty(t, groupA.conditions[1].(*Group).memo)
+	assert.Equal(t, cache.ScopeRequest, groupA.conditions[0].(*BaseCondition).memoScope)
+	assert.Empty(t, groupA.conditions[2].(*BaseCondition).memo)
+	assert.Empty(t, groupB.conditions[3].(*BaseCondition).memo)
+	assert.Empty(t, groupA.memo)
+
+	// 没有 Interner 时不共享
+	x, err := BuildCondition(context.Background(), []interface{}{"platform", "=", "ios"}, LogicAnd)
+	assert.Nil(t, err)
+	y, err := BuildCondition(context.Background(), []interface{}{"platform", "=", "ios"}, LogicAnd)
+	assert.Nil(t, err)
+	assert.NotSame(t, x, y)
+}
+
+func TestInternerMemo(t *testing.T) {
+	interner := NewInterner()
+	ctx := WithInterner(context.Background(), interner)
+
+	var conditions []Condition
+	for i := 0; i < 3; i++ {
+		cond, err := BuildCondition(ctx, []interface{}{
+			[]interface{}{"platform", "=", "ios"},
+			[]interface{}{"data.age", ">", 18},
+		}, LogicAnd)
+		assert.Nil(t, err)
+		conditions = append(conditions, cond)
+	}
+	interner.Finish()
+	assert.Equal(t, 3, interner.Shared())
+
+	var (
+		rec    = &countHook{}
+		data   = map[string]interface{}{"age": 20}
+		c      = cache.NewCache()
+		runCtx = hook.NewContext(context.Background(), rec)
+	)
+	for _, cond := range conditions {
+		ok, err := cond.IsConditionOk(filterContext.WithPlatform(runCtx, "android"), data, c)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	}
+	// platform 只计算一次，data.age 由于短路不会计算
+	assert.Equal(t, 1, rec.conditions)
+
+	// 每次执行使用新的缓存
+	rec.conditions = 0
+	c = cache.NewCache()
+	for _, cond := range conditions {
+		ok, err := cond.IsConditionOk(filterContext.WithPlatform(runCtx, "ios"), data, c)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, 2, rec.conditions)
+
+	// 赋值之后依赖data的结果重新计算
+	rec.conditions = 0
+	c.Invalidate(cache.ScopeData)
+	for _, cond := range conditions {
+		ok, err := cond.IsConditionOk(filterContext.WithPlatform(runCtx, "ios"), data, c)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, 1, rec.conditions)
+}
@@ -0,0 +1,140 @@
+package condition
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/variables"
+)
+
+// ErrUnknown 条件依赖的变量不存在并且缺失策略为 variables.MissingUnknown，分组按照三值逻辑处理，
+// 例如 and 中有不成立的子条件时结果为不成立，not 的子条件结果未知时结果仍然未知，顶层条件结果未知时filter不成立
+var ErrUnknown = errors.New("condition unknown")
+
+// IsUnknown 条件的结果是否未知
+func IsUnknown(err error) bool {
+	return errors.Is(err, ErrUnknown)
+}
+
+// ParseMissing 解析条件中带有缺失策略的变量名，例如 version?、data.age?false、version?=1.0.0，
+// ?之后不是合法的策略时整个key作为变量名，例如 calc 中的三元表达式
+func ParseMissing(key string) (string, variables.MissingPolicy, bool) {
+	index := strings.LastIndex(key, "?")
+	if index <= 0 {
+		return key, variables.MissingPolicy{}, false
+	}
+
+	policy, err := variables.ParseMissingPolicy(key[index+1:])
+	if err != nil {
+		return key, variables.MissingPolicy{}, false
+	}
+	return key[:index], policy, true
+}
+
+// missingKey 带有缺失策略的变量名，跟 ParseMissing 相反
+func missingKey(name string, policy variables.MissingPolicy) string {
+	if policy.Action == variables.MissingError {
+		return name
+	}
+
+	if policy.Action == variables.MissingUnknown {
+		return name + "?"
+	}
+	return name + "?" + policy.String()
+}
+
+// defaultVariable 变量不存在时返回默认值，默认值不写入缓存，其它条件取值时仍然返回 variables.ErrMissing
+type defaultVariable struct {
+	variables.Variable
+	value interface{}
+}
+
+func (s *defaultVariable) Cacheable() bool { return false }
+func (s *defaultVariable) Cost() int       { return variables.Cost(s.Variable) }
+
+func (s *defaultVariable) Value(ctx context.Context, data interface{}, cache *cache.Cache) (interface{}, error) {
+	value, err := variables.GetValue(ctx, s.Variable, data, cache)
+	if errors.Is(err, variables.ErrMissing) {
+		return s.value, nil
+	}
+	return value, err
+}
+
+// missingResult 按照缺失策略处理变量不存在时的结果，MissingDefault 在取值时已经处理
+func missingResult(policy variables.MissingPolicy, ok bool, err error) (bool, error) {
+	if err == nil || !errors.Is(err, variables.ErrMissing) {
+		return ok, err
+	}
+
+	switch policy.Action {
+	case variables.MissingFalse:
+		return false, nil
+	case variables.MissingTrue:
+		return true, nil
+	case variables.MissingUnknown:
+		return false, ErrUnknown
+	}
+	return ok, err
+}
+
+// Decide 根据成立的子条件数量的范围 [min, max] 判断分组的结果，结果跟范围内的具体数量无关时 known 为true。
+// 已经执行的子条件中成立的数量为 min，max 另外加上结果未知以及还没有执行的子条件数量
+func Decide(logic Logic, threshold, total, min, max int) (result bool, known bool) {
+	switch logic {
+	case LogicAnd:
+		if max < total {
+			return false, true
+		}
+		if min == total {
+			return true, true
+		}
+	case LogicOr:
+		if min > 0 {
+			return true, true
+		}
+		// 空的或条件恒成立
+		if max == 0 {
+			return total == 0, true
+		}
+	case LogicNot:
+		if min > 0 {
+			return false, true
+		}
+		if max == 0 {
+			return true, true
+		}
+	case LogicXor:
+		if min > 1 || max < 1 {
+			return false, true
+		}
+		if min == 1 && max == 1 {
+			return true, true
+		}
+	case LogicNand:
+		if max < total {
+			return true, true
+		}
+		if min == total {
+			return false, true
+		}
+	case LogicAtLeast:
+		if min >= threshold {
+			return true, true
+		}
+		if max < threshold {
+			return false, true
+		}
+	case LogicAtMost:
+		if min > threshold {
+			return false, true
+		}
+		if max <= threshold {
+			return true, true
+		}
+	default:
+		return false, true
+	}
+	return false, false
+}
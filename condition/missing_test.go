@@ -0,0 +1,181 @@
+package condition
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/airunny/filter/cache"
+	filterContext "github.com/airunny/filter/context"
+	"github.com/airunny/filter/variables"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMissing(t *testing.T) {
+	cases := []struct {
+		Key      string
+		Name     string
+		Policy   variables.MissingPolicy
+		Explicit bool
+	}{
+		{Key: "version", Name: "version"},
+		{Key: "version?", Name: "version", Policy: variables.MissingPolicy{Action: variables.MissingUnknown}, Explicit: true},
+		{Key: "data.age?false", Name: "data.age", Policy: variables.MissingPolicy{Action: variables.MissingFalse}, Explicit: true},
+		{Key: "version?=1.0.0", Name: "version", Policy: variables.MissingPolicy{Action: variables.MissingDefault, Default: "1.0.0"}, Explicit: true},
+		{Key: "calc.__a > 1 ? 1 : 0", Name: "calc.__a > 1 ? 1 : 0"},
+		{Key: "?", Name: "?"},
+	}
+
+	for index, tt := range cases {
+		name, policy, explicit := ParseMissing(tt.Key)
+		assert.Equal(t, tt.Name, name, index)
+		assert.Equal(t, tt.Policy, policy, index)
+		assert.Equal(t, tt.Explicit, explicit, index)
+	}
+}
+
+type errorValuer struct{}
+
+func (errorValuer) Value(context.Context, string) (interface{}, error) {
+	return nil, errors.New("valuer failed")
+}
+
+func TestMissingPolicy(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		version = filterContext.WithVersion(ctx, "3.1")
+		data    = map[string]interface{}{"age": float64(20)}
+	)
+
+	cases := []struct {
+		Items []interface{}
+		Ctx   context.Context
+		Data  interface{}
+		OK    bool
+		Err   string
+	}{
+		{Items: []interface{}{"version", "vgt", "3.0"}, Ctx: version, OK: true},
+		{Items: []interface{}{"version", "vgt", "3.0"}, Ctx: ctx, Err: "version not found in context"},
+		{Items: []interface{}{"version?error", "vgt", "3.0"}, Ctx: ctx, Err: "version not found in context"},
+		{Items: []interface{}{"version?false", "vgt", "3.0"}, Ctx: ctx, OK: false},
+		{Items: []interface{}{"version?true", "vgt", "3.0"}, Ctx: ctx, OK: true},
+		{Items: []interface{}{"version?", "vgt", "3.0"}, Ctx: ctx, Err: ErrUnknown.Error()},
+		{Items: []interface{}{"version?", "vgt", "3.0"}, Ctx: version, OK: true},
+		{Items: []interface{}{"version?=3.5.0", "vgt", "3.0"}, Ctx: ctx, OK: true},
+		{Items: []interface{}{"version?=3.5.0", "vgt", "3.2"}, Ctx: version, OK: false},
+		// 使用matcher的操作
+		{Items: []interface{}{"data.age?=0", ">=", 18}, Ctx: ctx, Data: map[string]interface{}{}, OK: false},
+		{Items: []interface{}{"data.age?=0", ">=", 18}, Ctx: ctx, Data: data, OK: true},
+		{Items: []interface{}{"data.name?", "=", "golang"}, Ctx: ctx, Data: data, Err: ErrUnknown.Error()},
+		// 比较值引用的变量不存在
+		{Items: []interface{}{"data.age?false", ">", "$data.min"}, Ctx: ctx, Data: data, OK: false},
+		// 不是变量不存在的错误
+		{Items: []interface{}{"data.age?false", ">=", 18}, Ctx: ctx, Data: errorValuer{}, Err: "valuer failed"},
+	}
+
+	for index, tt := range cases {
+		cond, err := BuildCondition(ctx, tt.Items, LogicAnd)
+		assert.Nil(t, err, index)
+
+		ok, err := cond.IsConditionOk(tt.Ctx, tt.Data, cache.NewCache())
+		if tt.Err != "" {
+			assert.EqualError(t, err, tt.Err, index)
+			continue
+		}
+		assert.Nil(t, err, index)
+		assert.Equal(t, tt.OK, ok, index)
+	}
+
+	_, err := BuildCondition(ctx, []interface{}{"golang?", "=", 1}, LogicAnd)
+	assert.EqualError(t, err, "condition not exists variable [golang]")
+}
+
+func TestMissingOf(t *testing.T) {
+	ctx := context.Background()
+	variables.SetMissing("platform", variables.MissingPolicy{Action: variables.MissingTrue})
+	defer variables.SetMissing("platform", variables.MissingPolicy{})
+
+	cond, err := BuildCondition(ctx, []interface{}{"platform", "=", "ios"}, LogicAnd)
+	assert.Nil(t, err)
+	assert.Equal(t, "platform?true", cond.(*BaseCondition).Key())
+
+	ok, err := cond.IsConditionOk(ctx, nil, cache.NewCache())
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// 条件中的策略优先
+	cond, err = BuildCondition(ctx, []interface{}{"platform?false", "=", "ios"}, LogicAnd)
+	assert.Nil(t, err)
+	ok, err = cond.IsConditionOk(ctx, nil, cache.NewCache())
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+// triCondition 结果为nil时未知
+type triCondition struct {
+	ok *bool
+}
+
+func (s triCondition) IsConditionOk(context.Context, interface{}, *cache.Cache) (bool, error) {
+	if s.ok == nil {
+		return false, ErrUnknown
+	}
+	return *s.ok, nil
+}
+
+func TestGroupUnknown(t *testing.T) {
+	var (
+		yes     = true
+		no      = false
+		T, F, U = &yes, &no, (*bool)(nil)
+	)
+
+	cases := []struct {
+		Logic     Logic
+		Threshold int
+		Children  []*bool
+		Result    *bool
+	}{
+		{Logic: LogicAnd, Children: []*bool{T, U}, Result: U},
+		{Logic: LogicAnd, Children: []*bool{U, F}, Result: F},
+		{Logic: LogicOr, Children: []*bool{U, F}, Result: U},
+		{Logic: LogicOr, Children: []*bool{U, T}, Result: T},
+		// not 不会把未知变为成立
+		{Logic: LogicNot, Children: []*bool{U}, Result: U},
+		{Logic: LogicNot, Children: []*bool{U, T}, Result: F},
+		{Logic: LogicNand, Children: []*bool{U, T}, Result: U},
+		{Logic: LogicNand, Children: []*bool{U, F}, Result: T},
+		{Logic: LogicXor, Children: []*bool{T, U}, Result: U},
+		{Logic: LogicXor, Children: []*bool{T, U, T}, Result: F},
+		{Logic: LogicAtLeast, Threshold: 2, Children: []*bool{T, U, T}, Result: T},
+		{Logic: LogicAtLeast, Threshold: 2, Children: []*bool{T, U, F}, Result: U},
+		{Logic: LogicAtLeast, Threshold: 2, Children: []*bool{U, F, F}, Result: F},
+		{Logic: LogicAtMost, Threshold: 1, Children: []*bool{U, F, F}, Result: T},
+		{Logic: LogicAtMost, Threshold: 1, Children: []*bool{U, T, F}, Result: U},
+	}
+
+	ctx := context.Background()
+	for index, tt := range cases {
+		group := NewGroup(tt.Logic)
+		group.threshold = tt.Threshold
+		for _, ok := range tt.Children {
+			group.Add(triCondition{ok: ok})
+		}
+
+		ok, err := group.IsConditionOk(ctx, nil, cache.NewCache())
+		if tt.Result == nil {
+			assert.True(t, IsUnknown(err), index)
+			continue
+		}
+		assert.Nil(t, err, index)
+		assert.Equal(t, *tt.Result, ok, index)
+	}
+
+	// 嵌套的分组
+	cond, err := BuildCondition(ctx, []interface{}{
+		[]interface{}{"not", "=>", []interface{}{[]interface{}{"version?", "vgt", "3.0"}}},
+	}, LogicAnd)
+	assert.Nil(t, err)
+	_, err = cond.IsConditionOk(ctx, nil, cache.NewCache())
+	assert.True(t, IsUnknown(err))
+}
@@ -0,0 +1,67 @@
+package condition
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/lists"
+	"github.com/airunny/filter/variables"
+)
+
+// operandCacheSize 每个引用变量的条件最多缓存的准备结果数量
+const operandCacheSize = 64
+
+// ParseOperand 解析条件的比较值，以$开头的字符串引用其它变量，例如 "$data.price"，执行时使用该变量的值作为比较值；
+// 以$$开头的字符串去掉一个$之后作为普通的比较值，例如 "$$100" 表示 "$100"；
+// 以@list:开头的字符串引用通过 lists.Register 注册的名单，例如 "@list:whitelist_2026"
+func ParseOperand(value interface{}) (variables.Variable, interface{}, error) {
+	return parseOperand(context.Background(), value)
+}
+
+// parseOperand 量词中未注册的变量名跟条件的变量一样作为元素的字段，例如 "$limit" 等价于 "$data.limit"，
+// ctx 中设置了 lists.NewContext 时从对应的注册表中查找名单
+func parseOperand(ctx context.Context, value interface{}) (variables.Variable, interface{}, error) {
+	str, ok := value.(string)
+	if ok && strings.HasPrefix(str, lists.Prefix) {
+		list, ok := lists.Lookup(ctx, str[len(lists.Prefix):])
+		if !ok {
+			return nil, nil, fmt.Errorf("condition operand not exists list [%s]", str[len(lists.Prefix):])
+		}
+		return nil, list, nil
+	}
+
+	if !ok || !strings.HasPrefix(str, "$") {
+		return nil, value, nil
+	}
+
+	if strings.HasPrefix(str, "$$") {
+		return nil, str[1:], nil
+	}
+
+	variable, ok := variables.Get(str[1:])
+	if !ok {
+		variable, ok = elementVariable(ctx, str[1:])
+	}
+	if !ok {
+		return nil, nil, fmt.Errorf("condition operand not exists variable [%s], use $$ for string starting with $", str[1:])
+	}
+	return variable, nil, nil
+}
+
+// operandValue 比较值引用的变量的取值，字符串取值（正则、版本号、CIDR等）准备之后的结果按照取值缓存，其它取值每次执行时重新准备
+func (s *BaseCondition) operandValue(ctx context.Context, data interface{}, c *cache.Cache) (interface{}, error) {
+	value, err := variables.GetValue(ctx, s.operand, data, c)
+	if err != nil {
+		return nil, err
+	}
+
+	str, ok := value.(string)
+	if !ok || s.prepared == nil {
+		return s.operation.PrepareValue(value)
+	}
+	return s.prepared.Load(str, func() (interface{}, error) {
+		return s.operation.PrepareValue(str)
+	})
+}
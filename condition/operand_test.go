@@ -0,0 +1,240 @@
+package condition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/airunny/filter/cache"
+	filterContext "github.com/airunny/filter/context"
+	"github.com/airunny/filter/hook"
+	"github.com/airunny/filter/lists"
+	"github.com/stretchr/testify/assert"
+)
+
+type valueHook struct {
+	hook.Base
+	values []interface{}
+}
+
+func (s *valueHook) OnCondition(_ context.Context, _, _ string, value interface{}, _ bool, _ error) {
+	s.values = append(s.values, value)
+}
+
+type order struct {
+	Balance float64  `json:"balance"`
+	Price   int      `json:"price"`
+	Tags    []string `json:"tags"`
+}
+
+func TestParseOperand(t *testing.T) {
+	variable, value, err := ParseOperand("$data.price")
+	assert.Nil(t, err)
+	assert.Equal(t, "data.price", variable.Name())
+	assert.Nil(t, value)
+
+	variable, value, err = ParseOperand("$$100")
+	assert.Nil(t, err)
+	assert.Nil(t, variable)
+	assert.Equal(t, "$100", value)
+
+	variable, value, err = ParseOperand(100)
+	assert.Nil(t, err)
+	assert.Nil(t, variable)
+	assert.Equal(t, 100, value)
+
+	_, _, err = ParseOperand("$unknown")
+	assert.EqualError(t, err, "condition operand not exists variable [unknown], use $$ for string starting with $")
+}
+
+func TestOperandCondition(t *testing.T) {
+	ctx := filterContext.WithVersion(context.Background(), "3.4.5")
+	ctx = filterContext.WithPlatform(ctx, "ios")
+	data := map[string]interface{}{
+		"balance":     100.0,
+		"price":       80,
+		"min_version": "3.4.0",
+		"range":       []interface{}{50, 150},
+		"platforms":   []string{"android", "ios"},
+		"pattern":     "/^i/",
+		"network":     "10.0.0.0/8",
+		"name":        "$100",
+		"order":       order{Balance: 10, Price: 20, Tags: []string{"vip"}},
+	}
+
+	cases := []struct {
+		Items    []interface{}
+		Expected bool
+	}{
+		{Items: []interface{}{"data.balance", ">=", "$data.price"}, Expected: true},
+		{Items: []interface{}{"data.balance", "<", "$data.price"}, Expected: false},
+		{Items: []interface{}{"data.order.balance", ">=", "$data.order.price"}, Expected: false},
+		{Items: []interface{}{"version", "vgte", "$data.min_version"}, Expected: true},
+		{Items: []interface{}{"version", "vlt", "$data.min_version"}, Expected: false},
+		{Items: []interface{}{"data.balance", "between", "$data.range"}, Expected: true},
+		{Items: []interface{}{"platform", "in", "$data.platforms"}, Expected: true},
+		{Items: []interface{}{"data.order.tags", "any", "$data.platforms"}, Expected: false},
+		{Items: []interface{}{"platform", "~", "$data.pattern"}, Expected: true},
+		{Items: []interface{}{"data.name", "=", "$$100"}, Expected: true},
+		{Items: []interface{}{"data.name", "=", "$data.name"}, Expected: true},
+	}
+
+	for index, tt := range cases {
+		cond, err := BuildCondition(ctx, tt.Items, LogicAnd)
+		assert.Nil(t, err, index)
+		ok, err := cond.IsConditionOk(ctx, data, cache.NewCache())
+		assert.Nil(t, err, index)
+		assert.Equal(t, tt.Expected, ok, index)
+	}
+
+	// 引用的变量取值失败或者不是合法的比较值时返回错误
+	cond, err := BuildCondition(ctx, []interface{}{"data.balance", ">", "$data.missing"}, LogicAnd)
+	assert.Nil(t, err)
+	_, err = cond.IsConditionOk(ctx, data, cache.NewCache())
+	assert.EqualError(t, err, "data.missing not found in data")
+
+	cond, err = BuildCondition(ctx, []interface{}{"data.balance", "between", "$data.price"}, LogicAnd)
+	assert.Nil(t, err)
+	_, err = cond.IsConditionOk(ctx, data, cache.NewCache())
+	assert.NotNil(t, err)
+
+	// hook 中的比较值为执行时的取值
+	h := &valueHook{}
+	cond, err = BuildCondition(ctx, []interface{}{"data.balance", ">=", "$data.price"}, LogicAnd)
+	assert.Nil(t, err)
+	_, err = cond.IsConditionOk(hook.NewContext(ctx, h), data, cache.NewCache())
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{80}, h.values)
+}
+
+func TestOperandPrepared(t *testing.T) {
+	ctx := context.Background()
+	cond, err := BuildCondition(ctx, []interface{}{"data.ua", "~", "$data.pattern"}, LogicAnd)
+	assert.Nil(t, err)
+	base := cond.(*BaseCondition)
+
+	// 相同的取值只准备一次，例如正则只编译一次
+	h := &valueHook{}
+	for i := 0; i < 2; i++ {
+		ok, err := cond.IsConditionOk(hook.NewContext(ctx, h), map[string]interface{}{"ua": "iphone", "pattern": "/^i/"}, cache.NewCache())
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	}
+	assert.Len(t, h.values, 2)
+	assert.Same(t, h.values[0], h.values[1])
+	assert.Equal(t, 1, base.prepared.Len())
+
+	ok, err := cond.IsConditionOk(ctx, map[string]interface{}{"ua": "iphone", "pattern": "/^a/"}, cache.NewCache())
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 2, base.prepared.Len())
+
+	// 准备失败时不缓存
+	_, err = cond.IsConditionOk(ctx, map[string]interface{}{"ua": "iphone", "pattern": "//"}, cache.NewCache())
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, base.prepared.Len())
+}
+
+// 量词中 data. 以及未注册的变量名都指向数组的元素，比较值引用变量时同样如此
+func TestQuantifierOperand(t *testing.T) {
+	ctx := context.Background()
+	data := map[string]interface{}{
+		"limit": 1000,
+		"orders": []interface{}{
+			map[string]interface{}{"amount": 200, "limit": 100},
+		},
+	}
+
+	for _, items := range [][]interface{}{
+		{"data.orders", "anyof", []interface{}{[]interface{}{"amount", ">", "$limit"}}},
+		{"data.orders", "anyof", []interface{}{[]interface{}{"amount", ">", "$data.limit"}}},
+		{"data.orders", "anyof", []interface{}{[]interface{}{"data.amount", ">", "$limit"}}},
+	} {
+		cond, err := BuildCondition(ctx, items, LogicAnd)
+		assert.Nil(t, err, items)
+		ok, err := cond.IsConditionOk(ctx, data, cache.NewCache())
+		assert.Nil(t, err, items)
+		assert.True(t, ok, items)
+	}
+
+	// 量词之外未注册的变量名仍然构建失败
+	_, err := BuildCondition(ctx, []interface{}{"data.amount", ">", "$limit"}, LogicAnd)
+	assert.EqualError(t, err, "condition operand not exists variable [limit], use $$ for string starting with $")
+}
+
+func TestInternOperand(t *testing.T) {
+	interner := NewInterner()
+	ctx := WithInterner(context.Background(), interner)
+
+	var conditions []Condition
+	for i := 0; i < 2; i++ {
+		cond, err := BuildCondition(ctx, []interface{}{"uid", ">", "$data.uid"}, LogicAnd)
+		assert.Nil(t, err)
+		conditions = append(conditions, cond)
+	}
+	interner.Finish()
+
+	assert.Same(t, conditions[0], conditions[1])
+	base := conditions[0].(*BaseCondition)
+	assert.Equal(t, cache.ScopeData, base.memoScope)
+}
+
+func TestListOperand(t *testing.T) {
+	ctx := filterContext.WithUserID(context.Background(), 1001)
+	whitelist, err := lists.Register(ctx, "operand_whitelist", lists.ProviderFunc(func(context.Context) ([]string, error) {
+		return []string{"1001", "1002", "vip"}, nil
+	}))
+	assert.Nil(t, err)
+	defer lists.Remove("operand_whitelist")
+
+	_, value, err := ParseOperand("@list:operand_whitelist")
+	assert.Nil(t, err)
+	assert.Same(t, whitelist, value)
+
+	_, _, err = ParseOperand("@list:unknown")
+	assert.EqualError(t, err, "condition operand not exists list [unknown]")
+
+	// ctx 中指定了注册表时从该注册表中查找
+	registry := lists.NewRegistry()
+	scoped, err := registry.Register(ctx, "operand_whitelist", lists.ProviderFunc(func(context.Context) ([]string, error) {
+		return []string{"1002"}, nil
+	}))
+	assert.Nil(t, err)
+	_, value, err = parseOperand(lists.NewContext(ctx, registry), "@list:operand_whitelist")
+	assert.Nil(t, err)
+	assert.Same(t, scoped, value)
+
+	data := map[string]interface{}{"tags": []interface{}{"new", "vip"}, "all": []interface{}{1001, "1002", "vip", "new"}}
+	cases := []struct {
+		Items    []interface{}
+		Expected bool
+	}{
+		{Items: []interface{}{"uid", "in", "@list:operand_whitelist"}, Expected: true},
+		{Items: []interface{}{"uid", "nin", "@list:operand_whitelist"}, Expected: false},
+		{Items: []interface{}{"uid", "!in", "@list:operand_whitelist"}, Expected: false},
+		{Items: []interface{}{"data.tags", "any", "@list:operand_whitelist"}, Expected: true},
+		{Items: []interface{}{"data.tags", "not", "@list:operand_whitelist"}, Expected: false},
+		{Items: []interface{}{"data.tags", "in", "@list:operand_whitelist"}, Expected: false},
+		{Items: []interface{}{"data.tags", "has", "@list:operand_whitelist"}, Expected: false},
+		{Items: []interface{}{"data.all", "has", "@list:operand_whitelist"}, Expected: true},
+		{Items: []interface{}{"data.all", "!has", "@list:operand_whitelist"}, Expected: false},
+	}
+	for index, tt := range cases {
+		cond, err := BuildCondition(ctx, tt.Items, LogicAnd)
+		assert.Nil(t, err, index)
+		ok, err := cond.IsConditionOk(ctx, data, cache.NewCache())
+		assert.Nil(t, err, index)
+		assert.Equal(t, tt.Expected, ok, index)
+	}
+
+	// 只有 in、nin、any、not、has 可以使用名单
+	_, err = BuildCondition(ctx, []interface{}{"uid", "=", "@list:operand_whitelist"}, LogicAnd)
+	assert.EqualError(t, err, "operation [=] does not accept list [@list:operand_whitelist#"+whitelist.Version()+"]")
+
+	// hook 中的比较值为名单以及版本
+	h := &valueHook{}
+	cond, err := BuildCondition(ctx, []interface{}{"uid", "in", "@list:operand_whitelist"}, LogicAnd)
+	assert.Nil(t, err)
+	_, err = cond.IsConditionOk(hook.NewContext(ctx, h), data, cache.NewCache())
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{whitelist}, h.values)
+}
@@ -0,0 +1,103 @@
+package condition
+
+import (
+	"sort"
+
+	"github.com/airunny/filter/variables"
+)
+
+// Cost 条件的执行代价，分组为所有子条件的代价之和
+func Cost(cond Condition) int {
+	switch c := cond.(type) {
+	case *BaseCondition:
+		return variables.Cost(c.variable)
+	case *Group:
+		cost := 0
+		for _, child := range c.conditions {
+			cost += Cost(child)
+		}
+		return cost
+	case *Segment:
+		return Cost(c.condition)
+	case *Quantifier:
+		return variables.Cost(c.variable) + Cost(c.condition)
+	}
+	return variables.CostCheap
+}
+
+// Optimize 按照代价从小到大重排分组中的子条件，让代价小的条件先执行并短路代价大的条件。
+// and、or、not 的结果跟子条件顺序无关，代价相同时保持配置顺序；可能返回错误的子条件（参见 mayError）保持原来的位置，
+// 只在相邻的两个这样的子条件之间重排，是否短路以及返回的错误跟配置顺序一致。
+// 依赖执行顺序的分组可以使用 {"ordered": true} 保持配置顺序
+func Optimize(cond Condition) Condition {
+	if quantifier, ok := cond.(*Quantifier); ok {
+		quantifier.condition = Optimize(quantifier.condition)
+		return quantifier
+	}
+
+	group, ok := cond.(*Group)
+	if !ok {
+		return cond
+	}
+
+	for index, child := range group.conditions {
+		group.conditions[index] = Optimize(child)
+	}
+
+	if group.ordered {
+		return group
+	}
+
+	var (
+		children = make([]costed, 0, len(group.conditions))
+		start    = 0
+	)
+	for index, child := range group.conditions {
+		if !mayError(child) {
+			children = append(children, costed{condition: child, cost: Cost(child)})
+			continue
+		}
+
+		// 可能返回错误的子条件之前的子条件重排之后仍然在它之前
+		sortByCost(group.conditions[start:index], children)
+		children = children[:0]
+		start = index + 1
+	}
+	sortByCost(group.conditions[start:], children)
+	return group
+}
+
+// sortByCost 按照代价从小到大写回 conditions
+func sortByCost(conditions []Condition, children []costed) {
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].cost < children[j].cost
+	})
+
+	for index, child := range children {
+		conditions[index] = child.condition
+	}
+}
+
+type costed struct {
+	condition Condition
+	cost      int
+}
+
+// mayError 条件执行时是否可能返回错误（ErrUnknown 按照三值逻辑处理，跟顺序无关），
+// 只有带有缺失策略并且比较值不引用变量的条件以及只包含这些条件的分组、分群不会因为变量不存在返回错误
+func mayError(cond Condition) bool {
+	switch c := cond.(type) {
+	case *BaseCondition:
+		return c.operand != nil || c.missingPolicy.Action == variables.MissingError
+	case *Group:
+		for _, child := range c.conditions {
+			if mayError(child) {
+				return true
+			}
+		}
+		return false
+	case *Segment:
+		return mayError(c.condition)
+	}
+	return true
+}
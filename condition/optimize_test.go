@@ -0,0 +1,153 @@
+package condition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/variables"
+	"github.com/stretchr/testify/assert"
+)
+
+func variableNames(cond Condition) []string {
+	var names []string
+	for _, child := range cond.(*Group).Conditions() {
+		switch c := child.(type) {
+		case *BaseCondition:
+			names = append(names, c.Variable().Name())
+		case *Group:
+			names = append(names, "group")
+		}
+	}
+	return names
+}
+
+func TestCost(t *testing.T) {
+	cond, err := BuildCondition(context.Background(), []interface{}{
+		[]interface{}{"freq.a", ">", 1},
+		[]interface{}{"city", "=", "北京"},
+		[]interface{}{"platform", "=", "ios"},
+	}, LogicAnd)
+	assert.Nil(t, err)
+	assert.Equal(t, variables.CostExpensive+variables.CostModerate+variables.CostCheap, Cost(cond))
+}
+
+func TestOptimize(t *testing.T) {
+	ctx := context.Background()
+	cond, err := BuildCondition(ctx, []interface{}{
+		[]interface{}{"freq.a?", ">", 1},
+		[]interface{}{"or", "=>", []interface{}{
+			[]interface{}{"calc.a + 1?false", ">", 1},
+			[]interface{}{"city?", "=", "北京"},
+			[]interface{}{"success?", "=", 1},
+		}},
+		[]interface{}{"not", "=>", []interface{}{
+			[]interface{}{"freq.b?", ">", 1},
+			[]interface{}{"timestamp?", "<", 1},
+		}, map[string]interface{}{"ordered": true}},
+		[]interface{}{"city?", "=", "上海"},
+		[]interface{}{"platform?", "=", "ios"},
+		[]interface{}{"success?=1", "=", 1},
+	}, LogicAnd)
+	assert.Nil(t, err)
+
+	cond = Optimize(cond)
+	assert.Equal(t, []string{"platform", "success", "city", "freq.a", "group", "group"}, variableNames(cond))
+
+	children := cond.(*Group).Conditions()
+	// ordered 的分组保持配置顺序
+	assert.Equal(t, []string{"freq.b", "timestamp"}, variableNames(children[4]))
+	assert.Equal(t, []string{"success", "city", "calc.a + 1"}, variableNames(children[5]))
+
+	// 可能返回错误的子条件保持原来的位置，只重排它前后的子条件
+	cond, err = BuildCondition(ctx, []interface{}{
+		[]interface{}{"freq.a?", ">", 1},
+		[]interface{}{"platform?", "=", "ios"},
+		[]interface{}{"freq.b", ">", 1},
+		[]interface{}{"calc.a + 1?", ">", 1},
+		[]interface{}{"channel?", "=", "store"},
+		[]interface{}{"data.a", ">", "$data.b"},
+		[]interface{}{"success", "=", 1},
+	}, LogicAnd)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"platform", "freq.a", "freq.b", "channel", "calc.a + 1", "data.a", "success"}, variableNames(Optimize(cond)))
+}
+
+// 变量不存在时返回错误的条件不会被重排到短路它的条件之前
+func TestOptimizeError(t *testing.T) {
+	ctx := context.Background()
+	data := map[string]interface{}{"a": 1.0}
+
+	for _, items := range [][]interface{}{
+		{
+			[]interface{}{"data.a?", "=", 2},
+			[]interface{}{"platform", "=", "ios"},
+		},
+		{
+			[]interface{}{"freq.a?", ">", 1},
+			[]interface{}{"platform", "=", "ios"},
+		},
+		{
+			[]interface{}{"or", "=>", []interface{}{
+				[]interface{}{"freq.a?", "<", 1},
+				[]interface{}{"platform", "=", "ios"},
+			}},
+		},
+	} {
+		cond, err := BuildCondition(ctx, items, LogicAnd)
+		assert.Nil(t, err)
+		want, wantErr := cond.IsConditionOk(ctx, data, cache.NewCache())
+		assert.Nil(t, wantErr)
+
+		got, gotErr := Optimize(cond).IsConditionOk(ctx, data, cache.NewCache())
+		assert.Nil(t, gotErr)
+		assert.Equal(t, want, got)
+	}
+
+	// 两个可能返回错误的子条件之间不重排，返回的错误跟配置顺序一致
+	cond, err := BuildCondition(ctx, []interface{}{
+		[]interface{}{"freq.a", ">", 1},
+		[]interface{}{"platform", "=", "ios"},
+	}, LogicAnd)
+	assert.Nil(t, err)
+	_, wantErr := cond.IsConditionOk(ctx, data, cache.NewCache())
+	_, gotErr := Optimize(cond).IsConditionOk(ctx, data, cache.NewCache())
+	assert.Equal(t, wantErr, gotErr)
+}
+
+func TestOptimizeResult(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		data = map[string]interface{}{"a": 1.0, "b": 2.0}
+	)
+
+	cases := [][]interface{}{
+		{
+			[]interface{}{"data.a", "=", 1},
+			[]interface{}{"success", "=", 1},
+		},
+		{
+			[]interface{}{"or", "=>", []interface{}{
+				[]interface{}{"data.a", "=", 2},
+				[]interface{}{"success", "=", 1},
+			}},
+		},
+		{
+			[]interface{}{"not", "=>", []interface{}{
+				[]interface{}{"data.b", "=", 2},
+				[]interface{}{"success", ">", 1},
+			}},
+		},
+	}
+
+	for index, items := range cases {
+		cond, err := BuildCondition(ctx, items, LogicAnd)
+		assert.Nil(t, err)
+		want, err := cond.IsConditionOk(ctx, data, cache.NewCache())
+		assert.Nil(t, err)
+
+		got, err := Optimize(cond).IsConditionOk(ctx, data, cache.NewCache())
+		assert.Nil(t, err)
+		assert.Equal(t, want, got, index)
+	}
+}
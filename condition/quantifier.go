@@ -0,0 +1,205 @@
+package condition
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/hook"
+	"github.com/airunny/filter/variables"
+	"github.com/airunny/filter/variables/data"
+)
+
+// 数组元素的量词条件，例如 ["data.orders", "anyof", [["status", "=", "paid"], ["amount", ">", 100]]]
+const (
+	QuantifierAny  = "anyof"  // 至少一个元素满足条件
+	QuantifierAll  = "allof"  // 所有元素都满足条件
+	QuantifierNone = "noneof" // 所有元素都不满足条件
+)
+
+// anyof 使用 atleast 1 而不是 or，空数组不满足 anyof
+var quantifierLogics = map[string]Logic{
+	QuantifierAny:  LogicAtLeast,
+	QuantifierAll:  LogicAnd,
+	QuantifierNone: LogicNot,
+}
+
+// IsQuantifier name 是否为 anyof、allof、noneof
+func IsQuantifier(name string) bool {
+	_, ok := quantifierLogics[name]
+	return ok
+}
+
+type elementKey struct{}
+
+// inElement 是否在构建量词中的条件，此时 data. 指向数组的元素，未注册的变量名作为元素的字段，例如 status 等价于 data.status
+func inElement(ctx context.Context) bool {
+	in, _ := ctx.Value(elementKey{}).(bool)
+	return in
+}
+
+// Quantifier 对数组变量的每一个元素执行条件，元素作为条件中 data. 的根，
+// 每个元素使用独立的 cache.Cache（共享 cache.ScopeRequest），元素之间的取值互不影响
+type Quantifier struct {
+	variable  variables.Variable
+	source    variables.Variable // 执行时取值的变量，参见 BaseCondition
+	name      string
+	logic     Logic
+	condition Condition
+	raw       interface{}
+	memo      string // 不为空时结果缓存在 cache.Cache 中，参见 Interner
+	memoScope cache.Scope
+
+	missingPolicy variables.MissingPolicy
+}
+
+func (s *Quantifier) Variable() variables.Variable { return s.variable }
+
+// Name anyof、allof 或者 noneof
+func (s *Quantifier) Name() string         { return s.name }
+func (s *Quantifier) Condition() Condition { return s.condition }
+
+// Missing 数组变量不存在时的策略，参见 BaseCondition.Missing
+func (s *Quantifier) Missing() variables.MissingPolicy { return s.missingPolicy }
+
+// Key 配置中的变量名，参见 BaseCondition.Key
+func (s *Quantifier) Key() string { return missingKey(s.variable.Name(), s.missingPolicy) }
+
+// Raw 配置中元素的条件
+func (s *Quantifier) Raw() interface{} { return s.raw }
+
+func (s *Quantifier) IsConditionOk(ctx context.Context, data interface{}, cache *cache.Cache) (bool, error) {
+	if s.memo == "" {
+		return s.run(ctx, data, cache)
+	}
+
+	if value, ok := cache.GetScoped(s.memoScope, s.memo); ok {
+		return value.(bool), nil
+	}
+
+	ok, err := s.run(ctx, data, cache)
+	if err == nil {
+		cache.SetScoped(s.memoScope, s.memo, ok)
+	}
+	return ok, err
+}
+
+func (s *Quantifier) run(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	ok, err := s.evaluate(ctx, data, c)
+	if h, exists := hook.FromContext(ctx); exists {
+		h.OnCondition(ctx, s.variable.Name(), s.name, nil, ok, err)
+	}
+	return ok, err
+}
+
+// evaluate 跟分组一样按照三值逻辑判断，anyof、allof、noneof 分别对应 atleast 1、and、not
+func (s *Quantifier) evaluate(ctx context.Context, data interface{}, c *cache.Cache) (bool, error) {
+	value, err := variables.GetValue(ctx, s.source, data, c)
+	if err != nil {
+		return missingResult(s.missingPolicy, false, err)
+	}
+
+	elements, err := s.elements(value)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		total     = len(elements)
+		satisfied = 0
+		unknown   = 0
+	)
+	for index := 0; ; index++ {
+		if result, known := Decide(s.logic, 1, total, satisfied, satisfied+unknown+total-index); known {
+			return result, nil
+		}
+
+		if index == total {
+			return false, ErrUnknown
+		}
+
+		ok, err := s.condition.IsConditionOk(ctx, elements[index], c.Child())
+		if IsUnknown(err) {
+			unknown++
+			continue
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		if ok {
+			satisfied++
+		}
+	}
+}
+
+// elements 数组或者切片的所有元素，nil为空数组
+func (s *Quantifier) elements(value interface{}) ([]interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	if elements, ok := value.([]interface{}); ok {
+		return elements, nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
+		elements := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elements = append(elements, v.Index(i).Interface())
+		}
+		return elements, nil
+	}
+	return nil, fmt.Errorf("[%s] variable %s value must be array", s.name, s.variable.Name())
+}
+
+// buildQuantifier 构建 [变量, anyof, 条件] 形式的条件，元素的条件使用 BuildCondition 构建
+func buildQuantifier(ctx context.Context, key string, variable variables.Variable, missing variables.MissingPolicy, items []interface{}) (Condition, error) {
+	name := items[1].(string)
+	children, ok := items[2].([]interface{})
+	if !ok || len(children) == 0 {
+		return nil, fmt.Errorf("[%s] condition of variable [%s] should be non-empty array", name, key)
+	}
+
+	cond, err := BuildCondition(context.WithValue(ctx, elementKey{}, true), children, LogicAnd)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] condition of variable [%s]: %w", name, key, err)
+	}
+
+	quantifier := &Quantifier{
+		variable:  variable,
+		source:    variable,
+		name:      name,
+		logic:     quantifierLogics[name],
+		condition: cond,
+		raw:       items[2],
+
+		missingPolicy: missing,
+	}
+	if missing.Action == variables.MissingDefault {
+		quantifier.source = &defaultVariable{Variable: variable, value: missing.Default}
+	}
+
+	in := interner(ctx)
+	if in == nil {
+		return quantifier, nil
+	}
+
+	entry, ok := in.entries[cond]
+	if !ok {
+		return quantifier, nil
+	}
+	return in.add(fmt.Sprintf("%q %q {%s}", items[0], name, entry.key), quantifier), nil
+}
+
+// elementVariable 量词中未注册的变量名作为元素的字段
+func elementVariable(ctx context.Context, name string) (variables.Variable, bool) {
+	if !inElement(ctx) {
+		return nil, false
+	}
+	return variables.Get(data.Name + name)
+}
@@ -0,0 +1,128 @@
+package condition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/airunny/filter/cache"
+	filterContext "github.com/airunny/filter/context"
+	"github.com/stretchr/testify/assert"
+)
+
+type payment struct {
+	Status string  `json:"status"`
+	Amount float64 `json:"amount"`
+}
+
+func TestQuantifier(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		paid = []interface{}{[]interface{}{"status", "=", "paid"}, []interface{}{"amount", ">", 100}}
+		data = map[string]interface{}{
+			"orders": []interface{}{
+				map[string]interface{}{"status": "paid", "amount": float64(50)},
+				map[string]interface{}{"status": "paid", "amount": float64(200)},
+				map[string]interface{}{"status": "refund", "amount": float64(300)},
+			},
+			"structs": []payment{{Status: "paid", Amount: 120}, {Status: "paid", Amount: 150}},
+			"empty":   []interface{}{},
+			"groups": []interface{}{
+				map[string]interface{}{"orders": []interface{}{map[string]interface{}{"status": "paid", "amount": float64(200)}}},
+				map[string]interface{}{"orders": []interface{}{}},
+			},
+			"name": "golang",
+		}
+	)
+
+	cases := []struct {
+		Items []interface{}
+		OK    bool
+		Err   string
+	}{
+		{Items: []interface{}{"data.orders", "anyof", paid}, OK: true},
+		{Items: []interface{}{"data.orders", "allof", paid}, OK: false},
+		{Items: []interface{}{"data.orders", "noneof", paid}, OK: false},
+		{Items: []interface{}{"data.orders", "noneof", []interface{}{"status", "=", "cancel"}}, OK: true},
+		{Items: []interface{}{"data.structs", "allof", paid}, OK: true},
+		// data. 指向元素
+		{Items: []interface{}{"data.structs", "allof", []interface{}{"data.status", "=", "paid"}}, OK: true},
+		{Items: []interface{}{"data.empty", "anyof", paid}, OK: false},
+		{Items: []interface{}{"data.empty", "allof", paid}, OK: true},
+		// 嵌套的量词
+		{Items: []interface{}{"data.groups", "anyof", []interface{}{"orders", "allof", paid}}, OK: true},
+		{Items: []interface{}{"data.groups", "allof", []interface{}{"orders", "anyof", paid}}, OK: false},
+		// 元素的字段不存在时结果未知
+		{Items: []interface{}{"data.orders", "anyof", []interface{}{"coupon?", "=", "vip"}}, Err: ErrUnknown.Error()},
+		{Items: []interface{}{"data.orders", "allof", []interface{}{[]interface{}{"coupon?", "=", "vip"}, []interface{}{"status", "=", "refund"}}}, OK: false},
+		// 数组变量不存在
+		{Items: []interface{}{"data.missing", "anyof", paid}, Err: "data.missing not found in data"},
+		{Items: []interface{}{"data.missing?false", "noneof", paid}, OK: false},
+		{Items: []interface{}{"data.missing?=[]", "allof", paid}, OK: true},
+		{Items: []interface{}{"data.name", "anyof", paid}, Err: "[anyof] variable data.name value must be array"},
+	}
+
+	for index, tt := range cases {
+		cond, err := BuildCondition(ctx, tt.Items, LogicAnd)
+		assert.Nil(t, err, index)
+
+		// 不使用缓存时结果相同
+		for _, c := range []*cache.Cache{cache.NewCache(), nil} {
+			ok, err := cond.IsConditionOk(ctx, data, c)
+			if tt.Err != "" {
+				assert.EqualError(t, err, tt.Err, index)
+				continue
+			}
+			assert.Nil(t, err, index)
+			assert.Equal(t, tt.OK, ok, index)
+		}
+	}
+
+	for _, tt := range []struct {
+		Items []interface{}
+		Err   string
+	}{
+		{Items: []interface{}{"data.orders", "anyof", "status"}, Err: "[anyof] condition of variable [data.orders] should be non-empty array"},
+		{Items: []interface{}{"data.orders", "allof", []interface{}{}}, Err: "[allof] condition of variable [data.orders] should be non-empty array"},
+		{Items: []interface{}{"data.orders", "anyof", []interface{}{"status", "golang", 1}}, Err: "[anyof] condition of variable [data.orders]: condition not exists operation [golang]"},
+	} {
+		_, err := BuildCondition(ctx, tt.Items, LogicAnd)
+		assert.EqualError(t, err, tt.Err)
+	}
+
+	// 只能在量词中省略 data. 前缀
+	_, err := BuildCondition(ctx, []interface{}{"status", "=", "paid"}, LogicAnd)
+	assert.EqualError(t, err, "condition not exists variable [status]")
+}
+
+func TestQuantifierCache(t *testing.T) {
+	ctx := filterContext.WithPlatform(context.Background(), "ios")
+	in := NewInterner()
+	buildCtx := WithInterner(ctx, in)
+
+	// 元素中的 data.status 跟外层的 data.status 共享同一个条件，但是取值互不影响
+	outer, err := BuildCondition(buildCtx, []interface{}{"data.status", "=", "paid"}, LogicAnd)
+	assert.Nil(t, err)
+	cond, err := BuildCondition(buildCtx, []interface{}{
+		[]interface{}{"data.status", "=", "paid"},
+		[]interface{}{"data.orders", "allof", []interface{}{[]interface{}{"data.status", "=", "paid"}, []interface{}{"platform", "=", "ios"}}},
+	}, LogicAnd)
+	assert.Nil(t, err)
+	assert.Same(t, outer, cond.(*Group).Conditions()[0])
+	assert.Same(t, outer, cond.(*Group).Conditions()[1].(*Quantifier).Condition().(*Group).Conditions()[0])
+	in.Finish()
+
+	data := map[string]interface{}{
+		"status": "paid",
+		"orders": []interface{}{map[string]interface{}{"status": "paid"}, map[string]interface{}{"status": "refund"}},
+	}
+	c := cache.NewCache()
+	c.CollectStats = true
+	ok, err := cond.IsConditionOk(ctx, data, c)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = outer.IsConditionOk(ctx, data, c)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, cache.Stat{Misses: 1}, c.Stats()[cache.StatKey{Scope: cache.ScopeRequest, Key: "platform"}])
+}
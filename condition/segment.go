@@ -0,0 +1,213 @@
+package condition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/variables"
+)
+
+// SegmentVariable 引用分群的条件，例如 ["segment", "=", "vip_android"]
+const SegmentVariable = "segment"
+
+type segmentsKey struct{}
+
+// Segments 配置中命名的分群，每个分群是一个条件，可以被多个filter以及其它分群引用
+type Segments struct {
+	items    map[string][]interface{}
+	built    map[string]*Segment
+	building []string // 正在构建的分群，用于检测循环引用
+}
+
+// SegmentError 分群中的条件有误，Name 为出错的分群，引用其它出错的分群时外层的错误为 "segment [a]: " 前缀
+type SegmentError struct {
+	Name string
+	Err  error
+}
+
+func (e *SegmentError) Error() string { return fmt.Sprintf("segment [%s]: %v", e.Name, e.Err) }
+func (e *SegmentError) Unwrap() error { return e.Err }
+
+// Segment 分群条件，每次执行中只计算一次，结果缓存在 cache.Cache 中，依赖data的结果在赋值之后重新计算
+type Segment struct {
+	name      string
+	condition Condition
+	memo      string
+	scope     cache.Scope
+}
+
+func (s *Segment) Name() string         { return s.name }
+func (s *Segment) Condition() Condition { return s.condition }
+
+// Key 结果在 cache.Cache 中的key以及作用域
+func (s *Segment) Key() (cache.Scope, string) { return s.scope, s.memo }
+
+func (s *Segment) IsConditionOk(ctx context.Context, data interface{}, cache *cache.Cache) (bool, error) {
+	if value, ok := cache.GetScoped(s.scope, s.memo); ok {
+		return value.(bool), nil
+	}
+
+	ok, err := s.condition.IsConditionOk(ctx, data, cache)
+	if err == nil {
+		cache.SetScoped(s.scope, s.memo, ok)
+	}
+	return ok, err
+}
+
+func NewSegments(items map[string][]interface{}) *Segments {
+	return &Segments{
+		items: items,
+		built: make(map[string]*Segment, len(items)),
+	}
+}
+
+func WithSegments(ctx context.Context, segments *Segments) context.Context {
+	return context.WithValue(ctx, segmentsKey{}, segments)
+}
+
+func segments(ctx context.Context) *Segments {
+	segments, _ := ctx.Value(segmentsKey{}).(*Segments)
+	return segments
+}
+
+// Build 构建所有的分群，未被引用的分群同样需要检查
+func (s *Segments) Build(ctx context.Context) error {
+	ctx = WithSegments(ctx, s)
+	for _, name := range s.Names() {
+		if _, err := s.get(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Names 按照名字排序的所有分群
+func (s *Segments) Names() []string {
+	names := make([]string, 0, len(s.items))
+	for name := range s.items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get 已经构建的分群
+func (s *Segments) Get(name string) (*Segment, bool) {
+	segment, ok := s.built[name]
+	return segment, ok
+}
+
+// Optimize 重排分群中的条件，参考 Optimize
+func (s *Segments) Optimize() {
+	for _, segment := range s.built {
+		segment.condition = Optimize(segment.condition)
+	}
+}
+
+func (s *Segments) get(ctx context.Context, name string) (*Segment, error) {
+	if segment, ok := s.built[name]; ok {
+		return segment, nil
+	}
+
+	for index, building := range s.building {
+		if building == name {
+			cycle := append(append([]string{}, s.building[index:]...), name)
+			return nil, fmt.Errorf("segment reference cycle [%s]", strings.Join(cycle, " -> "))
+		}
+	}
+
+	items, ok := s.items[name]
+	if !ok {
+		return nil, fmt.Errorf("segment [%s] is not defined", name)
+	}
+
+	s.building = append(s.building, name)
+	defer func() {
+		s.building = s.building[:len(s.building)-1]
+	}()
+
+	cond, err := BuildCondition(ctx, items, LogicAnd)
+	if err != nil {
+		var segmentErr *SegmentError
+		if errors.As(err, &segmentErr) {
+			return nil, fmt.Errorf("segment [%s]: %w", name, err)
+		}
+		return nil, &SegmentError{Name: name, Err: err}
+	}
+
+	segment := &Segment{
+		name:      name,
+		condition: cond,
+		memo:      "\x00segment." + name,
+		scope:     scopeOf(cond),
+	}
+	s.built[name] = segment
+	return segment, nil
+}
+
+// buildSegment 构建 ["segment", "=", name] 形式的条件
+func buildSegment(ctx context.Context, items []interface{}) (Condition, error) {
+	if operation, _ := items[1].(string); operation != "=" {
+		return nil, fmt.Errorf("segment condition only supports operation [=], got [%v]", items[1])
+	}
+
+	name, ok := items[2].(string)
+	if !ok {
+		return nil, fmt.Errorf("segment condition 3rd element [%v] is not string", items[2])
+	}
+
+	s := segments(ctx)
+	if s == nil {
+		return nil, fmt.Errorf("segment [%s] is not defined", name)
+	}
+
+	segment, err := s.get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return interner(ctx).add("segment "+strconv.Quote(name), segment), nil
+}
+
+// scopeOf 条件依赖的变量中最大的缓存作用域，依赖不可缓存的变量时在赋值之后同样需要重新计算
+func scopeOf(cond Condition) cache.Scope {
+	scope := cache.ScopeRequest
+	switch c := cond.(type) {
+	case *BaseCondition:
+		for _, v := range []variables.Variable{c.variable, c.operand} {
+			if v == nil {
+				continue
+			}
+
+			if !v.Cacheable() {
+				return cache.ScopeData
+			}
+
+			if variables.Scope(v) > scope {
+				scope = variables.Scope(v)
+			}
+		}
+	case *Group:
+		for _, child := range c.conditions {
+			if childScope := scopeOf(child); childScope > scope {
+				scope = childScope
+			}
+		}
+	case *Segment:
+		scope = c.scope
+	case *Quantifier:
+		if !c.variable.Cacheable() {
+			return cache.ScopeData
+		}
+
+		scope = variables.Scope(c.variable)
+		if childScope := scopeOf(c.condition); childScope > scope {
+			scope = childScope
+		}
+	}
+	return scope
+}
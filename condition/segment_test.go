@@ -0,0 +1,131 @@
+package condition
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/airunny/filter/cache"
+	filterContext "github.com/airunny/filter/context"
+	"github.com/airunny/filter/hook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegments(t *testing.T) {
+	segments := NewSegments(map[string][]interface{}{
+		"ios":       {"platform", "=", "ios"},
+		"adult":     {[]interface{}{"data.age", ">=", 18}},
+		"ios_adult": {[]interface{}{"segment", "=", "ios"}, []interface{}{"segment", "=", "adult"}},
+	})
+	ctx := WithSegments(context.Background(), segments)
+	assert.Nil(t, segments.Build(ctx))
+	assert.Equal(t, []string{"adult", "ios", "ios_adult"}, segments.Names())
+
+	ios, ok := segments.Get("ios")
+	assert.True(t, ok)
+	scope, key := ios.Key()
+	assert.Equal(t, cache.ScopeRequest, scope)
+	assert.Equal(t, "\x00segment.ios", key)
+
+	adult, _ := segments.Get("adult")
+	scope, _ = adult.Key()
+	assert.Equal(t, cache.ScopeData, scope)
+
+	cond, err := BuildCondition(ctx, []interface{}{
+		[]interface{}{"segment", "=", "ios_adult"},
+		[]interface{}{"or", "=>", []interface{}{
+			[]interface{}{"segment", "=", "ios"},
+			[]interface{}{"segment", "=", "adult"},
+		}},
+	}, LogicAnd)
+	assert.Nil(t, err)
+
+	// 同一次执行中每个分群只计算一次
+	var (
+		h    = &countHook{}
+		c    = cache.NewCache()
+		data = map[string]interface{}{"age": 20}
+	)
+	runCtx := hook.NewContext(filterContext.WithPlatform(context.Background(), "ios"), h)
+	ok, err = cond.IsConditionOk(runCtx, data, c)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, h.conditions)
+
+	ok, err = cond.IsConditionOk(runCtx, data, c)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, h.conditions)
+
+	// 赋值之后依赖data的分群重新计算
+	data["age"] = 10
+	c.Invalidate(cache.ScopeData)
+	ok, err = cond.IsConditionOk(runCtx, data, c)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 3, h.conditions)
+}
+
+func TestSegmentsError(t *testing.T) {
+	cases := []struct {
+		Segments map[string][]interface{}
+		Items    []interface{}
+		Err      string
+		Segment  string // 出错的分群
+	}{
+		{
+			Segments: map[string][]interface{}{"a": {"segment", "=", "b"}, "b": {"segment", "=", "c"}, "c": {"segment", "=", "a"}},
+			Err:      "segment [a]: segment [b]: segment [c]: segment reference cycle [a -> b -> c -> a]",
+			Segment:  "c",
+		},
+		{
+			Segments: map[string][]interface{}{"a": {"segment", "=", "a"}},
+			Err:      "segment [a]: segment reference cycle [a -> a]",
+			Segment:  "a",
+		},
+		{
+			Segments: map[string][]interface{}{"a": {"segment", "=", "unknown"}},
+			Err:      "segment [a]: segment [unknown] is not defined",
+			Segment:  "a",
+		},
+		{
+			Segments: map[string][]interface{}{"a": {"unknown", "=", 1}},
+			Err:      "segment [a]: condition not exists variable [unknown]",
+			Segment:  "a",
+		},
+		{
+			Segments: map[string][]interface{}{"a": {"segment", "=", "b"}, "b": {"unknown", "=", 1}},
+			Err:      "segment [a]: segment [b]: condition not exists variable [unknown]",
+			Segment:  "b",
+		},
+		{
+			Items: []interface{}{"segment", "=", "unknown"},
+			Err:   "segment [unknown] is not defined",
+		},
+		{
+			Segments: map[string][]interface{}{"a": {"platform", "=", "ios"}},
+			Items:    []interface{}{"segment", "in", "a"},
+			Err:      "segment condition only supports operation [=], got [in]",
+		},
+		{
+			Segments: map[string][]interface{}{"a": {"platform", "=", "ios"}},
+			Items:    []interface{}{"segment", "=", 1},
+			Err:      "segment condition 3rd element [1] is not string",
+		},
+	}
+
+	for index, tt := range cases {
+		segments := NewSegments(tt.Segments)
+		ctx := WithSegments(context.Background(), segments)
+		err := segments.Build(ctx)
+		if err == nil {
+			_, err = BuildCondition(ctx, tt.Items, LogicAnd)
+		}
+		assert.EqualError(t, err, tt.Err, index)
+
+		var segmentErr *SegmentError
+		if assert.Equal(t, tt.Segment != "", errors.As(err, &segmentErr), index) && tt.Segment != "" {
+			assert.Equal(t, tt.Segment, segmentErr.Name, index)
+		}
+	}
+}
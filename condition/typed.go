@@ -0,0 +1,47 @@
+package condition
+
+import (
+	"fmt"
+
+	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/utils"
+	"github.com/airunny/filter/variables"
+)
+
+// checkSignature 构建条件时检查变量以及比较值的类型是否符合操作的签名，类型未知时在执行时检查，参见 operations.Typed
+func checkSignature(name string, operation operations.Operation, operand variables.Variable, value interface{}) error {
+	signature := operations.SignatureOf(operation)
+	if t := variables.TypeOf(name); !signature.AcceptVariable(t) {
+		return fmt.Errorf("operation [%s] does not accept %s variable [%s]", operation.Name(), t, name)
+	}
+
+	if operand != nil {
+		if t := variables.TypeOf(operand.Name()); !signature.AcceptOperandType(t) {
+			return fmt.Errorf("operation [%s] does not accept %s operand [%s]", operation.Name(), t, operand.Name())
+		}
+		return nil
+	}
+
+	if _, ok := value.(utils.Set); ok {
+		if !signature.Sets {
+			return fmt.Errorf("operation [%s] does not accept list [%v]", operation.Name(), value)
+		}
+		return nil
+	}
+
+	if !signature.AcceptOperand(value) {
+		return fmt.Errorf("operation [%s] value [%v] is not %s", operation.Name(), value, typeNames(signature))
+	}
+	return nil
+}
+
+func typeNames(signature operations.Signature) string {
+	names := ""
+	for index, t := range signature.Operands {
+		if index > 0 {
+			names += " or "
+		}
+		names += t.String()
+	}
+	return names
+}
@@ -0,0 +1,54 @@
+package condition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSignature(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		Items []interface{}
+		Err   string
+	}{
+		{Items: []interface{}{"version", "vgt", "3.0"}},
+		{Items: []interface{}{"version", "vlte", float64(3.4)}},
+		{Items: []interface{}{"hour", "between", "10,19"}},
+		{Items: []interface{}{"date", ">=", "2024-01-01"}},
+		{Items: []interface{}{"ip", "iir", "10.0.0.0/8"}},
+		{Items: []interface{}{"ip", "~", "/^10\\./"}},
+		{Items: []interface{}{"calc.__a * 2", ">", 1}},
+		{Items: []interface{}{"version", "vgte", "$data.min_version"}},
+		{Items: []interface{}{"platform", "=", 1}},
+		// 类型未知的变量在执行时检查
+		{Items: []interface{}{"data.ip", "between", "a,b"}},
+		{Items: []interface{}{"data.tags", "has", "a,b"}},
+		// 变量可以是逗号分隔的字符串、数字或者单个字符串
+		{Items: []interface{}{"platform", "any", "ios,android"}},
+		{Items: []interface{}{"platform", "not", "ios,android"}},
+		{Items: []interface{}{"city", "has", "上海"}},
+		{Items: []interface{}{"hour", "has", "10"}},
+		{Items: []interface{}{"hour", "any", []interface{}{10, 11}}},
+		{Items: []interface{}{"rand", "not", "1,2"}},
+		{Items: []interface{}{"ip", "between", "a,b"}, Err: "operation [between] does not accept ip variable [ip]"},
+		{Items: []interface{}{"version", ">", "3.0"}, Err: "operation [>] does not accept version variable [version]"},
+		{Items: []interface{}{"hour", "vgt", "3.x"}, Err: "operation [vgt] does not accept number variable [hour]"},
+		{Items: []interface{}{"hour?false", "~", "/1/"}, Err: "operation [~] does not accept number variable [hour]"},
+		{Items: []interface{}{"version", "has", []interface{}{"3.0"}}, Err: "operation [has] does not accept version variable [version]"},
+		{Items: []interface{}{"version", "vgt", "3.x"}, Err: "operation [vgt] value [3.x] is not version"},
+		{Items: []interface{}{"data.version", "!vgt", "3.x"}, Err: "operation [!vgt] value [3.x] is not version"},
+		{Items: []interface{}{"version", "vgte", "$hour"}, Err: "operation [vgte] does not accept number operand [hour]"},
+		{Items: []interface{}{"platform", "in", true}, Err: "operation [in] value [true] is not list"},
+	}
+
+	for index, tt := range cases {
+		_, err := BuildCondition(ctx, tt.Items, LogicAnd)
+		if tt.Err != "" {
+			assert.EqualError(t, err, tt.Err, index)
+			continue
+		}
+		assert.Nil(t, err, index)
+	}
+}
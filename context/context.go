@@ -2,6 +2,7 @@ package context
 
 import (
 	"context"
+	"time"
 )
 
 type (
@@ -14,6 +15,7 @@ type (
 	uaKey       struct{}
 	refererKey  struct{}
 	userTagKey  struct{}
+	nowKey      struct{}
 )
 
 func WithUserID(ctx context.Context, userId interface{}) context.Context {
@@ -87,3 +89,12 @@ func FromUserTag(ctx context.Context) (interface{}, bool) {
 	value, ok := ctx.Value(userTagKey{}).(interface{})
 	return value, ok
 }
+
+// WithNow 固定时间变量使用的当前时间，用于回放或者复现某个时间点的请求
+func WithNow(ctx context.Context, now time.Time) context.Context {
+	return context.WithValue(ctx, nowKey{}, now)
+}
+func FromNow(ctx context.Context) (time.Time, bool) {
+	value, ok := ctx.Value(nowKey{}).(time.Time)
+	return value, ok
+}
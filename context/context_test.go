@@ -3,6 +3,7 @@ package context
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -136,3 +137,14 @@ func TestWithCommonValue(t *testing.T) {
 		assert.Equal(t, v.Expected, ret)
 	}
 }
+
+func TestWithNow(t *testing.T) {
+	ctx := context.Background()
+	_, ok := FromNow(ctx)
+	assert.False(t, ok)
+
+	now := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	got, ok := FromNow(WithNow(ctx, now))
+	assert.True(t, ok)
+	assert.Equal(t, now, got)
+}
@@ -0,0 +1,339 @@
+package dsl
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/airunny/filter/condition"
+	"github.com/airunny/filter/types"
+)
+
+// Format 将json格式的条件数组转化为文本表达式，第四个元素中的选项转化为 cached(...)、ordered(...)，
+// 值为false的选项跟没有配置时一致，会被省略
+func Format(items []interface{}) (string, error) {
+	var builder strings.Builder
+	err := formatCondition(&builder, items, precedenceOr)
+	if err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+const (
+	logicNor  = "nor"
+	logicNand = "nand"
+)
+
+const (
+	precedenceOr = iota
+	precedenceAnd
+	precedenceUnary
+)
+
+func formatCondition(builder *strings.Builder, items []interface{}, parent int) error {
+	if len(items) == 0 {
+		return errors.New("condition is empty")
+	}
+
+	// 条件组，默认为and
+	if types.IsArray(items[0]) {
+		return formatGroup(builder, keywordAnd, items, parent)
+	}
+
+	if len(items) == 4 {
+		return formatOptions(builder, items, parent)
+	}
+
+	if len(items) != 3 {
+		return errors.New("condition item must contains three element")
+	}
+
+	key, ok := items[0].(string)
+	if !ok {
+		return fmt.Errorf("condition item 1st element[%v] is not string", items[0])
+	}
+
+	logic := strings.ToLower(key)
+	switch logic {
+	case keywordAnd, keywordOr, keywordNot:
+		children, ok := items[2].([]interface{})
+		if !ok {
+			return fmt.Errorf("group condition [%s] 3rd element is not array", key)
+		}
+
+		// 跟condition.BuildCondition保持一致，子项不是条件组时忽略逻辑关系
+		if len(children) > 0 && !types.IsArray(children[0]) {
+			return formatCondition(builder, children, parent)
+		}
+		return formatGroup(builder, logic, children, parent)
+	case logicNor, logicNand:
+		children, ok := items[2].([]interface{})
+		if !ok {
+			return fmt.Errorf("group condition [%s] 3rd element is not array", key)
+		}
+
+		if len(children) > 0 && !types.IsArray(children[0]) {
+			return formatCondition(builder, children, parent)
+		}
+		if len(children) == 0 {
+			return errors.New("condition is empty")
+		}
+
+		// nor 等价于 not (a or b)，nand 等价于 not (a and b)
+		group := keywordOr
+		if logic == logicNand {
+			group = keywordAnd
+		}
+		builder.WriteString(keywordNot)
+		builder.WriteByte(' ')
+		return formatGroup(builder, group, children, precedenceUnary)
+	}
+
+	// xor、atleast:N、atmost:N 写作 xor(a, b)、atleast 2 (a, b, c)
+	if logic, threshold, ok, err := condition.ParseLogic(key); ok {
+		if err != nil {
+			return err
+		}
+		return formatLogicGroup(builder, key, logic, threshold, items[2], parent)
+	}
+
+	operation, ok := items[1].(string)
+	if !ok {
+		return fmt.Errorf("condition operation should be string [%v]", items[1])
+	}
+
+	builder.WriteString(formatName(key))
+	builder.WriteByte(' ')
+	builder.WriteString(operation)
+	builder.WriteByte(' ')
+	if condition.IsQuantifier(operation) {
+		children, ok := items[2].([]interface{})
+		if !ok {
+			return fmt.Errorf("[%s] condition of variable [%s] should be non-empty array", operation, key)
+		}
+
+		builder.WriteByte('(')
+		if err := formatCondition(builder, children, precedenceOr); err != nil {
+			return err
+		}
+		builder.WriteByte(')')
+		return nil
+	}
+	return formatValue(builder, items[2], true)
+}
+
+// formatOptions 将 ["and", "=>", [...], {"cached": true, "ordered": true}] 转化为 cached(ordered(a and b))
+func formatOptions(builder *strings.Builder, items []interface{}, parent int) error {
+	key, ok := items[0].(string)
+	if !ok {
+		return fmt.Errorf("condition item 1st element[%v] is not string", items[0])
+	}
+
+	var cached, ordered bool
+	if _, _, group, _ := condition.ParseLogic(key); group {
+		opts, err := condition.ParseGroupOptions(items[3])
+		if err != nil {
+			return fmt.Errorf("group condition [%s]: %w", key, err)
+		}
+		cached, ordered = opts.Cached, opts.Ordered
+
+		// 跟condition.BuildCondition保持一致，子项不是条件组时忽略逻辑关系，执行顺序也没有意义
+		if children, ok := items[2].([]interface{}); ok && len(children) > 0 && !types.IsArray(children[0]) {
+			ordered = false
+		}
+	} else {
+		opts, err := condition.ParseConditionOptions(items[3])
+		if err != nil {
+			return fmt.Errorf("condition [%s]: %w", key, err)
+		}
+		cached = opts.Cached
+	}
+
+	items = items[:3]
+	if !cached && !ordered {
+		return formatCondition(builder, items, parent)
+	}
+
+	if cached {
+		builder.WriteString(optionCached + "(")
+	}
+	if ordered {
+		// nor、nand 写作 not ordered(a or b)、not ordered(a and b)
+		switch strings.ToLower(key) {
+		case logicNor:
+			builder.WriteString(keywordNot + " ")
+			items = []interface{}{keywordOr, items[1], items[2]}
+		case logicNand:
+			builder.WriteString(keywordNot + " ")
+			items = []interface{}{keywordAnd, items[1], items[2]}
+		}
+		builder.WriteString(optionOrdered + "(")
+	}
+
+	if err := formatCondition(builder, items, precedenceOr); err != nil {
+		return err
+	}
+
+	if ordered {
+		builder.WriteByte(')')
+	}
+	if cached {
+		builder.WriteByte(')')
+	}
+	return nil
+}
+
+func formatLogicGroup(builder *strings.Builder, key string, logic condition.Logic, threshold int, value interface{}, parent int) error {
+	children, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("group condition [%s] 3rd element is not array", key)
+	}
+
+	if len(children) > 0 && !types.IsArray(children[0]) {
+		return formatCondition(builder, children, parent)
+	}
+	if len(children) == 0 {
+		return errors.New("condition is empty")
+	}
+
+	switch logic {
+	case condition.LogicXor:
+		builder.WriteString(logicXor)
+	case condition.LogicAtLeast:
+		builder.WriteString(logicAtLeast + " " + strconv.Itoa(threshold) + " ")
+	case condition.LogicAtMost:
+		builder.WriteString(logicAtMost + " " + strconv.Itoa(threshold) + " ")
+	default:
+		return fmt.Errorf("group condition [%s] can't be written as expression", key)
+	}
+
+	builder.WriteByte('(')
+	for index, child := range children {
+		if index > 0 {
+			builder.WriteString(", ")
+		}
+
+		if err := formatChild(builder, child, precedenceOr); err != nil {
+			return err
+		}
+	}
+	builder.WriteByte(')')
+	return nil
+}
+
+func formatGroup(builder *strings.Builder, logic string, children []interface{}, parent int) error {
+	if len(children) == 0 {
+		return errors.New("condition is empty")
+	}
+
+	if logic == keywordNot {
+		builder.WriteString(keywordNot)
+		builder.WriteByte(' ')
+		if len(children) == 1 {
+			return formatChild(builder, children[0], precedenceUnary)
+		}
+		// not 表示所有子条件均不成立，等价于 not (a or b)
+		return formatGroup(builder, keywordOr, children, precedenceUnary)
+	}
+
+	if len(children) == 1 {
+		return formatChild(builder, children[0], parent)
+	}
+
+	precedence := precedenceAnd
+	if logic == keywordOr {
+		precedence = precedenceOr
+	}
+
+	if precedence < parent {
+		builder.WriteByte('(')
+	}
+
+	for index, child := range children {
+		if index > 0 {
+			builder.WriteByte(' ')
+			builder.WriteString(logic)
+			builder.WriteByte(' ')
+		}
+
+		err := formatChild(builder, child, precedence+1)
+		if err != nil {
+			return err
+		}
+	}
+
+	if precedence < parent {
+		builder.WriteByte(')')
+	}
+	return nil
+}
+
+func formatChild(builder *strings.Builder, child interface{}, parent int) error {
+	items, ok := child.([]interface{})
+	if !ok {
+		return errors.New("condition item is not array")
+	}
+	return formatCondition(builder, items, parent)
+}
+
+func formatName(name string) string {
+	if name == "" {
+		return "``"
+	}
+
+	switch strings.ToLower(name) {
+	case keywordAnd, keywordOr, keywordNot:
+		return "`" + name + "`"
+	}
+
+	for _, r := range strings.TrimSuffix(name, "?") {
+		if !isNameRune(r) {
+			return "`" + name + "`"
+		}
+	}
+	return name
+}
+
+func formatValue(builder *strings.Builder, value interface{}, top bool) error {
+	switch types.GetFilterType(value) {
+	case types.NULL:
+		builder.WriteString("null")
+	case types.BOOL:
+		builder.WriteString(strconv.FormatBool(value.(bool)))
+	case types.NUMBER:
+		builder.WriteString(strconv.FormatFloat(types.GetFloat(value), 'f', -1, 64))
+	case types.STRING:
+		builder.WriteString(formatString(value.(string)))
+	case types.ARRAY:
+		elements, ok := value.([]interface{})
+		if !top || !ok {
+			return fmt.Errorf("unsupported value %v", value)
+		}
+
+		builder.WriteByte('(')
+		for index, element := range elements {
+			if index > 0 {
+				builder.WriteString(", ")
+			}
+
+			err := formatValue(builder, element, false)
+			if err != nil {
+				return err
+			}
+		}
+		builder.WriteByte(')')
+	default:
+		return fmt.Errorf("unsupported value %v", value)
+	}
+	return nil
+}
+
+// formatString 正则表达式保持原样，其他字符串统一加上双引号
+func formatString(value string) string {
+	if len(value) > 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") && !strings.Contains(value[1:len(value)-1], "/") {
+		return value
+	}
+	return strconv.Quote(value)
+}
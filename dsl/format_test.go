@@ -0,0 +1,197 @@
+package dsl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/condition"
+	filterContext "github.com/airunny/filter/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		Json string
+		Src  string
+		Err  error
+	}{
+		{
+			Json: `["success","=",1]`,
+			Src:  `success = 1`,
+		},
+		{
+			Json: `[["city","in","上海,天津,北京"],["hour","between","10,19"]]`,
+			Src:  `city in "上海,天津,北京" and hour between "10,19"`,
+		},
+		{
+			Json: `["or","=>",[[["city","in",["上海","北京"]],["hour","between",[10,19]]],[["version","vgt","3.4.5"],["ip","iir","192.168.1.1/24"]]]]`,
+			Src:  `city in ("上海", "北京") and hour between (10, 19) or version vgt "3.4.5" and ip iir "192.168.1.1/24"`,
+		},
+		{
+			Json: `["and","=>",[["or","=>",[["uid","=","1"],["uid","=","2"]]],["ua","~","/iphone/"]]]`,
+			Src:  `(uid = "1" or uid = "2") and ua ~ /iphone/`,
+		},
+		{
+			Json: `["not","=>",[["city","in","上海,天津,北京"],["hour","between","10,19"]]]`,
+			Src:  `not (city in "上海,天津,北京" or hour between "10,19")`,
+		},
+		{
+			Json: `["not","=>",[["not","=>",[["is_login","=",true]]]]]`,
+			Src:  `not not is_login = true`,
+		},
+		{
+			Json: `["not","=>",["is_login","=",true]]`,
+			Src:  `is_login = true`,
+		},
+		{
+			Json: `[["not","=>",[["version?","vgt","3.0"]]],["data.age?false",">=",18]]`,
+			Src:  "not version? vgt \"3.0\" and `data.age?false` >= 18",
+		},
+		{
+			Json: `["nor","=>",[["city","in","上海,天津,北京"],["hour","between","10,19"]]]`,
+			Src:  `not (city in "上海,天津,北京" or hour between "10,19")`,
+		},
+		{
+			Json: `[["NAND","=>",[["uid","=","1"],["or","=>",[["ua","~","/iphone/"],["ua","~","/ipad/"]]]]],["is_login","=",true]]`,
+			Src:  `not (uid = "1" and (ua ~ /iphone/ or ua ~ /ipad/)) and is_login = true`,
+		},
+		{
+			Json: `["nand","=>",[["is_login","=",true]]]`,
+			Src:  `not is_login = true`,
+		},
+		{
+			Json: `["nor","=>",["is_login","=",true]]`,
+			Src:  `is_login = true`,
+		},
+		{
+			Json: `[["data.orders","allof",[["status","=","paid"],["or","=>",[["amount",">",100],["vip","=",true]]]]],["uid","=","1"]]`,
+			Src:  `data.orders allof (status = "paid" and (amount > 100 or vip = true)) and uid = "1"`,
+		},
+		{
+			Json: `["calc.__a + __b","=",null]`,
+			Src:  "`calc.__a + __b` = null",
+		},
+		{
+			Json: `["ua","~","/a/b/"]`,
+			Src:  `ua ~ "/a/b/"`,
+		},
+		{
+			Json: `["XOR","=>",[["uid","=","1"],["uid","=","2"]]]`,
+			Src:  `xor(uid = "1", uid = "2")`,
+		},
+		{
+			Json: `[["atleast:2","=>",[["uid","=","1"],["or","=>",[["ua","~","/iphone/"],["ua","~","/ipad/"]]],["hour","between",[10,19]]]],["is_login","=",true]]`,
+			Src:  `atleast 2 (uid = "1", ua ~ /iphone/ or ua ~ /ipad/, hour between (10, 19)) and is_login = true`,
+		},
+		{
+			Json: `["not","=>",[["atmost:1","=>",[["xor","=>",[["uid","=","1"],["uid","=","2"]]],["is_login","=",true]]]]]`,
+			Src:  `not atmost 1 (xor(uid = "1", uid = "2"), is_login = true)`,
+		},
+		{
+			Json: `["xor","=>",["is_login","=",true]]`,
+			Src:  `is_login = true`,
+		},
+		{
+			Json: `[["data.age",">",18,{"cached":true}],["or","=>",[["uid","=","1"],["uid","=","2"]],{"ordered":true,"cached":true}]]`,
+			Src:  `cached(data.age > 18) and cached(ordered(uid = "1" or uid = "2"))`,
+		},
+		{
+			Json: `["not","=>",[["nor","=>",[["uid","=","1"],["uid","=","2"]],{"ordered":true}]]]`,
+			Src:  `not not ordered(uid = "1" or uid = "2")`,
+		},
+		{
+			Json: `[["atleast:1","=>",[["uid","=","1"],["data.orders","anyof",[["status","=","paid"]],{"cached":true}]],{"cached":true}],["data.age",">",18,{"cached":false}]]`,
+			Src:  `cached(atleast 1 (uid = "1", cached(data.orders anyof (status = "paid")))) and data.age > 18`,
+		},
+		{
+			Json: `["and","=>",["is_login","=",true],{"ordered":true}]`,
+			Src:  `is_login = true`,
+		},
+		// err
+		{
+			Json: `[]`,
+			Err:  errors.New("condition is empty"),
+		},
+		{
+			Json: `["and","=>",[["uid","=","1"],["uid","=","2"]],{"ordered":"true"}]`,
+			Err:  errors.New("group condition [and]: group option [ordered] should be bool"),
+		},
+		{
+			Json: `["data.age",">",18,{"ordered":true}]`,
+			Err:  errors.New("condition [data.age]: unknown condition option [ordered]"),
+		},
+		{
+			Json: `["atleast:x","=>",[["uid","=","1"],["uid","=","2"]]]`,
+			Err:  errors.New("group condition [atleast:x] threshold should be non-negative integer"),
+		},
+		{
+			Json: `["nor","=>",[]]`,
+			Err:  errors.New("condition is empty"),
+		},
+		{
+			Json: `["success","="]`,
+			Err:  errors.New("condition item must contains three element"),
+		},
+		{
+			Json: `["and","=>","1"]`,
+			Err:  errors.New("group condition [and] 3rd element is not array"),
+		},
+		{
+			Json: `["data.a","=",{"a":1}]`,
+			Err:  errors.New("unsupported value map[a:1]"),
+		},
+	}
+
+	for index, tt := range cases {
+		var items []interface{}
+		assert.Nil(t, json.Unmarshal([]byte(tt.Json), &items), index)
+
+		src, err := Format(items)
+		if tt.Err != nil {
+			assert.EqualError(t, err, tt.Err.Error(), index)
+			continue
+		}
+		assert.Nil(t, err, index)
+		assert.Equal(t, tt.Src, src, index)
+
+		// 格式化之后的表达式再次解析之后格式化结果保持不变
+		parsed, err := Parse(src)
+		assert.Nil(t, err, index)
+		again, err := Format(parsed)
+		assert.Nil(t, err, index)
+		assert.Equal(t, src, again, index)
+	}
+}
+
+// 格式化之后的表达式跟原始的分组条件执行结果一致
+func TestFormatLogic(t *testing.T) {
+	ctx := context.Background()
+	for _, logic := range []string{"nor", "nand", "xor", "atleast:1", "atleast:2", "atmost:1", "atmost:0"} {
+		var items []interface{}
+		assert.Nil(t, json.Unmarshal([]byte(`["`+logic+`","=>",[["platform","=","ios"],["channel","=","appstore"]]]`), &items))
+
+		src, err := Format(items)
+		assert.Nil(t, err, logic)
+
+		expected, err := condition.BuildCondition(ctx, items, condition.LogicAnd)
+		assert.Nil(t, err, logic)
+		compiled, err := Compile(ctx, src)
+		assert.Nil(t, err, logic)
+
+		for _, platform := range []string{"ios", "android"} {
+			for _, channel := range []string{"appstore", "huawei"} {
+				c := filterContext.WithPlatform(ctx, platform)
+				c = filterContext.WithChannel(c, channel)
+
+				want, err := expected.IsConditionOk(c, nil, cache.NewCache())
+				assert.Nil(t, err)
+				got, err := compiled.IsConditionOk(c, nil, cache.NewCache())
+				assert.Nil(t, err)
+				assert.Equal(t, want, got, "%s %s %s", src, platform, channel)
+			}
+		}
+	}
+}
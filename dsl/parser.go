@@ -0,0 +1,616 @@
+package dsl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/airunny/filter/condition"
+	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/variables"
+)
+
+// SyntaxError 表达式语法错误，Line跟Column均从1开始
+type SyntaxError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (s *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", s.Line, s.Column, s.Msg)
+}
+
+// Parse 将文本表达式解析为与json配置等价的条件数组
+//
+//	city in ("上海","北京") and (hour between 10,19 or version vgt "3.4.5")
+//
+// xor、atleast:N、atmost:N 分组写作 xor(a, b)、atleast 2 (a, b, c)、atmost 1 (a, b)，子条件之间使用逗号分割，
+// 分组中的多个比较值需要使用括号，例如 atleast 1 (hour between (10, 19), platform = ios)
+//
+// 条件的选项写作 cached(a) 以及 ordered(a and b)，分别对应第四个元素 {"cached": true}、{"ordered": true}
+func Parse(src string) ([]interface{}, error) {
+	p := &parser{src: []rune(src)}
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf(p.pos, "empty expression")
+	}
+
+	item, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if !p.eof() {
+		return nil, p.errorf(p.pos, "unexpected %q", string(p.src[p.pos]))
+	}
+	return item, nil
+}
+
+// Compile 解析文本表达式并构建为条件树
+func Compile(ctx context.Context, src string) (condition.Condition, error) {
+	items, err := Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return condition.BuildCondition(ctx, items, condition.LogicAnd)
+}
+
+const (
+	keywordAnd = "and"
+	keywordOr  = "or"
+	keywordNot = "not"
+)
+
+// 按数量判断的分组，后面是用括号包含并且用逗号分割的子条件，atleast、atmost 与括号之间为N
+const (
+	logicXor     = "xor"
+	logicAtLeast = "atleast"
+	logicAtMost  = "atmost"
+)
+
+// 条件的选项，后面是用括号包含的条件，ordered 只能用于分组
+const (
+	optionCached  = "cached"
+	optionOrdered = "ordered"
+)
+
+type parser struct {
+	src     []rune
+	pos     int
+	element int // 正在解析的量词条件的层数，元素的字段可以省略 data. 前缀
+	group   int // 正在解析的 xor 等分组的层数，逗号用于分割子条件
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	line, column := 1, 1
+	for i := 0; i < pos && i < len(p.src); i++ {
+		if p.src[i] == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return &SyntaxError{
+		Line:   line,
+		Column: column,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+// keyword 判断当前位置是否为指定的关键字，是的话跳过该关键字
+func (p *parser) keyword(word string) bool {
+	p.skipSpace()
+	end := p.pos + len(word)
+	if end > len(p.src) || !strings.EqualFold(string(p.src[p.pos:end]), word) {
+		return false
+	}
+
+	if end < len(p.src) && isNameRune(p.src[end]) {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func (p *parser) parseOr() ([]interface{}, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []interface{}{first}
+	for p.keyword(keywordOr) {
+		next, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, next)
+	}
+
+	if len(children) == 1 {
+		return first, nil
+	}
+	return []interface{}{keywordOr, "=>", children}, nil
+}
+
+func (p *parser) parseAnd() ([]interface{}, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []interface{}{first}
+	for p.keyword(keywordAnd) {
+		next, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, next)
+	}
+
+	if len(children) == 1 {
+		return first, nil
+	}
+	return []interface{}{keywordAnd, "=>", children}, nil
+}
+
+func (p *parser) parseUnary() ([]interface{}, error) {
+	if p.keyword(keywordNot) {
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{keywordNot, "=>", []interface{}{child}}, nil
+	}
+
+	p.skipSpace()
+	if p.peek() == '(' {
+		start := p.pos
+		p.pos++
+		item, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		if p.peek() != ')' {
+			return nil, p.errorf(p.pos, "missing ')' for '(' at %s", p.position(start))
+		}
+		p.pos++
+		return item, nil
+	}
+
+	item, ok, err := p.parseOption()
+	if ok || err != nil {
+		return item, err
+	}
+
+	item, ok, err = p.parseLogicGroup()
+	if ok || err != nil {
+		return item, err
+	}
+	return p.parseComparison()
+}
+
+// parseOption 解析 cached(a)、ordered(a and b)，选项记录在条件的第四个元素中，
+// 跟 parseLogicGroup 一样，后面不是括号时 ok 为false并且不移动位置
+func (p *parser) parseOption() ([]interface{}, bool, error) {
+	p.skipSpace()
+	start := p.pos
+	for _, word := range []string{optionCached, optionOrdered} {
+		if !p.keyword(word) {
+			continue
+		}
+
+		p.skipSpace()
+		if p.peek() != '(' {
+			p.pos = start
+			return nil, false, nil
+		}
+
+		open := p.pos
+		p.pos++
+		item, err := p.parseOr()
+		if err != nil {
+			return nil, true, err
+		}
+
+		p.skipSpace()
+		if p.peek() != ')' {
+			return nil, true, p.errorf(p.pos, "missing ')' for '(' at %s", p.position(open))
+		}
+		p.pos++
+
+		key, _ := item[0].(string)
+		if _, _, group, _ := condition.ParseLogic(key); word == optionOrdered && !group {
+			return nil, true, p.errorf(start, "option %q only applies to group condition", word)
+		}
+
+		// 嵌套的选项合并到同一个对象中，例如 cached(ordered(a and b))
+		options := map[string]interface{}{word: true}
+		if len(item) == 4 {
+			for k, v := range item[3].(map[string]interface{}) {
+				options[k] = v
+			}
+			item = item[:3]
+		}
+		return append(item, options), true, nil
+	}
+	return nil, false, nil
+}
+
+// parseLogicGroup 解析 xor(a, b)、atleast 2 (a, b, c) 形式的分组，不是分组时 ok 为false并且不移动位置，
+// 所以名字为 xor 等的变量仍然可以用于比较，例如 xor = 1
+func (p *parser) parseLogicGroup() ([]interface{}, bool, error) {
+	p.skipSpace()
+	start := p.pos
+	for _, word := range []string{logicXor, logicAtLeast, logicAtMost} {
+		if !p.keyword(word) {
+			continue
+		}
+
+		key := word
+		if word != logicXor {
+			p.skipSpace()
+			n := p.pos
+			for !p.eof() && unicode.IsDigit(p.peek()) {
+				p.pos++
+			}
+			if n == p.pos {
+				p.pos = start
+				return nil, false, nil
+			}
+			key += ":" + string(p.src[n:p.pos])
+		}
+
+		p.skipSpace()
+		if p.peek() != '(' {
+			p.pos = start
+			return nil, false, nil
+		}
+
+		children, err := p.parseGroupChildren()
+		if err != nil {
+			return nil, true, err
+		}
+		return []interface{}{key, "=>", children}, true, nil
+	}
+	return nil, false, nil
+}
+
+func (p *parser) parseGroupChildren() ([]interface{}, error) {
+	start := p.pos
+	p.pos++
+	p.group++
+	defer func() { p.group-- }()
+
+	var children []interface{}
+	for {
+		child, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return children, nil
+		default:
+			if p.eof() {
+				return nil, p.errorf(p.pos, "missing ')' for '(' at %s", p.position(start))
+			}
+			return nil, p.errorf(p.pos, "unexpected %q in group, expecting ',' or ')'", string(p.peek()))
+		}
+	}
+}
+
+func (p *parser) position(pos int) string {
+	err := p.errorf(pos, "").(*SyntaxError)
+	return fmt.Sprintf("line %d, column %d", err.Line, err.Column)
+}
+
+func (p *parser) parseComparison() ([]interface{}, error) {
+	p.skipSpace()
+	start := p.pos
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+
+	variable, _, _ := condition.ParseMissing(name)
+	if _, ok := variables.Get(variable); !ok && name != condition.SegmentVariable && p.element == 0 {
+		return nil, p.errorf(start, "unknown variable %q", name)
+	}
+
+	p.skipSpace()
+	opStart := p.pos
+	operation := p.parseOperation()
+	if operation == "" {
+		if p.eof() {
+			return nil, p.errorf(p.pos, "missing operation after %q", name)
+		}
+		return nil, p.errorf(p.pos, "unexpected %q, expecting operation", string(p.peek()))
+	}
+
+	if condition.IsQuantifier(operation) {
+		return p.parseQuantifier(name, operation)
+	}
+
+	if _, ok := operations.Get(operation); !ok {
+		return nil, p.errorf(opStart, "unknown operation %q", operation)
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{name, operation, value}, nil
+}
+
+// parseQuantifier 量词之后是用括号包含的元素的条件，例如 data.orders anyof (status = paid and amount > 100)
+func (p *parser) parseQuantifier(name, quantifier string) ([]interface{}, error) {
+	p.skipSpace()
+	if p.peek() != '(' {
+		return nil, p.errorf(p.pos, "missing '(' after %q", quantifier)
+	}
+
+	start := p.pos
+	p.pos++
+	p.element++
+	item, err := p.parseOr()
+	p.element--
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if p.peek() != ')' {
+		return nil, p.errorf(p.pos, "missing ')' for '(' at %s", p.position(start))
+	}
+	p.pos++
+	return []interface{}{name, quantifier, item}, nil
+}
+
+func isNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-'
+}
+
+func (p *parser) parseName() (string, error) {
+	if p.eof() {
+		return "", p.errorf(p.pos, "unexpected end of expression, expecting variable")
+	}
+
+	if p.peek() == '`' {
+		start := p.pos
+		p.pos++
+		for !p.eof() && p.peek() != '`' {
+			p.pos++
+		}
+		if p.eof() {
+			return "", p.errorf(start, "unterminated quoted variable")
+		}
+		name := string(p.src[start+1 : p.pos])
+		p.pos++
+		return name, nil
+	}
+
+	start := p.pos
+	for !p.eof() && isNameRune(p.peek()) {
+		p.pos++
+	}
+
+	if start == p.pos {
+		return "", p.errorf(p.pos, "unexpected %q, expecting variable", string(p.peek()))
+	}
+
+	name := string(p.src[start:p.pos])
+	switch strings.ToLower(name) {
+	case keywordAnd, keywordOr, keywordNot:
+		return "", p.errorf(start, "unexpected keyword %q, expecting variable", name)
+	}
+
+	// 变量不存在时结果未知，例如 version? vgt 3.0，其它缺失策略需要使用反引号
+	if !p.eof() && p.peek() == '?' {
+		p.pos++
+		name += "?"
+	}
+	return name, nil
+}
+
+func isSymbolOperation(r rune) bool {
+	return strings.ContainsRune("=!<>~*", r)
+}
+
+func (p *parser) parseOperation() string {
+	start := p.pos
+	if p.peek() == '!' && p.pos+1 < len(p.src) && unicode.IsLetter(p.src[p.pos+1]) {
+		p.pos++
+	}
+
+	if unicode.IsLetter(p.peek()) {
+		for !p.eof() && (unicode.IsLetter(p.peek()) || unicode.IsDigit(p.peek()) || p.peek() == '_') {
+			p.pos++
+		}
+		return string(p.src[start:p.pos])
+	}
+
+	for !p.eof() && isSymbolOperation(p.peek()) {
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+// parseValue 比较值可以是单个值、用英文逗号分割的多个值或者是用括号包含的列表
+func (p *parser) parseValue() (interface{}, error) {
+	p.skipSpace()
+	if p.peek() == '(' || p.peek() == '[' {
+		return p.parseList()
+	}
+
+	first, err := p.parseScalar()
+	if err != nil {
+		return nil, err
+	}
+
+	// 分组中的逗号用于分割子条件
+	p.skipSpace()
+	if p.peek() != ',' || p.group > 0 {
+		return first, nil
+	}
+
+	values := []interface{}{first}
+	for p.peek() == ',' {
+		p.pos++
+		value, err := p.parseScalar()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		p.skipSpace()
+	}
+	return values, nil
+}
+
+func (p *parser) parseList() (interface{}, error) {
+	var (
+		start = p.pos
+		open  = p.peek()
+		close = ')'
+	)
+
+	if open == '[' {
+		close = ']'
+	}
+
+	p.pos++
+	values := make([]interface{}, 0, 2)
+	p.skipSpace()
+	if p.peek() == close {
+		p.pos++
+		return values, nil
+	}
+
+	for {
+		value, err := p.parseScalar()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case close:
+			p.pos++
+			return values, nil
+		default:
+			if p.eof() {
+				return nil, p.errorf(start, "unterminated list")
+			}
+			return nil, p.errorf(p.pos, "unexpected %q in list, expecting ',' or '%c'", string(p.peek()), close)
+		}
+	}
+}
+
+func isBareRune(r rune) bool {
+	return !unicode.IsSpace(r) && !strings.ContainsRune(",()[]\"`", r)
+}
+
+func (p *parser) parseScalar() (interface{}, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf(p.pos, "unexpected end of expression, expecting value")
+	}
+
+	start := p.pos
+	switch p.peek() {
+	case '"':
+		p.pos++
+		for !p.eof() && p.peek() != '"' {
+			if p.peek() == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.eof() {
+			return nil, p.errorf(start, "unterminated string")
+		}
+		p.pos++
+		value, err := strconv.Unquote(string(p.src[start:p.pos]))
+		if err != nil {
+			return nil, p.errorf(start, "invalid string %s", string(p.src[start:p.pos]))
+		}
+		return value, nil
+	case '/':
+		p.pos++
+		for !p.eof() && p.peek() != '/' {
+			if p.peek() == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.eof() {
+			return nil, p.errorf(start, "unterminated regexp")
+		}
+		p.pos++
+		return string(p.src[start:p.pos]), nil
+	}
+
+	for !p.eof() && isBareRune(p.peek()) {
+		p.pos++
+	}
+
+	if start == p.pos {
+		return nil, p.errorf(p.pos, "unexpected %q, expecting value", string(p.peek()))
+	}
+
+	word := string(p.src[start:p.pos])
+	switch strings.ToLower(word) {
+	case keywordAnd, keywordOr, keywordNot:
+		return nil, p.errorf(start, "unexpected keyword %q, expecting value", word)
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	if isNumberStart(p.src[start]) {
+		if number, err := strconv.ParseFloat(word, 64); err == nil {
+			return number, nil
+		}
+	}
+	return word, nil
+}
+
+func isNumberStart(r rune) bool {
+	return unicode.IsDigit(r) || r == '-' || r == '+' || r == '.'
+}
@@ -0,0 +1,256 @@
+package dsl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/condition"
+	filterContext "github.com/airunny/filter/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		Src   string
+		Items []interface{}
+		Err   error
+	}{
+		{
+			Src:   `success = 1`,
+			Items: []interface{}{"success", "=", float64(1)},
+		},
+		{
+			Src: `segment = vip and data.price <= "$data.balance"`,
+			Items: []interface{}{"and", "=>", []interface{}{
+				[]interface{}{"segment", "=", "vip"},
+				[]interface{}{"data.price", "<=", "$data.balance"},
+			}},
+		},
+		{
+			Src: `city in ("上海","北京") and (hour between 10,19 or version vgt "3.4.5")`,
+			Items: []interface{}{"and", "=>", []interface{}{
+				[]interface{}{"city", "in", []interface{}{"上海", "北京"}},
+				[]interface{}{"or", "=>", []interface{}{
+					[]interface{}{"hour", "between", []interface{}{float64(10), float64(19)}},
+					[]interface{}{"version", "vgt", "3.4.5"},
+				}},
+			}},
+		},
+		{
+			Src: `platform = ios or platform = android and not channel in [a, b]`,
+			Items: []interface{}{"or", "=>", []interface{}{
+				[]interface{}{"platform", "=", "ios"},
+				[]interface{}{"and", "=>", []interface{}{
+					[]interface{}{"platform", "=", "android"},
+					[]interface{}{"not", "=>", []interface{}{
+						[]interface{}{"channel", "in", []interface{}{"a", "b"}},
+					}},
+				}},
+			}},
+		},
+		{
+			Src: "ua ~ /iphone|ipad/ AND ip iir 192.168.1.1/24\nand data.user.age >= -1.5 and data.vip = true",
+			Items: []interface{}{"and", "=>", []interface{}{
+				[]interface{}{"ua", "~", "/iphone|ipad/"},
+				[]interface{}{"ip", "iir", "192.168.1.1/24"},
+				[]interface{}{"data.user.age", ">=", -1.5},
+				[]interface{}{"data.vip", "=", true},
+			}},
+		},
+		{
+			Src:   "`calc.__a * __b` > 10",
+			Items: []interface{}{"calc.__a * __b", ">", float64(10)},
+		},
+		{
+			Src: "version? vgt 3.0 or `data.age?=18` >= 18",
+			Items: []interface{}{"or", "=>", []interface{}{
+				[]interface{}{"version?", "vgt", float64(3)},
+				[]interface{}{"data.age?=18", ">=", float64(18)},
+			}},
+		},
+		{
+			Src: `hour !between 10,19 and version !vgt 3.0 and data.age !> 18`,
+			Items: []interface{}{"and", "=>", []interface{}{
+				[]interface{}{"hour", "!between", []interface{}{float64(10), float64(19)}},
+				[]interface{}{"version", "!vgt", float64(3)},
+				[]interface{}{"data.age", "!>", float64(18)},
+			}},
+		},
+		{
+			Src: `data.orders anyof (status = paid and data.amount > 100) and data.tags noneof (data.name = blocked)`,
+			Items: []interface{}{"and", "=>", []interface{}{
+				[]interface{}{"data.orders", "anyof", []interface{}{"and", "=>", []interface{}{
+					[]interface{}{"status", "=", "paid"},
+					[]interface{}{"data.amount", ">", float64(100)},
+				}}},
+				[]interface{}{"data.tags", "noneof", []interface{}{"data.name", "=", "blocked"}},
+			}},
+		},
+		{
+			Src:   `version vlte 3.4`,
+			Items: []interface{}{"version", "vlte", float64(3.4)},
+		},
+		{
+			Src:   `uid nin ()`,
+			Items: []interface{}{"uid", "nin", []interface{}{}},
+		},
+		{
+			Src: `XOR (platform = ios, channel in (a, b)) or atleast 2(success = 1, hour between (10, 19), not uid = 1)`,
+			Items: []interface{}{"or", "=>", []interface{}{
+				[]interface{}{"xor", "=>", []interface{}{
+					[]interface{}{"platform", "=", "ios"},
+					[]interface{}{"channel", "in", []interface{}{"a", "b"}},
+				}},
+				[]interface{}{"atleast:2", "=>", []interface{}{
+					[]interface{}{"success", "=", float64(1)},
+					[]interface{}{"hour", "between", []interface{}{float64(10), float64(19)}},
+					[]interface{}{"not", "=>", []interface{}{
+						[]interface{}{"uid", "=", float64(1)},
+					}},
+				}},
+			}},
+		},
+		{
+			Src: `atmost 1 (platform = ios and channel = a, data.orders anyof (status = paid)) and data.city in a,b`,
+			Items: []interface{}{"and", "=>", []interface{}{
+				[]interface{}{"atmost:1", "=>", []interface{}{
+					[]interface{}{"and", "=>", []interface{}{
+						[]interface{}{"platform", "=", "ios"},
+						[]interface{}{"channel", "=", "a"},
+					}},
+					[]interface{}{"data.orders", "anyof", []interface{}{"status", "=", "paid"}},
+				}},
+				[]interface{}{"data.city", "in", []interface{}{"a", "b"}},
+			}},
+		},
+		{
+			Src: `cached(hour > 10) or Cached (ordered(cached(platform = ios and channel = a)))`,
+			Items: []interface{}{"or", "=>", []interface{}{
+				[]interface{}{"hour", ">", float64(10), map[string]interface{}{"cached": true}},
+				[]interface{}{"and", "=>", []interface{}{
+					[]interface{}{"platform", "=", "ios"},
+					[]interface{}{"channel", "=", "a"},
+				}, map[string]interface{}{"cached": true, "ordered": true}},
+			}},
+		},
+		// err
+		{
+			Src: "  ",
+			Err: &SyntaxError{Line: 1, Column: 3, Msg: "empty expression"},
+		},
+		{
+			Src: "success = 1 and\n  golang = 1",
+			Err: &SyntaxError{Line: 2, Column: 3, Msg: `unknown variable "golang"`},
+		},
+		{
+			Src: "success == 1",
+			Err: &SyntaxError{Line: 1, Column: 9, Msg: `unknown operation "=="`},
+		},
+		{
+			Src: "success",
+			Err: &SyntaxError{Line: 1, Column: 8, Msg: `missing operation after "success"`},
+		},
+		{
+			Src: "success = ",
+			Err: &SyntaxError{Line: 1, Column: 11, Msg: "unexpected end of expression, expecting value"},
+		},
+		{
+			Src: "(success = 1 or success = 2",
+			Err: &SyntaxError{Line: 1, Column: 28, Msg: "missing ')' for '(' at line 1, column 1"},
+		},
+		{
+			Src: `city in ("上海" "北京")`,
+			Err: &SyntaxError{Line: 1, Column: 15, Msg: `unexpected "\"" in list, expecting ',' or ')'`},
+		},
+		{
+			Src: `ua = "iphone`,
+			Err: &SyntaxError{Line: 1, Column: 6, Msg: "unterminated string"},
+		},
+		{
+			Src: `success = 1 success = 2`,
+			Err: &SyntaxError{Line: 1, Column: 13, Msg: `unexpected "s"`},
+		},
+		{
+			Src: `data.orders anyof status = paid`,
+			Err: &SyntaxError{Line: 1, Column: 19, Msg: `missing '(' after "anyof"`},
+		},
+		{
+			Src: `status = paid`,
+			Err: &SyntaxError{Line: 1, Column: 1, Msg: `unknown variable "status"`},
+		},
+		{
+			Src: `and = 1`,
+			Err: &SyntaxError{Line: 1, Column: 1, Msg: `unexpected keyword "and", expecting variable`},
+		},
+		{
+			Src: `xor(success = 1, success = 2`,
+			Err: &SyntaxError{Line: 1, Column: 29, Msg: "missing ')' for '(' at line 1, column 4"},
+		},
+		{
+			Src: `xor(success = 1 success = 2)`,
+			Err: &SyntaxError{Line: 1, Column: 17, Msg: `unexpected "s" in group, expecting ',' or ')'`},
+		},
+		{
+			Src: `atleast 1 (hour between 10,19)`,
+			Err: &SyntaxError{Line: 1, Column: 28, Msg: `unknown variable "19"`},
+		},
+		{
+			Src: `atleast = 1`,
+			Err: &SyntaxError{Line: 1, Column: 1, Msg: `unknown variable "atleast"`},
+		},
+		{
+			Src: `success = 1 and ordered(platform = ios)`,
+			Err: &SyntaxError{Line: 1, Column: 17, Msg: `option "ordered" only applies to group condition`},
+		},
+		{
+			Src: `cached(success = 1`,
+			Err: &SyntaxError{Line: 1, Column: 19, Msg: "missing ')' for '(' at line 1, column 7"},
+		},
+	}
+
+	for index, tt := range cases {
+		items, err := Parse(tt.Src)
+		if tt.Err != nil {
+			assert.Equal(t, tt.Err, err, index)
+			continue
+		}
+		assert.Nil(t, err, index)
+		assert.Equal(t, tt.Items, items, index)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	ctx := context.Background()
+	cond, err := Compile(ctx, `platform in (ios, android) and not (channel = appstore or version vlt 3.0)`)
+	assert.Nil(t, err)
+
+	cases := []struct {
+		Platform string
+		Channel  string
+		Version  string
+		Result   bool
+	}{
+		{Platform: "ios", Channel: "huawei", Version: "3.1", Result: true},
+		{Platform: "ios", Channel: "appstore", Version: "3.1", Result: false},
+		{Platform: "android", Channel: "huawei", Version: "2.9", Result: false},
+		{Platform: "pc", Channel: "huawei", Version: "3.1", Result: false},
+	}
+
+	for index, tt := range cases {
+		c := filterContext.WithPlatform(ctx, tt.Platform)
+		c = filterContext.WithChannel(c, tt.Channel)
+		c = filterContext.WithVersion(c, tt.Version)
+		ok, err := cond.IsConditionOk(c, nil, cache.NewCache())
+		assert.Nil(t, err, index)
+		assert.Equal(t, tt.Result, ok, index)
+	}
+
+	cond, err = Compile(ctx, `cached(hour > 10 or rand > 50)`)
+	assert.Nil(t, err)
+	_, _, ok := condition.Cached(cond)
+	assert.True(t, ok)
+
+	_, err = Compile(ctx, `hour between 1,2,3`)
+	assert.EqualError(t, err, "[between] operation value must have two element")
+}
@@ -8,7 +8,9 @@ import (
 	"github.com/airunny/filter/assignment"
 	_ "github.com/airunny/filter/assignment/delete"
 	_ "github.com/airunny/filter/assignment/set"
+	"github.com/airunny/filter/hook"
 	"github.com/airunny/filter/types"
+	"github.com/airunny/filter/utils"
 )
 
 type Executor interface {
@@ -19,10 +21,35 @@ type BaseExecutor struct {
 	key        string
 	assignment assignment.Assignment
 	value      interface{}
+	raw        interface{}
 }
 
+func (s *BaseExecutor) Key() string                       { return s.key }
+func (s *BaseExecutor) Assignment() assignment.Assignment { return s.assignment }
+func (s *BaseExecutor) Value() interface{}                { return s.value }
+
+// Raw 配置中未经过 PrepareValue 的值
+func (s *BaseExecutor) Raw() interface{} { return s.raw }
+
 func (s *BaseExecutor) Execute(ctx context.Context, data interface{}) error {
-	return s.assignment.Run(ctx, data, s.key, s.value)
+	h, ok := hook.FromContext(ctx)
+	if !ok {
+		return s.assignment.Run(ctx, data, s.key, s.value)
+	}
+
+	// 赋值之前的值，key不存在时为nil
+	old, _ := utils.GetObjectValueByKey(data, s.key)
+	err := h.BeforeAssignment(ctx, s.key, s.assignment.Name(), old, s.value)
+	if err == nil {
+		err = s.assignment.Run(ctx, data, s.key, s.value)
+	}
+	h.OnAssignment(ctx, s.key, s.assignment.Name(), old, s.value, err)
+
+	// 被hook跳过的赋值不影响filter的结果
+	if errors.Is(err, hook.ErrSkipAssignment) {
+		return nil
+	}
+	return err
 }
 
 func BuildExecutor(ctx context.Context, items []interface{}) (Executor, error) {
@@ -62,5 +89,6 @@ func BuildExecutor(ctx context.Context, items []interface{}) (Executor, error) {
 		key:        key,
 		assignment: assignInstance,
 		value:      prepayValue,
+		raw:        items[2],
 	}, nil
 }
@@ -27,6 +27,8 @@ func (s *Group) Execute(ctx context.Context, data interface{}) error {
 	return nil
 }
 
+func (s *Group) Executors() []Executor { return s.executors }
+
 func (s *Group) Add(executor Executor) {
 	s.executors = append(s.executors, executor)
 }
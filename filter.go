@@ -1,17 +1,22 @@
 package filter
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math/rand"
 	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/condition"
 	"github.com/airunny/filter/executor"
+	"github.com/airunny/filter/hook"
+	"github.com/airunny/filter/lists"
 )
 
 type Reporter interface {
@@ -24,39 +29,93 @@ func (f ReportFunc) Report(ctx context.Context, data interface{}, filterIds []st
 	f(ctx, data, filterIds)
 }
 
+type FilterConfig = struct {
+	Id       string        `json:"id"`
+	Weight   int64         `json:"weight"`
+	Priority int64         `json:"priority"`
+	Filter   []interface{} `json:"Filter"`
+}
+
 type Config struct {
-	Filters []struct {
+	Filters []FilterConfig `json:"filters"`
+	Batch   bool           `json:"batch"`
+	// Optimize 构建时按照变量取值的代价重排条件，参考 condition.Optimize
+	Optimize bool `json:"optimize"`
+	// Segments 命名的分群条件，filter中通过 ["segment", "=", name] 引用，参考 condition.Segments
+	Segments map[string][]interface{} `json:"segments,omitempty"`
+}
+
+// MarshalJSON 输出跟README中一致的json格式
+func (s Config) MarshalJSON() ([]byte, error) {
+	type filterJSON struct {
 		Id       string        `json:"id"`
 		Weight   int64         `json:"weight"`
 		Priority int64         `json:"priority"`
-		Filter   []interface{} `json:"Filter"`
-	} `json:"filters"`
-	Batch bool `json:"batch"`
+		Filter   []interface{} `json:"filter"`
+	}
+
+	cnf := struct {
+		Filters  []filterJSON             `json:"filters"`
+		Batch    bool                     `json:"batch"`
+		Optimize bool                     `json:"optimize,omitempty"`
+		Segments map[string][]interface{} `json:"segments,omitempty"`
+	}{
+		Filters:  make([]filterJSON, 0, len(s.Filters)),
+		Batch:    s.Batch,
+		Optimize: s.Optimize,
+		Segments: s.Segments,
+	}
+
+	for _, filter := range s.Filters {
+		cnf.Filters = append(cnf.Filters, filterJSON(filter))
+	}
+
+	// 不转义条件中的 >、< 等字符
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(cnf)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
 }
 
 type Filter struct {
 	batch    atomic.Value
 	reporter Reporter
+	opts     *options
 }
 
-func NewFilter(ctx context.Context, jsonStr string, reporter Reporter) (*Filter, error) {
+func NewFilter(ctx context.Context, jsonStr string, reporter Reporter, opts ...Option) (*Filter, error) {
 	var cnf Config
 	err := json.NewDecoder(strings.NewReader(jsonStr)).Decode(&cnf)
 	if err != nil {
 		return nil, err
 	}
+	return NewFilterFromConfig(ctx, &cnf, reporter, opts...)
+}
 
-	batch, err := buildBatchFilter(ctx, &cnf)
+// NewFilterFromConfig 使用已经解析好的配置（例如通过 NewConfig 在代码中定义的配置）创建Filter
+func NewFilterFromConfig(ctx context.Context, cnf *Config, reporter Reporter, opts ...Option) (*Filter, error) {
+	f := newFilter(reporter, opts...)
+	err := f.RefreshConfig(ctx, cnf)
 	if err != nil {
 		return nil, err
 	}
+	return f, nil
+}
+
+func newFilter(reporter Reporter, opts ...Option) *Filter {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
 
-	batchValue := atomic.Value{}
-	batchValue.Store(batch)
 	return &Filter{
-		batch:    batchValue,
 		reporter: reporter,
-	}, nil
+		opts:     o,
+	}
 }
 
 func (s *Filter) Execute(ctx context.Context, data interface{}) (interface{}, error) {
@@ -65,11 +124,56 @@ func (s *Filter) Execute(ctx context.Context, data interface{}) (interface{}, er
 		return nil, errors.New("invalid Filter")
 	}
 
+	if s.opts != nil {
+		ctx = hook.NewContext(ctx, s.opts.hooks...)
+	}
+
+	c := cache.Acquire()
+	defer cache.Release(c)
+	return s.execute(ctx, batch, data, c)
+}
+
+// ExecuteMany 在同一个请求中并发执行多个data，只依赖请求上下文的变量以及条件结果在所有data之间共享。
+// 返回的结果跟 datas 一一对应，有错误时返回第一个错误
+func (s *Filter) ExecuteMany(ctx context.Context, datas []interface{}) ([]interface{}, error) {
+	batch, ok := s.batch.Load().(*batchFilter)
+	if !ok {
+		return nil, errors.New("invalid Filter")
+	}
+
+	if s.opts != nil {
+		ctx = hook.NewContext(ctx, s.opts.hooks...)
+	}
+
+	var (
+		shared  = cache.NewConcurrentCache()
+		results = make([]interface{}, len(datas))
+		errs    = make([]error, len(datas))
+		wg      sync.WaitGroup
+	)
+	for index, data := range datas {
+		wg.Add(1)
+		go func(index int, data interface{}) {
+			defer wg.Done()
+			results[index], errs[index] = s.execute(ctx, batch, data, shared.Child())
+		}(index, data)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (s *Filter) execute(ctx context.Context, batch *batchFilter, data interface{}, c *cache.Cache) (interface{}, error) {
 	if data == nil {
 		data = make(map[string]interface{})
 	}
 
-	_, filterIds, err := batch.Run(ctx, data, cache.NewCache())
+	_, filterIds, err := batch.Run(ctx, data, c)
 	if err != nil {
 		return nil, err
 	}
@@ -86,22 +190,41 @@ func (s *Filter) Refresh(ctx context.Context, jsonStr string) error {
 	if err != nil {
 		return err
 	}
+	return s.RefreshConfig(ctx, &cnf)
+}
 
-	batch, err := buildBatchFilter(ctx, &cnf)
+func (s *Filter) RefreshConfig(ctx context.Context, cnf *Config) error {
+	batch, err := buildBatchFilter(s.buildContext(ctx), cnf)
 	if err != nil {
 		return err
 	}
 
-	s.batch.Store(batch)
+	s.store(batch)
 	return nil
 }
 
+// buildContext 构建条件时使用的ctx，参见 WithLists
+func (s *Filter) buildContext(ctx context.Context) context.Context {
+	if s.opts != nil && s.opts.lists != nil {
+		ctx = lists.NewContext(ctx, s.opts.lists)
+	}
+	return ctx
+}
+
+func (s *Filter) store(batch *batchFilter) {
+	if s.opts != nil && s.opts.noIndex {
+		batch.index = nil
+	}
+	s.batch.Store(batch)
+}
+
 type singleFilter struct {
 	id        string
 	weight    int64
 	priority  int64
 	condition condition.Condition
 	executor  executor.Executor
+	index     int // 在 batchFilter.filters 中排序之后的位置
 }
 
 func (s *singleFilter) Weight() int64 {
@@ -112,8 +235,20 @@ func (s *singleFilter) Priority() int64 {
 	return s.priority
 }
 
-func (s *singleFilter) Run(ctx context.Context, data interface{}, cache *cache.Cache) (bool, error) {
-	ok, err := s.condition.IsConditionOk(ctx, data, cache)
+func (s *singleFilter) Run(ctx context.Context, data interface{}, c *cache.Cache) (ok bool, err error) {
+	if h, exists := hook.FromContext(ctx); exists {
+		ctx = h.BeforeFilter(ctx, s.id, data)
+		defer func() {
+			h.AfterFilter(ctx, s.id, data, ok, err)
+		}()
+	}
+
+	ok, err = s.condition.IsConditionOk(ctx, data, c)
+	// 结果未知时filter不成立
+	if condition.IsUnknown(err) {
+		return false, nil
+	}
+
 	if err != nil {
 		return false, err
 	}
@@ -123,6 +258,8 @@ func (s *singleFilter) Run(ctx context.Context, data interface{}, cache *cache.C
 	}
 
 	err = s.executor.Execute(ctx, data)
+	// 赋值之后依赖data的缓存失效
+	c.Invalidate(cache.ScopeData)
 	if err != nil {
 		return false, err
 	}
@@ -139,27 +276,75 @@ type batchFilter struct {
 	priorities []priorityBoundary
 	batch      bool
 	weight     int64
+	index      *filterIndex
 }
 
 func buildBatchFilter(ctx context.Context, cnf *Config) (*batchFilter, error) {
+	return buildBatchFilterWithLines(ctx, cnf, nil)
+}
+
+// configLines 每个filter以及分群在配置文件中所在的行
+type configLines struct {
+	filters  []int
+	segments map[string]int
+}
+
+// buildBatchFilterWithLines lines 不为空时错误信息中会带上出错的filter或者分群所在的行号
+func buildBatchFilterWithLines(ctx context.Context, cnf *Config, lines *configLines) (*batchFilter, error) {
 	batch := &batchFilter{
 		filters: make([]*singleFilter, 0, len(cnf.Filters)),
 		batch:   cnf.Batch,
 	}
 
-	for _, filter := range cnf.Filters {
+	// 所有filter共享相同的条件
+	interner := condition.NewInterner()
+	ctx = condition.WithInterner(ctx, interner)
+
+	// 分群在filter之前构建，未被引用的分群同样需要校验
+	segments := condition.NewSegments(cnf.Segments)
+	ctx = condition.WithSegments(ctx, segments)
+	err := segments.Build(ctx)
+	if err != nil {
+		var segmentErr *condition.SegmentError
+		if lines != nil && errors.As(err, &segmentErr) {
+			if line, ok := lines.segments[segmentErr.Name]; ok {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		return nil, err
+	}
+
+	if cnf.Optimize {
+		segments.Optimize()
+	}
+
+	for index, filter := range cnf.Filters {
 		single, err := buildSingleFilter(ctx, filter.Id, filter.Weight, filter.Priority, filter.Filter)
 		if err != nil {
+			if lines != nil && index < len(lines.filters) {
+				return nil, fmt.Errorf("line %d: filter [%s]: %w", lines.filters[index], filter.Id, err)
+			}
 			return nil, err
 		}
+
+		if cnf.Optimize {
+			single.condition = condition.Optimize(single.condition)
+		}
 		batch.Add(single)
 	}
+	interner.Finish()
+
+	if len(batch.filters) >= minIndexFilters {
+		batch.index = buildIndex(batch.filters)
+	}
 	return batch, nil
 }
 
 func (s *batchFilter) Run(ctx context.Context, data interface{}, cache *cache.Cache) (successNumber int, filterIds []string, err error) {
 	filters := s.filters
 	if s.weight > 0 {
+		// 复制之后再打乱，同时执行的请求互不影响
+		filters = append(make([]*singleFilter, 0, len(s.filters)), s.filters...)
 		lastBoundary := 0
 		for _, boundary := range s.priorities {
 			if boundary.weight != 0 {
@@ -169,6 +354,11 @@ func (s *batchFilter) Run(ctx context.Context, data interface{}, cache *cache.Ca
 		}
 	}
 
+	// 注册了hook时所有filter都需要执行，被索引跳过的filter同样触发hook
+	if _, traced := hook.FromContext(ctx); s.index != nil && !traced {
+		filters = s.candidates(ctx, filters, data, cache)
+	}
+
 	for _, filter := range filters {
 		var ok bool
 		ok, err = filter.Run(ctx, data, cache)
@@ -189,11 +379,38 @@ func (s *batchFilter) Run(ctx context.Context, data interface{}, cache *cache.Ca
 	return
 }
 
+// candidates 按照执行顺序返回索引筛选之后可能命中的filter
+func (s *batchFilter) candidates(ctx context.Context, filters []*singleFilter, data interface{}, cache *cache.Cache) []*singleFilter {
+	positions := s.index.candidates(ctx, data, cache)
+	candidates := make([]*singleFilter, 0, len(positions))
+
+	// 没有权重时执行顺序就是排序之后的位置
+	if s.weight <= 0 {
+		for _, position := range positions {
+			candidates = append(candidates, filters[position])
+		}
+		return candidates
+	}
+
+	marks := make([]bool, len(filters))
+	for _, position := range positions {
+		marks[position] = true
+	}
+
+	for _, filter := range filters {
+		if marks[filter.index] {
+			candidates = append(candidates, filter)
+		}
+	}
+	return candidates
+}
+
 func (s *batchFilter) Add(filter *singleFilter) {
 	s.filters = append(s.filters, filter)
 	s.weight += filter.weight
 
-	sort.Slice(s.filters, func(i, j int) bool {
+	// 优先级相同的filter保持配置顺序
+	sort.SliceStable(s.filters, func(i, j int) bool {
 		return s.filters[i].Priority() < s.filters[j].Priority()
 	})
 	s.locatePriority()
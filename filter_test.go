@@ -2,10 +2,19 @@ package filter
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
 
 	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/condition"
+	filterContext "github.com/airunny/filter/context"
+	"github.com/airunny/filter/hook"
+	"github.com/airunny/filter/lists"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -1479,3 +1488,610 @@ func TestShuffleByWeight(t *testing.T) {
 		}
 	}
 }
+
+type recordHook struct {
+	hook.Base
+	events []string
+}
+
+func (s *recordHook) BeforeFilter(ctx context.Context, id string, _ interface{}) context.Context {
+	s.events = append(s.events, "before:"+id)
+	return ctx
+}
+
+func (s *recordHook) AfterFilter(_ context.Context, id string, _ interface{}, ok bool, err error) {
+	s.events = append(s.events, fmt.Sprintf("after:%s:%v:%v", id, ok, err))
+}
+
+func (s *recordHook) OnCondition(_ context.Context, variable, operation string, value interface{}, ok bool, _ error) {
+	s.events = append(s.events, fmt.Sprintf("condition:%s%s%v:%v", variable, operation, value, ok))
+}
+
+func (s *recordHook) OnAssignment(_ context.Context, key, assignment string, _, value interface{}, _ error) {
+	s.events = append(s.events, fmt.Sprintf("assignment:%s%s%v", key, assignment, value))
+}
+
+func TestFilterHooks(t *testing.T) {
+	jsonStr := `
+{
+	"filters":[
+		{
+			"id":"1",
+			"priority": 1,
+			"filter": [
+				["success",">",1],
+				["name","=","张三"]
+			]
+		},
+		{
+			"id":"2",
+			"priority": 2,
+			"filter": [
+				["success","=",1],
+				["name","=","李四"]
+			]
+		}
+	],
+	"batch":false
+}`
+
+	var (
+		ctx       = context.Background()
+		filterRec = &recordHook{}
+		callRec   = &recordHook{}
+	)
+
+	f, err := NewFilter(ctx, jsonStr, nil, WithHooks(filterRec))
+	assert.Nil(t, err)
+
+	data, err := f.Execute(hook.NewContext(ctx, callRec), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "李四"}, data)
+
+	expected := []string{
+		"before:1",
+		"condition:success>1:false",
+		"after:1:false:<nil>",
+		"before:2",
+		"condition:success=1:true",
+		"assignment:name=李四",
+		"after:2:true:<nil>",
+	}
+	assert.Equal(t, expected, filterRec.events)
+	assert.Equal(t, expected, callRec.events)
+
+	_, err = f.Execute(ctx, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 14, len(filterRec.events))
+	assert.Equal(t, 7, len(callRec.events))
+}
+
+// guardHook 记录赋值之前的值，拦截 locked 以及 readonly 的赋值
+type guardHook struct {
+	hook.Base
+	events []string
+}
+
+func (s *guardHook) BeforeAssignment(_ context.Context, key, _ string, _, _ interface{}) error {
+	switch key {
+	case "locked":
+		return hook.ErrSkipAssignment
+	case "readonly":
+		return fmt.Errorf("key [%s] is readonly", key)
+	}
+	return nil
+}
+
+func (s *guardHook) OnAssignment(_ context.Context, key, _ string, old, value interface{}, err error) {
+	s.events = append(s.events, fmt.Sprintf("%s:%v->%v:%v", key, old, value, err))
+}
+
+func TestFilterAssignmentHook(t *testing.T) {
+	jsonStr := `
+{
+	"filters":[
+		{"id":"1","priority":1,"filter":[["success","=",1],[["name","=","李四"],["locked","=",true],["level","=",2]]]},
+		{"id":"2","priority":2,"filter":[["success","=",1],["readonly","=",true]]},
+		{"id":"3","priority":3,"filter":[["success","=",1],["age","=",20]]}
+	],
+	"batch":true
+}`
+
+	ctx := context.Background()
+	guard := &guardHook{}
+	f, err := NewFilter(ctx, jsonStr, nil, WithHooks(guard))
+	assert.Nil(t, err)
+
+	// 跳过的赋值不影响filter的结果，其它错误中止执行
+	data := map[string]interface{}{"name": "张三", "locked": false}
+	_, err = f.Execute(ctx, data)
+	assert.EqualError(t, err, "key [readonly] is readonly")
+	assert.Equal(t, map[string]interface{}{"name": "李四", "locked": false, "level": float64(2)}, data)
+	assert.Equal(t, []string{
+		"name:张三->李四:<nil>",
+		"locked:false->true:hook: skip assignment",
+		"level:<nil>->2:<nil>",
+		"readonly:<nil>->true:key [readonly] is readonly",
+	}, guard.events)
+}
+
+func TestFilterOptimize(t *testing.T) {
+	jsonStr := `
+{
+	"filters":[
+		{
+			"id":"1",
+			"filter": [
+				["freq.view?",">",1],
+				["or","=>",[["calc.1 + 1?","=",2],["platform?","=","ios"]],{"ordered":true}],
+				["platform?","=","ios"],
+				["name","=","张三"]
+			]
+		}
+	],
+	"batch":false,
+	"optimize":%v
+}
+`
+	ctx := filterContext.WithPlatform(context.Background(), "android")
+	cases := []struct {
+		optimize bool
+		events   []string
+	}{
+		{
+			optimize: false,
+			events: []string{
+				"condition:freq.view>1:false",
+			},
+		},
+		{
+			optimize: true,
+			events: []string{
+				"condition:platform=ios:false",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		rec := &recordHook{}
+		f, err := NewFilter(ctx, fmt.Sprintf(jsonStr, c.optimize), nil, WithHooks(rec))
+		assert.Nil(t, err)
+
+		data, err := f.Execute(ctx, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]interface{}{}, data)
+		assert.Equal(t, append([]string{"before:1"}, append(c.events, "after:1:false:<nil>")...), rec.events)
+	}
+}
+
+func TestFilterSharedConditions(t *testing.T) {
+	jsonStr := `
+{
+	"filters":[
+		{
+			"id":"1",
+			"filter": [
+				["platform","=","ios"],
+				["ua","~","/(?i)chrome/"],
+				["name","=","张三"]
+			]
+		},
+		{
+			"id":"2",
+			"filter": [
+				["platform","=","ios"],
+				["ua","~","/(?i)chrome/"],
+				["age","=",18]
+			]
+		}
+	],
+	"batch":true
+}`
+
+	ctx := filterContext.WithPlatform(context.Background(), "ios")
+	ctx = filterContext.WithUA(ctx, "Mozilla/5.0 Chrome/120.0")
+
+	var cnf Config
+	assert.Nil(t, json.Unmarshal([]byte(jsonStr), &cnf))
+	batch, err := buildBatchFilter(ctx, &cnf)
+	assert.Nil(t, err)
+
+	// 相同的条件只构建一次，正则只编译一次
+	first := batch.filters[0].condition.(*condition.Group).Conditions()
+	second := batch.filters[1].condition.(*condition.Group).Conditions()
+	assert.Same(t, first[0], second[0])
+	assert.Same(t, first[1], second[1])
+
+	rec := &recordHook{}
+	f, err := NewFilter(ctx, jsonStr, nil, WithHooks(rec))
+	assert.Nil(t, err)
+
+	data, err := f.Execute(ctx, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "张三", "age": float64(18)}, data)
+	assert.Equal(t, []string{
+		"before:1",
+		"condition:platform=ios:true",
+		"condition:ua~(?i)chrome:true",
+		"assignment:name=张三",
+		"after:1:true:<nil>",
+		"before:2",
+		"assignment:age=18",
+		"after:2:true:<nil>",
+	}, rec.events)
+}
+
+func TestFilterDataCache(t *testing.T) {
+	jsonStr := `
+{
+	"filters":[
+		{
+			"id":"1",
+			"priority": 1,
+			"filter": [
+				["data.level","=",1],
+				["level","=",2]
+			]
+		},
+		{
+			"id":"2",
+			"priority": 2,
+			"filter": [
+				["data.level","=",2],
+				["vip","=",true]
+			]
+		},
+		{
+			"id":"3",
+			"priority": 3,
+			"filter": [
+				["data.level","=",1],
+				["old","=",true]
+			]
+		}
+	],
+	"batch":true
+}`
+
+	f, err := NewFilter(context.Background(), jsonStr, nil)
+	assert.Nil(t, err)
+
+	// 赋值之后重新读取data中的值
+	data, err := f.Execute(context.Background(), map[string]interface{}{"level": 1})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"level": float64(2), "vip": true}, data)
+}
+
+func TestFilterExecuteMany(t *testing.T) {
+	jsonStr := `
+{
+	"filters":[
+		{
+			"id":"1",
+			"filter": [
+				["platform","=","ios"],
+				["data.age",">",18],
+				["adult","=",true]
+			]
+		}
+	],
+	"batch":true
+}`
+
+	f, err := NewFilter(context.Background(), jsonStr, nil)
+	assert.Nil(t, err)
+
+	ctx := filterContext.WithPlatform(context.Background(), "ios")
+	results, err := f.ExecuteMany(ctx, []interface{}{
+		map[string]interface{}{"age": 20},
+		map[string]interface{}{"age": 10},
+		map[string]interface{}{"age": 30},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"age": 20, "adult": true},
+		map[string]interface{}{"age": 10},
+		map[string]interface{}{"age": 30, "adult": true},
+	}, results)
+
+	_, err = f.ExecuteMany(ctx, []interface{}{map[string]interface{}{}})
+	assert.Equal(t, "data.age not found in data", err.Error())
+}
+
+func TestFilterSegments(t *testing.T) {
+	jsonStr := `
+{
+	"segments": {
+		"vip": [["data.level", ">=", 3]],
+		"vip_ios": [["platform", "=", "ios"], ["segment", "=", "vip"]]
+	},
+	"filters":[
+		{
+			"id":"1",
+			"priority": 1,
+			"filter": [
+				["segment","=","vip_ios"],
+				["level","=",1]
+			]
+		},
+		{
+			"id":"2",
+			"priority": 2,
+			"filter": [
+				["segment","=","vip"],
+				["discount","=",0.8]
+			]
+		}
+	],
+	"batch":true
+}`
+
+	rec := &recordHook{}
+	f, err := NewFilter(context.Background(), jsonStr, nil, WithHooks(rec))
+	assert.Nil(t, err)
+
+	// 分群在同一次执行中只计算一次，依赖data的分群在赋值之后重新计算
+	ctx := filterContext.WithPlatform(context.Background(), "ios")
+	data, err := f.Execute(ctx, map[string]interface{}{"level": 3})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"level": float64(1)}, data)
+	assert.Equal(t, []string{
+		"before:1",
+		"condition:platform=ios:true",
+		"condition:data.level>=3:true",
+		"assignment:level=1",
+		"after:1:true:<nil>",
+		"before:2",
+		"condition:data.level>=3:false",
+		"after:2:false:<nil>",
+	}, rec.events)
+
+	for _, tt := range []struct {
+		segments string
+		err      string
+	}{
+		{segments: `{"a": [["segment", "=", "b"]], "b": [["segment", "=", "a"]]}`, err: "segment [a]: segment [b]: segment reference cycle [a -> b -> a]"},
+		{segments: `{"vip": [["golang", "=", 1]]}`, err: "segment [vip]: condition not exists variable [golang]"},
+		{segments: `{}`, err: "segment [vip] is not defined"},
+	} {
+		err = f.Refresh(context.Background(), `{"segments":`+tt.segments+`,"filters":[{"id":"1","filter":[["segment","=","vip"],["level","=",1]]}]}`)
+		assert.EqualError(t, err, tt.err)
+	}
+}
+
+func TestFilterMissing(t *testing.T) {
+	jsonStr := `
+{
+	"filters":[
+		{
+			"id":"1",
+			"priority": 1,
+			"filter": [
+				["not","=>",[["version?","vgt","3.0"]]],
+				["old","=",true]
+			]
+		},
+		{
+			"id":"2",
+			"priority": 1,
+			"filter": [
+				["or","=>",[["version?","vgt","3.0"],["data.age?=0",">=",18]]],
+				["adult","=",true]
+			]
+		},
+		{
+			"id":"3",
+			"priority": 1,
+			"filter": [
+				["platform?false","=","ios"],
+				["ios","=",true]
+			]
+		}
+	],
+	"batch":true
+}`
+
+	f, err := NewFilter(context.Background(), jsonStr, nil)
+	assert.Nil(t, err)
+
+	// 版本不存在时 not 的结果仍然未知，filter不成立
+	data, err := f.Execute(context.Background(), map[string]interface{}{"age": 20})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"age": 20, "adult": true}, data)
+
+	data, err = f.Execute(context.Background(), map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{}, data)
+
+	ctx := filterContext.WithVersion(context.Background(), "2.0")
+	data, err = f.Execute(ctx, map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"old": true}, data)
+}
+
+func TestFilterQuantifier(t *testing.T) {
+	jsonStr := `
+{
+	"filters":[
+		{
+			"id":"1",
+			"priority": 1,
+			"filter": [
+				["data.orders","anyof",[["status","=","paid"],["amount",">",100]]],
+				["vip","=",true]
+			]
+		},
+		{
+			"id":"2",
+			"priority": 1,
+			"filter": [
+				["data.orders?false","noneof",[["status","=","refund"]]],
+				["trusted","=",true]
+			]
+		}
+	],
+	"batch":true
+}`
+
+	f, err := NewFilter(context.Background(), jsonStr, nil)
+	assert.Nil(t, err)
+
+	orders := []interface{}{
+		map[string]interface{}{"status": "paid", "amount": 50},
+		map[string]interface{}{"status": "paid", "amount": 200},
+	}
+	data, err := f.Execute(context.Background(), map[string]interface{}{"orders": orders})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"orders": orders, "vip": true, "trusted": true}, data)
+
+	orders = []interface{}{
+		map[string]interface{}{"status": "refund", "amount": 200},
+	}
+	data, err = f.Execute(context.Background(), map[string]interface{}{"orders": orders})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"orders": orders}, data)
+}
+
+func TestFilterCached(t *testing.T) {
+	jsonStr := `
+{
+	"filters":[
+		{
+			"id":"1",
+			"priority": 1,
+			"filter": [
+				["or","=>",[["data.age",">=",18],["data.vip?false","=",true]],{"cached":true}],
+				["adult","=",true]
+			]
+		},
+		{
+			"id":"2",
+			"priority": 1,
+			"filter": [
+				["data.adult?false","=",true],
+				["age","=",10]
+			]
+		},
+		{
+			"id":"3",
+			"priority": 1,
+			"filter": [
+				["or","=>",[["data.age",">=",18],["data.vip?false","=",true]],{"cached":true}],
+				["still","=",true]
+			]
+		}
+	],
+	"batch":true
+}`
+
+	f, err := NewFilter(context.Background(), jsonStr, nil)
+	assert.Nil(t, err)
+
+	// 赋值之后依赖data的结果重新计算
+	data, err := f.Execute(context.Background(), map[string]interface{}{"age": 20})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"age": float64(10), "adult": true}, data)
+
+	data, err = f.Execute(context.Background(), map[string]interface{}{"age": 20, "vip": true})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"age": float64(10), "vip": true, "adult": true, "still": true}, data)
+}
+
+func TestFilterList(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "whitelist.txt")
+	assert.Nil(t, os.WriteFile(path, []byte("1001\n1002\n"), 0644))
+
+	whitelist, err := lists.Register(ctx, "filter_whitelist", lists.File(path))
+	assert.Nil(t, err)
+	defer lists.Remove("filter_whitelist")
+
+	jsonStr := `
+{
+	"filters":[
+		{
+			"id":"1",
+			"priority": 1,
+			"filter": [
+				["uid","in","@list:filter_whitelist"],
+				["white","=",true]
+			]
+		}
+	]
+}`
+
+	f, err := NewFilter(ctx, jsonStr, nil)
+	assert.Nil(t, err)
+
+	data, err := f.Execute(filterContext.WithUserID(ctx, 1001), map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"white": true}, data)
+
+	// 重新加载名单之后不需要刷新配置
+	assert.Nil(t, os.WriteFile(path, []byte("1002\n"), 0644))
+	assert.Nil(t, whitelist.Reload(ctx))
+	data, err = f.Execute(filterContext.WithUserID(ctx, 1001), map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{}, data)
+
+	_, err = NewFilter(ctx, `{"filters":[{"id":"1","filter":[["uid","in","@list:unknown"],["white","=",true]]}]}`, nil)
+	assert.NotNil(t, err)
+}
+
+func TestFilterListRegistry(t *testing.T) {
+	ctx := context.Background()
+	provider := func(values ...string) lists.Provider {
+		return lists.ProviderFunc(func(context.Context) ([]string, error) {
+			return values, nil
+		})
+	}
+
+	// 每个Filter使用各自的注册表，同名的名单互不影响
+	jsonStr := `{"batch":true,"filters":[{"id":"1","filter":[["uid","in","@list:vip"],["vip","=",true]]},{"id":"2","filter":[["data.tags","has","@list:tags"],["tagged","=",true]]}]}`
+	registries := []*lists.Registry{lists.NewRegistry(), lists.NewRegistry()}
+	for index, registry := range registries {
+		_, err := registry.Register(ctx, "vip", provider(strconv.Itoa(1001+index)))
+		assert.Nil(t, err)
+		_, err = registry.Register(ctx, "tags", provider("a", strconv.Itoa(index)))
+		assert.Nil(t, err)
+	}
+
+	first, err := NewFilter(ctx, jsonStr, nil, WithLists(registries[0]))
+	assert.Nil(t, err)
+	second, err := NewFilter(ctx, jsonStr, nil, WithLists(registries[1]))
+	assert.Nil(t, err)
+
+	c := filterContext.WithUserID(ctx, 1001)
+	data, err := first.Execute(c, map[string]interface{}{"tags": []interface{}{"a", "0", "b"}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"tags": []interface{}{"a", "0", "b"}, "vip": true, "tagged": true}, data)
+
+	data, err = second.Execute(c, map[string]interface{}{"tags": []interface{}{"a", "0", "b"}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"tags": []interface{}{"a", "0", "b"}}, data)
+
+	// 默认的注册表中没有这些名单
+	_, err = NewFilter(ctx, jsonStr, nil)
+	assert.EqualError(t, err, "condition operand not exists list [vip]")
+}
+
+func BenchmarkFilterExecute(b *testing.B) {
+	cnf := NewConfig(true,
+		When(Var("platform").Eq("ios"), Var("channel").In("store", "ads")).Then(Set("a", 1)),
+		When(Var("platform").Eq("ios"), Var("data.age").Gt(18)).Then(Set("b", 1)),
+		When(Var("version").VersionGte("1.0"), Var("data.age").Lt(60)).Then(Set("c", 1)),
+	)
+	f, err := NewFilterFromConfig(context.Background(), cnf, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	ctx := filterContext.WithPlatform(context.Background(), "ios")
+	ctx = filterContext.WithChannel(ctx, "store")
+	ctx = filterContext.WithVersion(ctx, "1.2")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = f.Execute(ctx, map[string]interface{}{"age": 20})
+	}
+}
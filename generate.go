@@ -0,0 +1,27 @@
+package filter
+
+import (
+	"context"
+
+	"github.com/airunny/filter/codegen"
+)
+
+// Generate 生成跟配置逻辑一致的Go代码，参考 codegen.Generate
+func (s *Config) Generate(ctx context.Context, opts codegen.Options) ([]byte, error) {
+	batch, err := buildBatchFilter(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := make([]codegen.Filter, 0, len(batch.filters))
+	for _, filter := range batch.filters {
+		filters = append(filters, codegen.Filter{
+			Id:        filter.id,
+			Weight:    filter.weight,
+			Priority:  filter.priority,
+			Condition: filter.condition,
+			Executor:  filter.executor,
+		})
+	}
+	return codegen.Generate(filters, s.Batch, opts)
+}
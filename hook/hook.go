@@ -0,0 +1,159 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// ErrSkipAssignment BeforeAssignment 返回该错误时跳过这次赋值，filter仍然成立，其它错误会中止执行并返回
+var ErrSkipAssignment = errors.New("hook: skip assignment")
+
+// Hook 在filter执行过程中的回调，可用于链路追踪、调试日志以及审计
+type Hook interface {
+	// BeforeFilter 在单个filter执行之前调用，返回的ctx会传递给后续的条件、赋值以及AfterFilter
+	BeforeFilter(ctx context.Context, id string, data interface{}) context.Context
+	// AfterFilter 在单个filter执行之后调用，ok表示条件是否成立并且赋值成功
+	AfterFilter(ctx context.Context, id string, data interface{}, ok bool, err error)
+	// OnCondition 在每个条件项计算之后调用
+	OnCondition(ctx context.Context, variable, operation string, value interface{}, ok bool, err error)
+	// BeforeAssignment 在每个执行项执行之前调用，old为赋值之前的值（不存在时为nil），返回错误时不执行赋值，参见 ErrSkipAssignment
+	BeforeAssignment(ctx context.Context, key, assignment string, old, value interface{}) error
+	// OnAssignment 在每个执行项执行之后调用，被 BeforeAssignment 拦截时err为拦截的错误
+	OnAssignment(ctx context.Context, key, assignment string, old, value interface{}, err error)
+}
+
+// Base 所有回调都为空实现，方便只关心部分回调的业务方嵌入使用
+type Base struct{}
+
+func (Base) BeforeFilter(ctx context.Context, _ string, _ interface{}) context.Context { return ctx }
+func (Base) AfterFilter(context.Context, string, interface{}, bool, error)             {}
+func (Base) OnCondition(context.Context, string, string, interface{}, bool, error)     {}
+func (Base) BeforeAssignment(context.Context, string, string, interface{}, interface{}) error {
+	return nil
+}
+func (Base) OnAssignment(context.Context, string, string, interface{}, interface{}, error) {}
+
+// Hooks 按顺序依次调用多个Hook
+type Hooks []Hook
+
+func (s Hooks) BeforeFilter(ctx context.Context, id string, data interface{}) context.Context {
+	for _, h := range s {
+		ctx = h.BeforeFilter(ctx, id, data)
+	}
+	return ctx
+}
+
+func (s Hooks) AfterFilter(ctx context.Context, id string, data interface{}, ok bool, err error) {
+	for i := len(s) - 1; i >= 0; i-- {
+		s[i].AfterFilter(ctx, id, data, ok, err)
+	}
+}
+
+func (s Hooks) OnCondition(ctx context.Context, variable, operation string, value interface{}, ok bool, err error) {
+	for _, h := range s {
+		h.OnCondition(ctx, variable, operation, value, ok, err)
+	}
+}
+
+// BeforeAssignment 返回第一个错误，之后的Hook不再调用
+func (s Hooks) BeforeAssignment(ctx context.Context, key, assignment string, old, value interface{}) error {
+	for _, h := range s {
+		if err := h.BeforeAssignment(ctx, key, assignment, old, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s Hooks) OnAssignment(ctx context.Context, key, assignment string, old, value interface{}, err error) {
+	for _, h := range s {
+		h.OnAssignment(ctx, key, assignment, old, value, err)
+	}
+}
+
+type hooksKey struct{}
+
+// NewContext 将hooks注入到ctx中，已经存在的hooks会保留并先于新的hooks调用
+func NewContext(ctx context.Context, hooks ...Hook) context.Context {
+	if len(hooks) == 0 {
+		return ctx
+	}
+
+	exists, _ := ctx.Value(hooksKey{}).(Hooks)
+	merged := make(Hooks, 0, len(exists)+len(hooks))
+	merged = append(merged, exists...)
+	for _, h := range hooks {
+		if h != nil {
+			merged = append(merged, h)
+		}
+	}
+	return context.WithValue(ctx, hooksKey{}, merged)
+}
+
+func FromContext(ctx context.Context) (Hook, bool) {
+	hooks, ok := ctx.Value(hooksKey{}).(Hooks)
+	if !ok || len(hooks) == 0 {
+		return nil, false
+	}
+
+	if len(hooks) == 1 {
+		return hooks[0], true
+	}
+	return hooks, true
+}
+
+// Logger 使用slog以Debug级别输出执行过程
+type Logger struct {
+	logger *slog.Logger
+}
+
+func NewLogger(logger *slog.Logger) *Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Logger{logger: logger}
+}
+
+func (s *Logger) BeforeFilter(ctx context.Context, id string, _ interface{}) context.Context {
+	s.logger.DebugContext(ctx, "filter start", slog.String("filter", id))
+	return ctx
+}
+
+func (s *Logger) AfterFilter(ctx context.Context, id string, _ interface{}, ok bool, err error) {
+	attrs := []slog.Attr{slog.String("filter", id), slog.Bool("ok", ok)}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "filter end", attrs...)
+}
+
+func (s *Logger) OnCondition(ctx context.Context, variable, operation string, value interface{}, ok bool, err error) {
+	attrs := []slog.Attr{
+		slog.String("variable", variable),
+		slog.String("operation", operation),
+		slog.Any("value", value),
+		slog.Bool("ok", ok),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "condition", attrs...)
+}
+
+func (s *Logger) BeforeAssignment(context.Context, string, string, interface{}, interface{}) error {
+	return nil
+}
+
+func (s *Logger) OnAssignment(ctx context.Context, key, assignment string, old, value interface{}, err error) {
+	attrs := []slog.Attr{
+		slog.String("key", key),
+		slog.String("assignment", assignment),
+		slog.Any("old", old),
+		slog.Any("value", value),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "assignment", attrs...)
+}
@@ -0,0 +1,103 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type spanKey struct{}
+
+type recordHook struct {
+	Base
+	name   string
+	events *[]string
+}
+
+func (s *recordHook) BeforeFilter(ctx context.Context, id string, _ interface{}) context.Context {
+	*s.events = append(*s.events, s.name+":before:"+id)
+	return context.WithValue(ctx, spanKey{}, s.name)
+}
+
+func (s *recordHook) AfterFilter(ctx context.Context, id string, _ interface{}, _ bool, _ error) {
+	span, _ := ctx.Value(spanKey{}).(string)
+	*s.events = append(*s.events, s.name+":after:"+id+":"+span)
+}
+
+func (s *recordHook) OnCondition(_ context.Context, variable, operation string, _ interface{}, _ bool, _ error) {
+	*s.events = append(*s.events, s.name+":condition:"+variable+operation)
+}
+
+func (s *recordHook) BeforeAssignment(_ context.Context, key, assignment string, _, _ interface{}) error {
+	*s.events = append(*s.events, s.name+":before:"+key+assignment)
+	if key == "blocked" && s.name == "first" {
+		return ErrSkipAssignment
+	}
+	return nil
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	_, ok := FromContext(ctx)
+	assert.False(t, ok)
+	assert.Equal(t, ctx, NewContext(ctx))
+
+	var events []string
+	first := &recordHook{name: "first", events: &events}
+	second := &recordHook{name: "second", events: &events}
+
+	ctx = NewContext(ctx, first)
+	h, ok := FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, first, h)
+
+	ctx = NewContext(ctx, second, nil)
+	h, ok = FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, Hooks{first, second}, h)
+
+	spanCtx := h.BeforeFilter(ctx, "1", nil)
+	h.OnCondition(spanCtx, "uid", "=", 1, true, nil)
+	assert.Nil(t, h.BeforeAssignment(spanCtx, "name", "=", nil, "golang"))
+	h.OnAssignment(spanCtx, "name", "=", nil, "golang", nil)
+	// 第一个Hook拦截之后不再调用之后的Hook
+	assert.Equal(t, ErrSkipAssignment, h.BeforeAssignment(spanCtx, "blocked", "=", nil, "golang"))
+	h.AfterFilter(spanCtx, "1", nil, true, nil)
+	assert.Equal(t, []string{
+		"first:before:1",
+		"second:before:1",
+		"first:condition:uid=",
+		"second:condition:uid=",
+		"first:before:name=",
+		"second:before:name=",
+		"first:before:blocked=",
+		"second:after:1:second",
+		"first:after:1:second",
+	}, events)
+}
+
+func TestLogger(t *testing.T) {
+	var (
+		buf    bytes.Buffer
+		ctx    = context.Background()
+		logger = NewLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	)
+
+	ctx = logger.BeforeFilter(ctx, "1", nil)
+	logger.OnCondition(ctx, "platform", "=", "ios", true, nil)
+	assert.Nil(t, logger.BeforeAssignment(ctx, "name", "=", "go", "golang"))
+	logger.OnAssignment(ctx, "name", "=", "go", "golang", errors.New("set failed"))
+	logger.AfterFilter(ctx, "1", nil, false, errors.New("set failed"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 4, len(lines))
+	assert.Contains(t, lines[0], `msg="filter start" filter=1`)
+	assert.Contains(t, lines[1], `msg=condition variable=platform operation="=" value=ios ok=true`)
+	assert.Contains(t, lines[2], `msg=assignment key=name assignment="=" old=go value=golang error="set failed"`)
+	assert.Contains(t, lines[3], `msg="filter end" filter=1 ok=false error="set failed"`)
+}
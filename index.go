@@ -0,0 +1,259 @@
+package filter
+
+import (
+	"context"
+	"net"
+	"sort"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/condition"
+	"github.com/airunny/filter/types"
+	"github.com/airunny/filter/utils"
+	"github.com/airunny/filter/variables"
+)
+
+type indexKind int
+
+const (
+	indexEqual indexKind = iota
+	indexIn
+	indexIPRange
+)
+
+// filterIndex 根据filter顶层and条件中的第一个条件建立的索引，执行时只需要运行可能命中的filter。
+// 第一个条件不成立时and条件直接短路，并且只使用同一次请求中取值不变的变量（batch 模式中前面filter的赋值不影响），
+// 所以跳过这些filter跟逐个执行的结果完全一致
+type filterIndex struct {
+	groups    []*indexGroup
+	unindexed []int // 无法建立索引的filter，每次都需要执行
+}
+
+// indexGroup 同一个变量使用同一种操作的filter
+type indexGroup struct {
+	kind     indexKind
+	variable variables.Variable
+	all      []int
+	values   map[string][]int
+	segments ipSegments
+}
+
+// indexPredicate 返回filter可以用于索引的条件，只使用顶层and条件中的第一个条件
+func indexPredicate(filter *singleFilter) (*condition.BaseCondition, indexKind, bool) {
+	group, ok := filter.condition.(*condition.Group)
+	if !ok || group.Logic() != condition.LogicAnd || len(group.Conditions()) == 0 {
+		return nil, 0, false
+	}
+
+	// 比较值引用其它变量时每次执行的比较值都不同，不能用于索引
+	base, ok := group.Conditions()[0].(*condition.BaseCondition)
+	if !ok || base.Operand() != nil {
+		return nil, 0, false
+	}
+
+	// 跟 condition 中的缓存作用域一致，data. 等变量的取值在赋值之后可能改变，不能用于索引
+	variable := base.Variable()
+	if !variable.Cacheable() || variables.Scope(variable) != cache.ScopeRequest {
+		return nil, 0, false
+	}
+
+	switch base.Operation().Name() {
+	case "=", "eq":
+		if types.GetFilterType(base.Value()) == types.STRING {
+			return base, indexEqual, true
+		}
+	case "in":
+		set, ok := base.Value().(*utils.ValueSet)
+		if !ok {
+			return nil, 0, false
+		}
+
+		for _, element := range set.Values() {
+			if types.GetFilterType(element) != types.STRING {
+				return nil, 0, false
+			}
+		}
+		return base, indexIn, true
+	case "iir":
+		ranges, ok := base.Value().([]utils.IPRange)
+		if !ok {
+			return nil, 0, false
+		}
+
+		// 索引只支持ipv4的区间
+		for _, r := range ranges {
+			if !r.IsIPv4() {
+				return nil, 0, false
+			}
+		}
+		return base, indexIPRange, true
+	}
+	return nil, 0, false
+}
+
+// minIndexFilters filter少于该数量时逐个执行比查找索引更快，不建立索引
+const minIndexFilters = 4
+
+// buildIndex filters 为排序之后的filter，位置记录在 singleFilter.index 中
+func buildIndex(filters []*singleFilter) *filterIndex {
+	var (
+		index  = &filterIndex{}
+		groups = make(map[indexKind]map[string]*indexGroup)
+		ranges = make(map[*indexGroup][]ipRange)
+	)
+
+	for position, filter := range filters {
+		filter.index = position
+		base, kind, ok := indexPredicate(filter)
+		if !ok {
+			index.unindexed = append(index.unindexed, position)
+			continue
+		}
+
+		if groups[kind] == nil {
+			groups[kind] = make(map[string]*indexGroup)
+		}
+
+		name := base.Variable().Name()
+		group, ok := groups[kind][name]
+		if !ok {
+			group = &indexGroup{
+				kind:     kind,
+				variable: base.Variable(),
+				values:   make(map[string][]int),
+			}
+			groups[kind][name] = group
+			index.groups = append(index.groups, group)
+		}
+		group.all = append(group.all, position)
+
+		switch kind {
+		case indexEqual:
+			group.add(types.GetString(base.Value()), position)
+		case indexIn:
+			for _, element := range base.Value().(*utils.ValueSet).Values() {
+				group.add(types.GetString(element), position)
+			}
+		case indexIPRange:
+			for _, r := range base.Value().([]utils.IPRange) {
+				ranges[group] = append(ranges[group], ipRange{start: r.Start(), end: r.End(), position: position})
+			}
+		}
+	}
+
+	for group, rs := range ranges {
+		group.segments = buildIPSegments(rs)
+	}
+	return index
+}
+
+func (s *indexGroup) add(value string, position int) {
+	positions := s.values[value]
+	if len(positions) > 0 && positions[len(positions)-1] == position {
+		return
+	}
+	s.values[value] = append(positions, position)
+}
+
+// lookup 返回第一个条件可能成立的filter，取值跟操作中的比较方式保持一致，无法判断时返回全部
+func (s *indexGroup) lookup(ctx context.Context, data interface{}, cache *cache.Cache) []int {
+	value, err := variables.GetValue(ctx, s.variable, data, cache)
+	if err != nil {
+		// 交给filter执行时返回同样的错误
+		return s.all
+	}
+
+	switch s.kind {
+	case indexEqual:
+		// 非字符串会按照数字比较
+		if types.GetFilterType(value) != types.STRING {
+			return s.all
+		}
+		return s.values[types.GetString(value)]
+	case indexIn:
+		if types.GetFilterType(value) != types.STRING {
+			return s.all
+		}
+
+		// in 要求变量的每一个元素都在集合中，只需要查找第一个元素
+		elements := utils.ParseTargetArrayValue(value)
+		if len(elements) == 0 || types.GetFilterType(elements[0]) != types.STRING {
+			return s.all
+		}
+		return s.values[types.GetString(elements[0])]
+	case indexIPRange:
+		ip, ok := value.(string)
+		if !ok {
+			return s.all
+		}
+
+		address := net.ParseIP(ip)
+		if address == nil || address.To4() == nil {
+			return s.all
+		}
+		return s.segments.lookup(utils.ToInt(address))
+	}
+	return s.all
+}
+
+// candidates 返回按照位置排序之后可能命中的filter
+func (s *filterIndex) candidates(ctx context.Context, data interface{}, cache *cache.Cache) []int {
+	candidates := make([]int, 0, len(s.unindexed))
+	candidates = append(candidates, s.unindexed...)
+	for _, group := range s.groups {
+		candidates = append(candidates, group.lookup(ctx, data, cache)...)
+	}
+	sort.Ints(candidates)
+	return candidates
+}
+
+type ipRange struct {
+	start, end int
+	position   int
+}
+
+// ipSegments 将所有ip段拆分为互不重叠的区间，每个区间记录覆盖它的filter
+type ipSegments struct {
+	starts    []int
+	positions [][]int
+}
+
+func buildIPSegments(ranges []ipRange) ipSegments {
+	boundaries := make([]int, 0, len(ranges)*2)
+	for _, r := range ranges {
+		boundaries = append(boundaries, r.start, r.end+1)
+	}
+	sort.Ints(boundaries)
+
+	starts := boundaries[:0]
+	for _, boundary := range boundaries {
+		if len(starts) == 0 || boundary != starts[len(starts)-1] {
+			starts = append(starts, boundary)
+		}
+	}
+
+	segments := ipSegments{
+		starts:    starts,
+		positions: make([][]int, len(starts)),
+	}
+
+	// ranges 按照filter的位置顺序添加，每个区间中的filter天然有序
+	for _, r := range ranges {
+		first := sort.SearchInts(starts, r.start)
+		for i := first; i < len(starts) && starts[i] <= r.end; i++ {
+			positions := segments.positions[i]
+			if len(positions) > 0 && positions[len(positions)-1] == r.position {
+				continue
+			}
+			segments.positions[i] = append(positions, r.position)
+		}
+	}
+	return segments
+}
+
+func (s ipSegments) lookup(ip int) []int {
+	index := sort.SearchInts(s.starts, ip+1) - 1
+	if index < 0 {
+		return nil
+	}
+	return s.positions[index]
+}
@@ -0,0 +1,293 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/airunny/filter/cache"
+	filterContext "github.com/airunny/filter/context"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	indexPlatforms = []string{"ios", "android", "web", "mini"}
+	indexChannels  = []string{"store", "ads", "share", "push", "search"}
+	indexIPs       = []string{"10.0.0.0/8", "10.1.0.0/16", "192.168.1.0/24", "172.16.0.0/12"}
+)
+
+// randomConfig 随机生成可以建立索引以及无法建立索引的filter
+func randomConfig(r *rand.Rand, count int, batch bool) *Config {
+	cnf := &Config{Batch: batch}
+	for i := 0; i < count; i++ {
+		var first []interface{}
+		switch r.Intn(6) {
+		case 0:
+			first = []interface{}{"platform", "=", indexPlatforms[r.Intn(len(indexPlatforms))]}
+		case 1:
+			first = []interface{}{"channel", "in", []interface{}{indexChannels[r.Intn(len(indexChannels))], indexChannels[r.Intn(len(indexChannels))]}}
+		case 2:
+			first = []interface{}{"ip", "iir", []interface{}{indexIPs[r.Intn(len(indexIPs))], indexIPs[r.Intn(len(indexIPs))]}}
+		case 3:
+			first = []interface{}{"data.level", "=", fmt.Sprint(r.Intn(3))}
+		case 4:
+			first = []interface{}{"uid", ">", float64(r.Intn(100))}
+		default:
+			first = []interface{}{"or", "=>", []interface{}{
+				[]interface{}{"platform", "=", indexPlatforms[r.Intn(len(indexPlatforms))]},
+				[]interface{}{"channel", "=", indexChannels[r.Intn(len(indexChannels))]},
+			}}
+		}
+
+		cnf.Filters = append(cnf.Filters, FilterConfig{
+			Id:       fmt.Sprint(i),
+			Priority: int64(r.Intn(5)),
+			Filter: []interface{}{
+				first,
+				[]interface{}{"uid", "<", float64(r.Intn(100) + 20)},
+				[]interface{}{"hit", "=", fmt.Sprint(i)},
+			},
+		})
+	}
+	return cnf
+}
+
+func randomRequest(r *rand.Rand) (context.Context, map[string]interface{}) {
+	ctx := context.Background()
+	ctx = filterContext.WithPlatform(ctx, indexPlatforms[r.Intn(len(indexPlatforms))])
+	ctx = filterContext.WithChannel(ctx, indexChannels[r.Intn(len(indexChannels))])
+	ctx = filterContext.WithIP(ctx, fmt.Sprintf("%d.%d.%d.%d", []int{10, 192, 172, 8}[r.Intn(4)], r.Intn(256), r.Intn(3), r.Intn(256)))
+	ctx = filterContext.WithUserID(ctx, float64(r.Intn(100)))
+
+	data := make(map[string]interface{})
+	if r.Intn(4) != 0 {
+		data["level"] = fmt.Sprint(r.Intn(3))
+	}
+	return ctx, data
+}
+
+func TestIndexEquivalence(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, batch := range []bool{false, true} {
+		cnf := randomConfig(r, 300, batch)
+
+		indexed, err := buildBatchFilter(context.Background(), cnf)
+		assert.Nil(t, err)
+		assert.NotNil(t, indexed.index)
+
+		linear, err := buildBatchFilter(context.Background(), cnf)
+		assert.Nil(t, err)
+		linear.index = nil
+
+		for i := 0; i < 500; i++ {
+			ctx, data := randomRequest(r)
+			wantData, gotData := copyData(data), copyData(data)
+
+			wantNumber, wantIds, wantErr := linear.Run(ctx, wantData, cache.NewCache())
+			gotNumber, gotIds, gotErr := indexed.Run(ctx, gotData, cache.NewCache())
+			assert.Equal(t, wantErr, gotErr)
+			assert.Equal(t, wantNumber, gotNumber)
+			assert.Equal(t, wantIds, gotIds)
+			assert.Equal(t, wantData, gotData)
+		}
+	}
+}
+
+func copyData(data map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		c[key] = value
+	}
+	return c
+}
+
+func TestIndexLookup(t *testing.T) {
+	cnf := NewConfig(true,
+		When(Var("platform").Eq("ios")).Then(Set("a", 1)).Id("eq"),
+		When(Var("platform").In("ios", "android")).Then(Set("a", 1)).Id("in"),
+		When(Var("ip").InIPRange("10.0.0.0/8", "10.1.0.0/16")).Then(Set("a", 1)).Id("iir"),
+		When(Var("ip").InIPRange("10.1.2.0/24")).Then(Set("a", 1)).Id("iir2"),
+		When(Var("uid").Gt(1)).Then(Set("a", 1)).Id("gt"),
+	)
+
+	batch, err := buildBatchFilter(context.Background(), cnf)
+	assert.Nil(t, err)
+
+	ids := func(ctx context.Context) []string {
+		var result []string
+		for _, filter := range batch.candidates(ctx, batch.filters, nil, cache.NewCache()) {
+			result = append(result, filter.id)
+		}
+		return result
+	}
+
+	// 取值失败时交给filter执行返回错误
+	ctx := context.Background()
+	assert.Equal(t, []string{"eq", "in", "iir", "iir2", "gt"}, ids(ctx))
+
+	ctx = filterContext.WithIP(ctx, "8.8.8.8")
+	assert.Equal(t, []string{"eq", "in", "gt"}, ids(filterContext.WithPlatform(ctx, "ios")))
+	assert.Equal(t, []string{"in", "gt"}, ids(filterContext.WithPlatform(ctx, "android,ios")))
+	assert.Equal(t, []string{"gt"}, ids(filterContext.WithPlatform(ctx, "web")))
+	// 数字按照数字比较，无法使用索引
+	assert.Equal(t, []string{"eq", "in", "gt"}, ids(filterContext.WithPlatform(ctx, 1)))
+	assert.Equal(t, []string{"iir", "gt"}, ids(filterContext.WithIP(filterContext.WithPlatform(ctx, "web"), "10.2.0.1")))
+	assert.Equal(t, []string{"iir", "iir2", "gt"}, ids(filterContext.WithIP(filterContext.WithPlatform(ctx, "web"), "10.1.2.3")))
+
+	// 有权重时按照打乱之后的顺序返回
+	batch.filters[0], batch.filters[4] = batch.filters[4], batch.filters[0]
+	batch.weight = 1
+	assert.Equal(t, []string{"gt", "in"}, ids(filterContext.WithPlatform(ctx, "android")))
+}
+
+func TestIndexOptions(t *testing.T) {
+	ctx := filterContext.WithPlatform(context.Background(), "ios")
+	cnf := NewConfig(true,
+		When(Var("platform").Eq("android")).Then(Set("a", 1)).Id("1"),
+		When(Var("platform").Eq("web")).Then(Set("b", 1)).Id("2"),
+		When(Var("platform").Eq("ios")).Then(Set("c", 1)).Id("3"),
+		When(Var("platform").In("mini", "web")).Then(Set("d", 1)).Id("4"),
+	)
+
+	// 注册了hook时被索引跳过的filter同样触发hook
+	rec := &recordHook{}
+	f, err := NewFilterFromConfig(ctx, cnf, nil, WithHooks(rec))
+	assert.Nil(t, err)
+	assert.NotNil(t, f.batch.Load().(*batchFilter).index)
+	data, err := f.Execute(ctx, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"c": 1}, data)
+	assert.Equal(t, []string{
+		"before:1", "condition:platform=android:false", "after:1:false:<nil>",
+		"before:2", "condition:platform=web:false", "after:2:false:<nil>",
+		"before:3", "condition:platform=ios:true", "assignment:c=1", "after:3:true:<nil>",
+		"before:4", "condition:platformin[mini web]:false", "after:4:false:<nil>",
+	}, rec.events)
+
+	f, err = NewFilterFromConfig(ctx, cnf, nil, WithoutIndex())
+	assert.Nil(t, err)
+	assert.Nil(t, f.batch.Load().(*batchFilter).index)
+	data, err = f.Execute(ctx, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"c": 1}, data)
+
+	// filter很少时不建立索引
+	cnf.Filters = cnf.Filters[:minIndexFilters-1]
+	f, err = NewFilterFromConfig(ctx, cnf, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, f.batch.Load().(*batchFilter).index)
+}
+
+// benchmarkConfig 模拟大量活动分别投放到不同渠道、版本以及ip段
+func benchmarkConfig(r *rand.Rand, count int) *Config {
+	cnf := &Config{Batch: true}
+	for i := 0; i < count; i++ {
+		var first []interface{}
+		switch r.Intn(4) {
+		case 0, 1:
+			first = []interface{}{"channel", "=", fmt.Sprintf("channel-%d", r.Intn(count))}
+		case 2:
+			first = []interface{}{"version", "in", []interface{}{fmt.Sprintf("1.%d", r.Intn(count)), fmt.Sprintf("1.%d", r.Intn(count))}}
+		default:
+			first = []interface{}{"ip", "iir", fmt.Sprintf("10.%d.%d.0/24", r.Intn(256), r.Intn(256))}
+		}
+
+		cnf.Filters = append(cnf.Filters, FilterConfig{
+			Id:       fmt.Sprint(i),
+			Priority: int64(r.Intn(5)),
+			Filter: []interface{}{
+				first,
+				[]interface{}{"uid", "<", float64(r.Intn(100))},
+				[]interface{}{"hit", "=", fmt.Sprint(i)},
+			},
+		})
+	}
+	return cnf
+}
+
+func benchmarkBatchFilter(b *testing.B, count int, indexed bool) {
+	r := rand.New(rand.NewSource(1))
+	batch, err := buildBatchFilter(context.Background(), benchmarkConfig(r, count))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	if !indexed {
+		batch.index = nil
+	}
+
+	ctx := context.Background()
+	ctx = filterContext.WithChannel(ctx, "channel-1")
+	ctx = filterContext.WithVersion(ctx, "1.1")
+	ctx = filterContext.WithIP(ctx, "10.1.1.1")
+	ctx = filterContext.WithUserID(ctx, 50)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = batch.Run(ctx, map[string]interface{}{}, cache.NewCache())
+	}
+}
+
+func BenchmarkBatchFilter(b *testing.B) {
+	for _, count := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("linear-%d", count), func(b *testing.B) {
+			benchmarkBatchFilter(b, count, false)
+		})
+		b.Run(fmt.Sprintf("indexed-%d", count), func(b *testing.B) {
+			benchmarkBatchFilter(b, count, true)
+		})
+	}
+}
+
+// batch 模式中前面filter的赋值会改变 data. 的取值，不能用于索引
+func TestIndexDataAssignment(t *testing.T) {
+	ctx := filterContext.WithPlatform(context.Background(), "ios")
+	cnf := NewConfig(true,
+		When(Var("platform").Eq("ios")).Then(Set("city", "bj")).Id("1"),
+		When(Var("data.city").Eq("bj")).Then(Set("hit", "yes")).Id("2"),
+		When(Var("platform").Eq("android")).Then(Set("a", 1)).Id("3"),
+		When(Var("platform").Eq("web")).Then(Set("b", 1)).Id("4"),
+	)
+
+	expected := map[string]interface{}{"city": "bj", "hit": "yes", "x": 1}
+	for _, opts := range [][]Option{nil, {WithoutIndex()}} {
+		f, err := NewFilterFromConfig(ctx, cnf, nil, opts...)
+		assert.Nil(t, err)
+		data, err := f.Execute(ctx, map[string]interface{}{"x": 1})
+		assert.Nil(t, err)
+		assert.Equal(t, expected, data)
+	}
+
+	batch, err := buildBatchFilter(ctx, cnf)
+	assert.Nil(t, err)
+	assert.NotNil(t, batch.index)
+	assert.Equal(t, []int{1}, batch.index.unindexed)
+}
+
+// ipv6 的地址以及区间不使用索引
+func TestIndexIPv6(t *testing.T) {
+	cnf := NewConfig(true,
+		When(Var("ip").InIPRange("10.0.0.0/8")).Then(Set("a", 1)).Id("v4"),
+		When(Var("ip").InIPRange("2001:db8::/32")).Then(Set("a", 1)).Id("v6"),
+		When(Var("platform").Eq("ios")).Then(Set("a", 1)).Id("eq"),
+		When(Var("platform").Eq("web")).Then(Set("a", 1)).Id("eq2"),
+	)
+
+	batch, err := buildBatchFilter(context.Background(), cnf)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1}, batch.index.unindexed)
+
+	ids := func(ctx context.Context) []string {
+		var result []string
+		for _, filter := range batch.candidates(ctx, batch.filters, nil, cache.NewCache()) {
+			result = append(result, filter.id)
+		}
+		return result
+	}
+
+	ctx := filterContext.WithPlatform(context.Background(), "android")
+	assert.Equal(t, []string{"v6"}, ids(filterContext.WithIP(ctx, "8.8.8.8")))
+	assert.Equal(t, []string{"v4", "v6"}, ids(filterContext.WithIP(ctx, "10.1.1.1")))
+	assert.Equal(t, []string{"v4", "v6"}, ids(filterContext.WithIP(ctx, "2001:db8::1")))
+}
@@ -0,0 +1,263 @@
+package lists
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/airunny/filter/utils"
+)
+
+// Prefix 比较值以该前缀开头时引用已注册的名单，例如 ["uid", "in", "@list:whitelist_2026"]
+const Prefix = "@list:"
+
+// Provider 加载名单的全部元素，注册以及每次 Reload 时调用
+type Provider interface {
+	Load(ctx context.Context) ([]string, error)
+}
+
+type ProviderFunc func(ctx context.Context) ([]string, error)
+
+func (f ProviderFunc) Load(ctx context.Context) ([]string, error) { return f(ctx) }
+
+// File 从本地文件加载名单，每行一个元素，忽略空行以及#开头的注释
+func File(path string) Provider {
+	return ProviderFunc(func(context.Context) ([]string, error) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var values []string
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			values = append(values, line)
+		}
+		return values, scanner.Err()
+	})
+}
+
+type snapshot struct {
+	set      *utils.ValueSet
+	version  string
+	loadedAt time.Time
+}
+
+// List 已加载的名单，元素保存在哈希集合中，Reload 时整体替换，正在执行的条件不受影响
+type List struct {
+	name     string
+	provider Provider
+	opts     options
+	current  atomic.Pointer[snapshot]
+	stop     chan struct{}
+	once     sync.Once
+}
+
+func newList(name string, provider Provider, opts options) *List {
+	return &List{
+		name:     name,
+		provider: provider,
+		opts:     opts,
+		stop:     make(chan struct{}),
+	}
+}
+
+func (s *List) Name() string { return s.name }
+
+// Version 当前元素的哈希，元素不变时版本不变
+func (s *List) Version() string { return s.current.Load().version }
+
+// LoadedAt 当前元素的加载时间
+func (s *List) LoadedAt() time.Time { return s.current.Load().loadedAt }
+
+func (s *List) Len() int { return s.current.Load().set.Len() }
+
+// Contains 跟 in 操作逐个比较的结果一致，"1" 跟 1 相等
+func (s *List) Contains(value interface{}) bool {
+	return s.current.Load().set.Contains(value)
+}
+
+// Values 当前的全部元素，用于 has 等需要遍历比较值的操作
+func (s *List) Values() []interface{} {
+	return s.current.Load().set.Values()
+}
+
+// Reload 重新加载名单，失败时保留之前的元素
+func (s *List) Reload(ctx context.Context) error {
+	values, err := s.provider.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("list [%s] load failed: %w", s.name, err)
+	}
+
+	hash := sha1.New()
+	elements := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		hash.Write([]byte(value))
+		hash.Write([]byte{'\n'})
+		elements = append(elements, value)
+	}
+
+	s.current.Store(&snapshot{
+		set:      utils.NewValueSet(elements),
+		version:  hex.EncodeToString(hash.Sum(nil))[:8],
+		loadedAt: time.Now(),
+	})
+	return nil
+}
+
+// String 名单以及版本，例如 @list:whitelist_2026#1a2b3c4d，用于 hook 等输出执行过程
+func (s *List) String() string {
+	return Prefix + s.name + "#" + s.Version()
+}
+
+func (s *List) LogValue() slog.Value { return slog.StringValue(s.String()) }
+
+func (s *List) watch() {
+	ticker := time.NewTicker(s.opts.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.Reload(context.Background()); err != nil && s.opts.onError != nil {
+				s.opts.onError(s, err)
+			}
+		}
+	}
+}
+
+// Close 停止定时重新加载，已经引用名单的条件仍然可以使用最后一次加载的元素
+func (s *List) Close() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+type options struct {
+	interval time.Duration
+	onError  func(list *List, err error)
+}
+
+type Option func(o *options)
+
+// WithReload 每隔interval重新加载一次名单
+func WithReload(interval time.Duration) Option {
+	return func(o *options) {
+		o.interval = interval
+	}
+}
+
+// WithErrorHandler 定时重新加载失败时调用，名单保留之前的元素
+func WithErrorHandler(fn func(list *List, err error)) Option {
+	return func(o *options) {
+		o.onError = fn
+	}
+}
+
+var defaultRegistry = NewRegistry()
+
+type registryKey struct{}
+
+// Registry 名单的注册表，不同的过滤器可以使用各自的注册表，同名的名单互不影响，参见 NewContext
+type Registry struct {
+	lists map[string]*List
+	sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		lists: make(map[string]*List),
+	}
+}
+
+// NewContext 构建条件时从 registry 中查找 @list: 引用的名单，没有设置时使用通过 Register 注册的名单
+func NewContext(ctx context.Context, registry *Registry) context.Context {
+	return context.WithValue(ctx, registryKey{}, registry)
+}
+
+// Lookup 从ctx中的注册表查找名单，参见 NewContext
+func Lookup(ctx context.Context, name string) (*List, bool) {
+	registry, ok := ctx.Value(registryKey{}).(*Registry)
+	if !ok || registry == nil {
+		registry = defaultRegistry
+	}
+	return registry.Get(name)
+}
+
+// Register 加载并注册名单，加载失败或者名称已存在时返回错误
+func (s *Registry) Register(ctx context.Context, name string, provider Provider, opts ...Option) (*List, error) {
+	if name == "" {
+		return nil, fmt.Errorf("list name is empty")
+	}
+	if provider == nil {
+		return nil, fmt.Errorf("list [%s] provider is nil", name)
+	}
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	list := newList(name, provider, o)
+	if err := list.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.lists[name]; ok {
+		return nil, fmt.Errorf("list [%s] already exists", name)
+	}
+	s.lists[name] = list
+
+	if o.interval > 0 {
+		go list.watch()
+	}
+	return list, nil
+}
+
+func (s *Registry) Get(name string) (*List, bool) {
+	s.RLock()
+	defer s.RUnlock()
+	list, ok := s.lists[name]
+	return list, ok
+}
+
+// Remove 移除名单并停止定时重新加载，已经构建的条件仍然引用之前的名单
+func (s *Registry) Remove(name string) {
+	s.Lock()
+	list, ok := s.lists[name]
+	delete(s.lists, name)
+	s.Unlock()
+
+	if ok {
+		list.Close()
+	}
+}
+
+// Register 在默认的注册表中加载并注册名单，参见 Registry.Register
+func Register(ctx context.Context, name string, provider Provider, opts ...Option) (*List, error) {
+	return defaultRegistry.Register(ctx, name, provider, opts...)
+}
+
+func Get(name string) (*List, bool) {
+	return defaultRegistry.Get(name)
+}
+
+// Remove 从默认的注册表中移除名单，参见 Registry.Remove
+func Remove(name string) {
+	defaultRegistry.Remove(name)
+}
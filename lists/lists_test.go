@@ -0,0 +1,175 @@
+package lists
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "whitelist.txt")
+	assert.Nil(t, os.WriteFile(path, []byte("# uid\n1001\n\n  1002  \n#1003\nabc\n"), 0644))
+
+	values, err := File(path).Load(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1001", "1002", "abc"}, values)
+
+	_, err = File(filepath.Join(t.TempDir(), "missing.txt")).Load(context.Background())
+	assert.NotNil(t, err)
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	var (
+		values = []string{"1001", "1002", "abc"}
+		fail   error
+	)
+	provider := ProviderFunc(func(context.Context) ([]string, error) {
+		return values, fail
+	})
+
+	list, err := Register(ctx, "test_list", provider)
+	assert.Nil(t, err)
+	defer Remove("test_list")
+
+	got, ok := Get("test_list")
+	assert.True(t, ok)
+	assert.Same(t, list, got)
+	assert.Equal(t, "test_list", list.Name())
+	assert.Equal(t, 3, list.Len())
+
+	// 跟 in 操作一样，"1001" 跟 1001 相等
+	for _, tt := range []struct {
+		Value interface{}
+		OK    bool
+	}{
+		{Value: "1001", OK: true},
+		{Value: 1001, OK: true},
+		{Value: 1002.0, OK: true},
+		{Value: "abc", OK: true},
+		{Value: "1003", OK: false},
+		{Value: 1003, OK: false},
+	} {
+		assert.Equal(t, tt.OK, list.Contains(tt.Value), tt.Value)
+	}
+
+	// 元素不变时版本不变
+	version := list.Version()
+	assert.Len(t, version, 8)
+	assert.Nil(t, list.Reload(ctx))
+	assert.Equal(t, version, list.Version())
+	assert.Equal(t, "@list:test_list#"+version, list.String())
+	assert.Equal(t, slog.StringValue(list.String()), list.LogValue())
+
+	values = []string{"1003"}
+	assert.Nil(t, list.Reload(ctx))
+	assert.NotEqual(t, version, list.Version())
+	assert.True(t, list.Contains(1003))
+	assert.False(t, list.Contains(1001))
+
+	// 加载失败时保留之前的元素
+	version = list.Version()
+	fail = errors.New("timeout")
+	assert.EqualError(t, list.Reload(ctx), "list [test_list] load failed: timeout")
+	assert.Equal(t, version, list.Version())
+	assert.True(t, list.Contains(1003))
+
+	_, err = Register(ctx, "test_list", provider)
+	assert.EqualError(t, err, "list [test_list] load failed: timeout")
+	fail = nil
+	_, err = Register(ctx, "test_list", provider)
+	assert.EqualError(t, err, "list [test_list] already exists")
+	_, err = Register(ctx, "", provider)
+	assert.EqualError(t, err, "list name is empty")
+	_, err = Register(ctx, "nil_provider", nil)
+	assert.EqualError(t, err, "list [nil_provider] provider is nil")
+
+	Remove("test_list")
+	_, ok = Get("test_list")
+	assert.False(t, ok)
+}
+
+func TestReload(t *testing.T) {
+	var (
+		loads  int32
+		errs   = make(chan error, 1)
+		values = [][]string{{"1001"}, {"1002"}}
+	)
+	provider := ProviderFunc(func(context.Context) ([]string, error) {
+		n := atomic.AddInt32(&loads, 1)
+		if int(n) > len(values) {
+			return nil, errors.New("unavailable")
+		}
+		return values[n-1], nil
+	})
+
+	list, err := Register(context.Background(), "reload_list", provider,
+		WithReload(10*time.Millisecond),
+		WithErrorHandler(func(_ *List, err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}))
+	assert.Nil(t, err)
+	defer Remove("reload_list")
+	assert.True(t, list.Contains("1001"))
+
+	// 第二次加载之后替换为新的元素，之后加载失败时保留
+	select {
+	case err = <-errs:
+		assert.EqualError(t, err, "list [reload_list] load failed: unavailable")
+	case <-time.After(time.Second):
+		t.Fatal("list not reloaded")
+	}
+	assert.False(t, list.Contains("1001"))
+	assert.True(t, list.Contains("1002"))
+}
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	provider := func(values ...string) Provider {
+		return ProviderFunc(func(context.Context) ([]string, error) {
+			return values, nil
+		})
+	}
+
+	// 不同的注册表中同名的名单互不影响
+	first, second := NewRegistry(), NewRegistry()
+	a, err := first.Register(ctx, "registry_list", provider("1001"))
+	assert.Nil(t, err)
+	b, err := second.Register(ctx, "registry_list", provider("1002"))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"1001"}, a.Values())
+	assert.Equal(t, []interface{}{"1002"}, b.Values())
+
+	got, ok := Lookup(NewContext(ctx, first), "registry_list")
+	assert.True(t, ok)
+	assert.Same(t, a, got)
+	got, ok = Lookup(NewContext(ctx, second), "registry_list")
+	assert.True(t, ok)
+	assert.Same(t, b, got)
+
+	// 没有设置注册表时使用默认的注册表
+	_, ok = Lookup(ctx, "registry_list")
+	assert.False(t, ok)
+	c, err := Register(ctx, "registry_list", provider("1003"))
+	assert.Nil(t, err)
+	defer Remove("registry_list")
+	got, ok = Lookup(ctx, "registry_list")
+	assert.True(t, ok)
+	assert.Same(t, c, got)
+
+	second.Remove("registry_list")
+	_, ok = second.Get("registry_list")
+	assert.False(t, ok)
+	_, ok = first.Get("registry_list")
+	assert.True(t, ok)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/utils"
 	"github.com/airunny/filter/variables"
 )
@@ -21,12 +22,24 @@ func init() {
 type Any struct{}
 
 func (s *Any) Name() string { return Name }
+func (s *Any) Signature() operations.Signature {
+	return operations.Signature{
+		Variables: []types.ValueType{types.TypeList, types.TypeString, types.TypeNumber},
+		Operands:  []types.ValueType{types.TypeList},
+		Sets:      true,
+	}
+}
+
 func (s *Any) PrepareValue(value interface{}) (interface{}, error) {
+	if set, ok := value.(utils.Set); ok {
+		return set, nil
+	}
+
 	targetValues := utils.ParseTargetArrayValue(value)
 	if len(targetValues) == 0 {
 		return nil, ErrInvalidOperationValue
 	}
-	return targetValues, nil
+	return utils.NewValueSet(targetValues), nil
 }
 
 func (s *Any) Run(ctx context.Context, variable variables.Variable, operationValue, data interface{}, cache *cache.Cache) (bool, error) {
@@ -35,17 +48,14 @@ func (s *Any) Run(ctx context.Context, variable variables.Variable, operationVal
 		return false, err
 	}
 
-	variableValueElements := utils.ParseTargetArrayValue(variableValue)
-	targetValueElements, ok := operationValue.([]interface{})
+	set, ok := operationValue.(utils.Set)
 	if !ok {
 		return false, ErrInvalidOperationValue
 	}
 
-	for _, targetValueElement := range targetValueElements {
-		for _, variableValueElement := range variableValueElements {
-			if utils.ObjectCompare(targetValueElement, variableValueElement) == 0 {
-				return true, nil
-			}
+	for _, variableValueElement := range utils.ParseTargetArrayValue(variableValue) {
+		if set.Contains(variableValueElement) {
+			return true, nil
 		}
 	}
 	return false, nil
@@ -7,6 +7,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/utils"
 	"github.com/airunny/filter/variables"
 	"github.com/stretchr/testify/assert"
 )
@@ -216,7 +217,7 @@ func TestAny(t *testing.T) {
 			assert.Nil(t, value)
 		} else {
 			assert.Nil(t, err)
-			assert.Equal(t, tt.ParsedValue, value, index)
+			assert.Equal(t, tt.ParsedValue, value.(*utils.ValueSet).Values(), index)
 		}
 
 		result, err := op.Run(context.Background(), tt.Variable, value, tt.Data, cc)
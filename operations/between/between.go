@@ -6,6 +6,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/utils"
 	"github.com/airunny/filter/variables"
 )
@@ -21,6 +22,10 @@ func init() {
 type Between struct{}
 
 func (s *Between) Name() string { return Name }
+func (s *Between) Signature() operations.Signature {
+	return operations.Signature{Variables: []types.ValueType{types.TypeNumber, types.TypeString}}
+}
+
 func (s *Between) PrepareValue(value interface{}) (interface{}, error) {
 	elements := utils.ParseTargetArrayValue(value)
 	if len(elements) != 2 {
@@ -40,3 +45,15 @@ func (s *Between) Run(ctx context.Context, variable variables.Variable, operatio
 	}
 	return utils.ObjectCompare(variableValue, startAndEnd[0]) >= 0 && utils.ObjectCompare(variableValue, startAndEnd[1]) <= 0, nil
 }
+
+func (s *Between) Compile(operationValue interface{}) operations.Matcher {
+	startAndEnd, ok := operationValue.([]interface{})
+	if !ok || len(startAndEnd) != 2 {
+		return nil
+	}
+
+	start, end := utils.CompileCompare(startAndEnd[0]), utils.CompileCompare(startAndEnd[1])
+	return func(value interface{}) (bool, error) {
+		return start(value) >= 0 && end(value) <= 0, nil
+	}
+}
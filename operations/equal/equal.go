@@ -27,3 +27,10 @@ func (s *Equal) Run(ctx context.Context, variable variables.Variable, operationV
 	}
 	return utils.ObjectCompare(variableValue, operationValue) == 0, nil
 }
+
+func (s *Equal) Compile(operationValue interface{}) operations.Matcher {
+	compare := utils.CompileCompare(operationValue)
+	return func(value interface{}) (bool, error) {
+		return compare(value) == 0, nil
+	}
+}
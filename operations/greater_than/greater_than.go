@@ -5,6 +5,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/utils"
 	"github.com/airunny/filter/variables"
 )
@@ -20,6 +21,10 @@ type GreaterThan struct {
 }
 
 func (s *GreaterThan) Name() string { return s.name }
+func (s *GreaterThan) Signature() operations.Signature {
+	return operations.Signature{Variables: []types.ValueType{types.TypeNumber, types.TypeString}}
+}
+
 func (s *GreaterThan) Run(ctx context.Context, variable variables.Variable, operationValue, data interface{}, cache *cache.Cache) (bool, error) {
 	variableValue, err := variables.GetValue(ctx, variable, data, cache)
 	if err != nil {
@@ -27,3 +32,10 @@ func (s *GreaterThan) Run(ctx context.Context, variable variables.Variable, oper
 	}
 	return utils.ObjectCompare(variableValue, operationValue) == 1, nil
 }
+
+func (s *GreaterThan) Compile(operationValue interface{}) operations.Matcher {
+	compare := utils.CompileCompare(operationValue)
+	return func(value interface{}) (bool, error) {
+		return compare(value) == 1, nil
+	}
+}
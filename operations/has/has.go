@@ -6,6 +6,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/utils"
 	"github.com/airunny/filter/variables"
 )
@@ -21,12 +22,29 @@ func init() {
 type Has struct{}
 
 func (s *Has) Name() string { return Name }
+func (s *Has) Signature() operations.Signature {
+	return operations.Signature{
+		Variables: []types.ValueType{types.TypeList, types.TypeString, types.TypeNumber},
+		Operands:  []types.ValueType{types.TypeList},
+		Sets:      true,
+	}
+}
+
+// values 可以遍历元素的比较值，*utils.ValueSet 以及 "@list:whitelist" 引用的名单
+type values interface {
+	Values() []interface{}
+}
+
 func (s *Has) PrepareValue(value interface{}) (interface{}, error) {
+	if set, ok := value.(values); ok {
+		return set, nil
+	}
+
 	targetValues := utils.ParseTargetArrayValue(value)
 	if len(targetValues) == 0 {
 		return nil, ErrInvalidOperationValue
 	}
-	return targetValues, nil
+	return utils.NewValueSet(targetValues), nil
 }
 
 func (s *Has) Run(ctx context.Context, variable variables.Variable, operationValue, data interface{}, cache *cache.Cache) (bool, error) {
@@ -35,21 +53,15 @@ func (s *Has) Run(ctx context.Context, variable variables.Variable, operationVal
 		return false, err
 	}
 
-	targetValueElements, ok := operationValue.([]interface{})
+	set, ok := operationValue.(values)
 	if !ok {
 		return false, ErrInvalidOperationValue
 	}
 
-	variableValueElements := utils.ParseTargetArrayValue(variableValue)
-	for _, valueElement := range targetValueElements {
-		has := false
-		for _, variableValueElement := range variableValueElements {
-			if utils.ObjectCompare(valueElement, variableValueElement) == 0 {
-				has = true
-				break
-			}
-		}
-		if !has {
+	// 变量的元素建立集合之后逐个查找比较值，结果跟逐个比较一致
+	variableValueElements := utils.NewValueSet(utils.ParseTargetArrayValue(variableValue))
+	for _, valueElement := range set.Values() {
+		if !variableValueElements.Contains(valueElement) {
 			return false, nil
 		}
 	}
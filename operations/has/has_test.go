@@ -7,6 +7,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/utils"
 	"github.com/airunny/filter/variables"
 	"github.com/stretchr/testify/assert"
 )
@@ -211,6 +212,25 @@ func TestHas(t *testing.T) {
 			ParsedValue:    []interface{}{1.1, 2.2},
 			Result:         false,
 		},
+		// 字符串跟数字按照数字比较，"1" 跟 1 相等
+		{
+			Variable: mockVariable{
+				name:  "mock",
+				value: []interface{}{1, "2.0", "vip"},
+			},
+			OperationValue: []interface{}{"1", 2, "vip"},
+			ParsedValue:    []interface{}{"1", 2, "vip"},
+			Result:         true,
+		},
+		{
+			Variable: mockVariable{
+				name:  "mock",
+				value: []interface{}{1, "2.0", "vip"},
+			},
+			OperationValue: []interface{}{"1", "2", "vip"},
+			ParsedValue:    []interface{}{"1", "2", "vip"},
+			Result:         false,
+		},
 	}
 
 	op, ok := operations.Get(Name)
@@ -225,7 +245,7 @@ func TestHas(t *testing.T) {
 			assert.Nil(t, value)
 		} else {
 			assert.Nil(t, err)
-			assert.Equal(t, tt.ParsedValue, value, index)
+			assert.Equal(t, tt.ParsedValue, value.(*utils.ValueSet).Values(), index)
 		}
 
 		result, err := op.Run(context.Background(), tt.Variable, value, tt.Data, cc)
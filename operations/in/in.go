@@ -6,6 +6,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/utils"
 	"github.com/airunny/filter/variables"
 )
@@ -21,12 +22,23 @@ func init() {
 type In struct{}
 
 func (s *In) Name() string { return Name }
+func (s *In) Signature() operations.Signature {
+	return operations.Signature{
+		Operands: []types.ValueType{types.TypeList},
+		Sets:     true,
+	}
+}
+
 func (s *In) PrepareValue(value interface{}) (interface{}, error) {
+	if set, ok := value.(utils.Set); ok {
+		return set, nil
+	}
+
 	targetValues := utils.ParseTargetArrayValue(value)
 	if len(targetValues) == 0 {
 		return nil, emptyElementErr
 	}
-	return targetValues, nil
+	return utils.NewValueSet(targetValues), nil
 }
 
 func (s *In) Run(ctx context.Context, variable variables.Variable, operationValue, data interface{}, cache *cache.Cache) (bool, error) {
@@ -35,21 +47,31 @@ func (s *In) Run(ctx context.Context, variable variables.Variable, operationValu
 		return false, err
 	}
 
-	variableValueElements := utils.ParseTargetArrayValue(variableValue)
-	if targetValues, ok := operationValue.([]interface{}); ok {
-		for _, variableValueElement := range variableValueElements {
-			exists := false
-			for _, targetValue := range targetValues {
-				if utils.ObjectCompare(variableValueElement, targetValue) == 0 {
-					exists = true
-				}
-			}
+	set, ok := operationValue.(utils.Set)
+	if !ok {
+		return false, nil
+	}
+
+	for _, variableValueElement := range utils.ParseTargetArrayValue(variableValue) {
+		if !set.Contains(variableValueElement) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (s *In) Compile(operationValue interface{}) operations.Matcher {
+	set, ok := operationValue.(utils.Set)
+	if !ok {
+		return nil
+	}
 
-			if !exists {
+	return func(value interface{}) (bool, error) {
+		for _, element := range utils.ParseTargetArrayValue(value) {
+			if !set.Contains(element) {
 				return false, nil
 			}
 		}
 		return true, nil
 	}
-	return false, nil
 }
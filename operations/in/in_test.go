@@ -8,6 +8,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/utils"
 	"github.com/airunny/filter/variables"
 	"github.com/stretchr/testify/assert"
 )
@@ -147,6 +148,25 @@ func TestIn(t *testing.T) {
 			Target: []interface{}{"1", "2"},
 			Result: true,
 		},
+		// 字符串跟数字按照数字比较，"1" 跟 1 相等
+		{
+			Variable: mockVariable{
+				name:  "mock",
+				value: []interface{}{1, "2", 3.0},
+			},
+			Value:  []interface{}{"1", 2, "3"},
+			Target: []interface{}{"1", 2, "3"},
+			Result: true,
+		},
+		{
+			Variable: mockVariable{
+				name:  "mock",
+				value: "2.0",
+			},
+			Value:  []interface{}{"1", "2"},
+			Target: []interface{}{"1", "2"},
+			Result: false,
+		},
 	}
 
 	op, ok := operations.Get("in")
@@ -160,7 +180,7 @@ func TestIn(t *testing.T) {
 			assert.Equal(t, tt.ParseErr, err)
 		} else {
 			assert.Nil(t, err)
-			assert.True(t, reflect.DeepEqual(value, tt.Target), index)
+			assert.True(t, reflect.DeepEqual(value.(*utils.ValueSet).Values(), tt.Target), index)
 		}
 
 		result, err := op.Run(context.Background(), tt.Variable, value, tt.Data, cc)
@@ -5,6 +5,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/utils"
 	"github.com/airunny/filter/variables"
 )
@@ -20,6 +21,10 @@ type LessThanEqual struct {
 }
 
 func (s *LessThanEqual) Name() string { return s.name }
+func (s *LessThanEqual) Signature() operations.Signature {
+	return operations.Signature{Variables: []types.ValueType{types.TypeNumber, types.TypeString}}
+}
+
 func (s *LessThanEqual) Run(ctx context.Context, variable variables.Variable, operationValue, data interface{}, cache *cache.Cache) (bool, error) {
 	variableValue, err := variables.GetValue(ctx, variable, data, cache)
 	if err != nil {
@@ -27,3 +32,10 @@ func (s *LessThanEqual) Run(ctx context.Context, variable variables.Variable, op
 	}
 	return utils.ObjectCompare(variableValue, operationValue) <= 0, nil
 }
+
+func (s *LessThanEqual) Compile(operationValue interface{}) operations.Matcher {
+	compare := utils.CompileCompare(operationValue)
+	return func(value interface{}) (bool, error) {
+		return compare(value) <= 0, nil
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/utils"
 	"github.com/airunny/filter/variables"
 )
@@ -28,6 +29,13 @@ func init() {
 type MatchNone struct{}
 
 func (s *MatchNone) Name() string { return Name }
+func (s *MatchNone) Signature() operations.Signature {
+	return operations.Signature{
+		Variables: []types.ValueType{types.TypeString, types.TypeVersion, types.TypeIP},
+		Operands:  []types.ValueType{types.TypeList},
+	}
+}
+
 func (s *MatchNone) PrepareValue(value interface{}) (interface{}, error) {
 	values := utils.ParseTargetArrayValue(value)
 	if len(values) == 0 {
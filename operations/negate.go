@@ -0,0 +1,59 @@
+package operations
+
+import (
+	"context"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/variables"
+)
+
+// NegatePrefix 操作名加上前缀表示对操作的结果取反，例如 !between、!vgt、!has，任何已注册的操作都可以使用
+const NegatePrefix = "!"
+
+// Negated 对操作的结果取反，操作返回错误时仍然返回错误，不会因为取值失败而成立
+type Negated struct {
+	operation Operation
+}
+
+// Negate 对 operation 的结果取反，Get 遇到未注册的 !name 时使用
+func Negate(operation Operation) *Negated {
+	return &Negated{operation: operation}
+}
+
+func (s *Negated) Name() string      { return NegatePrefix + s.operation.Name() }
+func (s *Negated) Unwrap() Operation { return s.operation }
+
+// Signature 跟被取反的操作相同
+func (s *Negated) Signature() Signature { return SignatureOf(s.operation) }
+func (s *Negated) PrepareValue(value interface{}) (interface{}, error) {
+	return s.operation.PrepareValue(value)
+}
+
+func (s *Negated) Run(ctx context.Context, variable variables.Variable, operationValue, data interface{}, cache *cache.Cache) (bool, error) {
+	ok, err := s.operation.Run(ctx, variable, operationValue, data, cache)
+	if err != nil {
+		return false, err
+	}
+	return !ok, nil
+}
+
+// Compile 被取反的操作实现了 Compiler 时同样编译
+func (s *Negated) Compile(operationValue interface{}) Matcher {
+	compiler, ok := s.operation.(Compiler)
+	if !ok {
+		return nil
+	}
+
+	matcher := compiler.Compile(operationValue)
+	if matcher == nil {
+		return nil
+	}
+
+	return func(value interface{}) (bool, error) {
+		ok, err := matcher(value)
+		if err != nil {
+			return false, err
+		}
+		return !ok, nil
+	}
+}
@@ -0,0 +1,93 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/types"
+	"github.com/airunny/filter/variables"
+	"github.com/stretchr/testify/assert"
+)
+
+// equalOperation 变量的取值等于比较值时成立，取值不是字符串时返回错误
+type equalOperation struct {
+	OriginValue
+}
+
+func (s *equalOperation) Name() string { return "negate_equal" }
+func (s *equalOperation) Signature() Signature {
+	return Signature{Operands: []types.ValueType{types.TypeString}}
+}
+
+func (s *equalOperation) Run(_ context.Context, _ variables.Variable, value, data interface{}, _ *cache.Cache) (bool, error) {
+	return s.Compile(value)(data)
+}
+
+func (s *equalOperation) Compile(operationValue interface{}) Matcher {
+	return func(value interface{}) (bool, error) {
+		if _, ok := value.(string); !ok {
+			return false, errors.New("value is not string")
+		}
+		return value == operationValue, nil
+	}
+}
+
+func TestNegate(t *testing.T) {
+	f := &factory{operations: make(map[string]Operation)}
+	f.Register(&equalOperation{})
+	f.Register(mockOperation{name: "!negate_equal_mock"})
+
+	operation, ok := f.Get("!negate_equal")
+	assert.True(t, ok)
+	assert.Equal(t, "!negate_equal", operation.Name())
+	assert.IsType(t, &Negated{}, operation)
+
+	// 已注册的操作优先
+	operation2, ok := f.Get("!negate_equal_mock")
+	assert.True(t, ok)
+	assert.Equal(t, mockOperation{name: "!negate_equal_mock"}, operation2)
+
+	for _, name := range []string{"!", "!unknown", "!!negate_equal"} {
+		_, ok = f.Get(name)
+		assert.False(t, ok, name)
+	}
+
+	value, err := operation.PrepareValue("a")
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	matcher := operation.(Compiler).Compile(value)
+	cases := []struct {
+		Data interface{}
+		OK   bool
+		Err  string
+	}{
+		{Data: "a", OK: false},
+		{Data: "b", OK: true},
+		// 错误不会取反为成立
+		{Data: 1, OK: false, Err: "value is not string"},
+	}
+
+	for index, tt := range cases {
+		ok, err := operation.Run(ctx, nil, value, tt.Data, nil)
+		ok2, err2 := matcher(tt.Data)
+		if tt.Err != "" {
+			assert.EqualError(t, err, tt.Err, index)
+			assert.EqualError(t, err2, tt.Err, index)
+		} else {
+			assert.Nil(t, err, index)
+			assert.Nil(t, err2, index)
+		}
+		assert.Equal(t, tt.OK, ok, index)
+		assert.Equal(t, tt.OK, ok2, index)
+	}
+
+	// 取反之后签名不变
+	assert.Equal(t, Signature{Operands: []types.ValueType{types.TypeString}}, SignatureOf(operation))
+
+	// 未实现 Compiler 的操作
+	assert.Nil(t, Negate(mockOperation{name: "mock"}).Compile("a"))
+	assert.Equal(t, mockOperation{name: "mock"}, Negate(mockOperation{name: "mock"}).Unwrap())
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/utils"
 	"github.com/airunny/filter/variables"
 )
@@ -21,12 +22,23 @@ func init() {
 type NotIn struct{}
 
 func (s *NotIn) Name() string { return Name }
+func (s *NotIn) Signature() operations.Signature {
+	return operations.Signature{
+		Operands: []types.ValueType{types.TypeList},
+		Sets:     true,
+	}
+}
+
 func (s *NotIn) PrepareValue(value interface{}) (interface{}, error) {
+	if set, ok := value.(utils.Set); ok {
+		return set, nil
+	}
+
 	targetValues := utils.ParseTargetArrayValue(value)
 	if len(targetValues) == 0 {
 		return nil, emptyElementErr
 	}
-	return targetValues, nil
+	return utils.NewValueSet(targetValues), nil
 }
 func (s *NotIn) Run(ctx context.Context, variable variables.Variable, operationValue, data interface{}, cache *cache.Cache) (bool, error) {
 	variableValue, err := variables.GetValue(ctx, variable, data, cache)
@@ -34,12 +46,19 @@ func (s *NotIn) Run(ctx context.Context, variable variables.Variable, operationV
 		return false, err
 	}
 
-	if targetValues, ok := operationValue.([]interface{}); ok {
-		for _, targetValue := range targetValues {
-			if utils.ObjectCompare(variableValue, targetValue) == 0 {
-				return false, nil
-			}
-		}
+	if set, ok := operationValue.(utils.Set); ok {
+		return !set.Contains(variableValue), nil
 	}
 	return true, nil
 }
+
+func (s *NotIn) Compile(operationValue interface{}) operations.Matcher {
+	set, ok := operationValue.(utils.Set)
+	if !ok {
+		return nil
+	}
+
+	return func(value interface{}) (bool, error) {
+		return !set.Contains(value), nil
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/utils"
 	"github.com/airunny/filter/variables"
 	"github.com/stretchr/testify/assert"
 )
@@ -169,7 +170,7 @@ func TestNot(t *testing.T) {
 			assert.Equal(t, tt.ParseErr, err)
 		} else {
 			assert.Nil(t, err)
-			assert.True(t, reflect.DeepEqual(value, tt.Target), index)
+			assert.True(t, reflect.DeepEqual(value.(*utils.ValueSet).Values(), tt.Target), index)
 		}
 
 		result, err := op.Run(context.Background(), tt.Variable, value, tt.Data, cc)
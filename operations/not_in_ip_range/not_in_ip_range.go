@@ -8,6 +8,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/utils"
 	"github.com/airunny/filter/variables"
 )
@@ -28,6 +29,13 @@ func init() {
 type NotInIPRange struct{}
 
 func (s *NotInIPRange) Name() string { return Name }
+func (s *NotInIPRange) Signature() operations.Signature {
+	return operations.Signature{
+		Variables: []types.ValueType{types.TypeIP, types.TypeString},
+		Operands:  []types.ValueType{types.TypeList},
+	}
+}
+
 func (s *NotInIPRange) PrepareValue(value interface{}) (interface{}, error) {
 	targetValue := utils.ParseTargetArrayValue(value)
 	if len(targetValue) == 0 {
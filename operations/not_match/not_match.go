@@ -8,6 +8,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/variables"
 )
 
@@ -25,6 +26,13 @@ func init() {
 type NotMatch struct{}
 
 func (s *NotMatch) Name() string { return Name }
+func (s *NotMatch) Signature() operations.Signature {
+	return operations.Signature{
+		Variables: []types.ValueType{types.TypeString, types.TypeVersion, types.TypeIP},
+		Operands:  []types.ValueType{types.TypeRegex},
+	}
+}
+
 func (s *NotMatch) PrepareValue(value interface{}) (interface{}, error) {
 	targetValue, ok := value.(string)
 	if !ok {
@@ -66,3 +74,25 @@ func (s *NotMatch) Run(ctx context.Context, variable variables.Variable, operati
 		return false, ErrInvalidOperationValue
 	}
 }
+
+func (s *NotMatch) Compile(operationValue interface{}) operations.Matcher {
+	switch target := operationValue.(type) {
+	case *regexp.Regexp:
+		return func(value interface{}) (bool, error) {
+			str, ok := value.(string)
+			if !ok {
+				return false, ErrInvalidVariableValue
+			}
+			return !target.MatchString(str), nil
+		}
+	case string:
+		return func(value interface{}) (bool, error) {
+			str, ok := value.(string)
+			if !ok {
+				return false, ErrInvalidVariableValue
+			}
+			return !strings.Contains(str, target), nil
+		}
+	}
+	return nil
+}
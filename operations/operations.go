@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/airunny/filter/cache"
@@ -16,6 +18,15 @@ type Operation interface {
 	Run(ctx context.Context, variable variables.Variable, operationValue, data interface{}, cache *cache.Cache) (bool, error)
 }
 
+// Matcher 编译之后的操作，参数为变量的取值
+type Matcher func(value interface{}) (bool, error)
+
+// Compiler 可选实现，构建条件时把准备好的操作数编译为 Matcher，执行时不再经过 Run 以及对操作数的类型判断。
+// 返回nil时仍然使用 Run，未实现的操作（例如业务自定义的操作）也使用 Run
+type Compiler interface {
+	Compile(operationValue interface{}) Matcher
+}
+
 type OriginValue struct{}
 
 func (s *OriginValue) PrepareValue(value interface{}) (interface{}, error) {
@@ -47,15 +58,40 @@ func (s *factory) Register(operation Operation) {
 	s.operations[operation.Name()] = operation
 }
 
+// Get 已注册的操作，未注册的 !name 在 name 已注册时返回取反之后的操作，参见 Negate
 func (s *factory) Get(name string) (Operation, bool) {
-	operation, ok := s.operations[name]
-	return operation, ok
+	if operation, ok := s.operations[name]; ok {
+		return operation, true
+	}
+
+	if len(name) > len(NegatePrefix) && strings.HasPrefix(name, NegatePrefix) {
+		if operation, ok := s.operations[name[len(NegatePrefix):]]; ok {
+			return Negate(operation), true
+		}
+	}
+	return nil, false
+}
+
+func (s *factory) Names() []string {
+	s.Lock()
+	defer s.Unlock()
+	names := make([]string, 0, len(s.operations))
+	for name := range s.operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func Register(operation Operation) {
 	defaultFactory.Register(operation)
 }
 
+// Names 返回所有已注册的操作符
+func Names() []string {
+	return defaultFactory.Names()
+}
+
 func Get(name string) (Operation, bool) {
 	return defaultFactory.Get(name)
 }
@@ -62,6 +62,7 @@ func TestRegister(t *testing.T) {
 	got, ok = Get("lt")
 	assert.False(t, ok)
 	assert.Nil(t, got)
+	assert.Equal(t, []string{"gt"}, Names())
 }
 
 type PanicTestFunc func()
@@ -0,0 +1,62 @@
+package operations
+
+import (
+	"github.com/airunny/filter/types"
+)
+
+// Signature 操作接受的变量类型以及比较值的类型，构建条件时检查，类型为 types.TypeUnknown 时只在执行时检查
+type Signature struct {
+	Variables []types.ValueType // 接受的变量类型，为空时接受任意类型
+	Operands  []types.ValueType // 比较值可以是其中任意一种类型，为空时不检查
+	Sets      bool              // 比较值可以是 utils.Set，例如 "@list:whitelist" 引用的名单
+}
+
+// Typed 可选实现，返回操作的签名，例如 vgt 只接受版本号以及字符串类型的变量，比较值为版本号
+type Typed interface {
+	Signature() Signature
+}
+
+// SignatureOf 操作的签名，未实现 Typed 时为空，不做任何检查
+func SignatureOf(operation Operation) Signature {
+	if typed, ok := operation.(Typed); ok {
+		return typed.Signature()
+	}
+	return Signature{}
+}
+
+// AcceptVariable 是否接受类型为t的变量
+func (s Signature) AcceptVariable(t types.ValueType) bool {
+	return accept(s.Variables, t)
+}
+
+// AcceptOperandType 是否接受类型为t的比较值，用于比较值引用其它变量的情况
+func (s Signature) AcceptOperandType(t types.ValueType) bool {
+	return accept(s.Operands, t)
+}
+
+// AcceptOperand 配置中的比较值是否为接受的类型之一
+func (s Signature) AcceptOperand(value interface{}) bool {
+	if len(s.Operands) == 0 {
+		return true
+	}
+
+	for _, t := range s.Operands {
+		if t.Check(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func accept(accepted []types.ValueType, t types.ValueType) bool {
+	if len(accepted) == 0 || t == types.TypeUnknown {
+		return true
+	}
+
+	for _, value := range accepted {
+		if value == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package operations
+
+import (
+	"testing"
+
+	"github.com/airunny/filter/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignature(t *testing.T) {
+	signature := Signature{
+		Variables: []types.ValueType{types.TypeVersion, types.TypeString},
+		Operands:  []types.ValueType{types.TypeVersion},
+	}
+
+	assert.True(t, signature.AcceptVariable(types.TypeVersion))
+	assert.True(t, signature.AcceptVariable(types.TypeUnknown))
+	assert.False(t, signature.AcceptVariable(types.TypeNumber))
+	assert.True(t, signature.AcceptOperandType(types.TypeVersion))
+	assert.False(t, signature.AcceptOperandType(types.TypeIP))
+	assert.True(t, signature.AcceptOperand("3.0"))
+	assert.False(t, signature.AcceptOperand("3.x"))
+
+	// 未实现 Typed 时不做任何检查
+	empty := SignatureOf(mockOperation{name: "mock"})
+	assert.Equal(t, Signature{}, empty)
+	assert.True(t, empty.AcceptVariable(types.TypeNumber))
+	assert.True(t, empty.AcceptOperand([]interface{}{1}))
+}
@@ -21,6 +21,13 @@ type VersionGreaterThan struct {
 }
 
 func (s *VersionGreaterThan) Name() string { return Name }
+func (s *VersionGreaterThan) Signature() operations.Signature {
+	return operations.Signature{
+		Variables: []types.ValueType{types.TypeVersion, types.TypeString},
+		Operands:  []types.ValueType{types.TypeVersion},
+	}
+}
+
 func (s *VersionGreaterThan) Run(ctx context.Context, variable variables.Variable, operationValue, data interface{}, cache *cache.Cache) (bool, error) {
 	variableValue, err := variables.GetValue(ctx, variable, data, cache)
 	if err != nil {
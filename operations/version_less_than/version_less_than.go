@@ -21,6 +21,13 @@ type VersionLessThan struct {
 }
 
 func (s *VersionLessThan) Name() string { return Name }
+func (s *VersionLessThan) Signature() operations.Signature {
+	return operations.Signature{
+		Variables: []types.ValueType{types.TypeVersion, types.TypeString},
+		Operands:  []types.ValueType{types.TypeVersion},
+	}
+}
+
 func (s *VersionLessThan) Run(ctx context.Context, variable variables.Variable, operationValue, data interface{}, cache *cache.Cache) (bool, error) {
 	variableValue, err := variables.GetValue(ctx, variable, data, cache)
 	if err != nil {
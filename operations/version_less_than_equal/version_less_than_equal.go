@@ -21,6 +21,13 @@ type VersionLessThanEqual struct {
 }
 
 func (s *VersionLessThanEqual) Name() string { return Name }
+func (s *VersionLessThanEqual) Signature() operations.Signature {
+	return operations.Signature{
+		Variables: []types.ValueType{types.TypeVersion, types.TypeString},
+		Operands:  []types.ValueType{types.TypeVersion},
+	}
+}
+
 func (s *VersionLessThanEqual) Run(ctx context.Context, variable variables.Variable, operationValue, data interface{}, cache *cache.Cache) (bool, error) {
 	variableValue, err := variables.GetValue(ctx, variable, data, cache)
 	if err != nil {
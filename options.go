@@ -0,0 +1,35 @@
+package filter
+
+import (
+	"github.com/airunny/filter/hook"
+	"github.com/airunny/filter/lists"
+)
+
+type options struct {
+	hooks   []hook.Hook
+	noIndex bool
+	lists   *lists.Registry
+}
+
+type Option func(o *options)
+
+// WithHooks 注册在每次Execute时都会调用的Hook
+func WithHooks(hooks ...hook.Hook) Option {
+	return func(o *options) {
+		o.hooks = append(o.hooks, hooks...)
+	}
+}
+
+// WithoutIndex 不使用索引，每次执行时逐个执行所有filter，参见 buildIndex
+func WithoutIndex() Option {
+	return func(o *options) {
+		o.noIndex = true
+	}
+}
+
+// WithLists 构建条件时从 registry 中查找 @list: 引用的名单，不同的Filter可以使用同名的不同名单，参见 lists.NewContext
+func WithLists(registry *lists.Registry) Option {
+	return func(o *options) {
+		o.lists = registry
+	}
+}
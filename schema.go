@@ -0,0 +1,350 @@
+package filter
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/airunny/filter/assignment"
+	"github.com/airunny/filter/condition"
+	"github.com/airunny/filter/operations"
+	"github.com/airunny/filter/variables"
+)
+
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	scalarSchema = map[string]interface{}{
+		"type": []interface{}{"string", "number", "boolean", "null"},
+	}
+
+	listSchema = map[string]interface{}{
+		"description": "array or comma separated string",
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "array", "minItems": 1, "items": scalarSchema},
+			map[string]interface{}{"type": "string", "minLength": 1},
+			map[string]interface{}{"type": "number"},
+		},
+	}
+
+	cachedSchema = map[string]interface{}{
+		"description": "share the result among all filters with the same condition in one execution",
+		"type":        "boolean",
+	}
+
+	operandSchema = map[string]interface{}{
+		"description": "reference to another variable, e.g. $data.price",
+		"type":        "string",
+		"pattern":     `^\$[^$]`,
+	}
+
+	betweenSchema = map[string]interface{}{
+		"description": "two elements: start and end",
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "array", "minItems": 2, "maxItems": 2, "items": scalarSchema},
+			map[string]interface{}{"type": "string", "pattern": `^[^,]+,[^,]+$`},
+		},
+	}
+
+	matchSchema = map[string]interface{}{
+		"description": "substring or /regexp/",
+		"type":        "string",
+		"minLength":   1,
+	}
+
+	matchListSchema = map[string]interface{}{
+		"description": "substrings or /regexp/, array or comma separated string",
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "array", "minItems": 1, "items": matchSchema},
+			matchSchema,
+		},
+	}
+
+	versionSchema = map[string]interface{}{
+		"description": "version like 3.4.5",
+		"type":        []interface{}{"string", "number"},
+		"pattern":     `^[vV]?\d+(\.\d+)*$`,
+	}
+
+	// ipv4 或者 ipv6 的CIDR，例如 192.168.1.1/24、2001:db8::/32、::ffff:10.0.0.0/104
+	cidrPattern = `(\d{1,3}(\.\d{1,3}){3}/\d{1,2}|[0-9a-fA-F]{0,4}(:[0-9a-fA-F]{0,4}){2,7}(\.\d{1,3}){0,3}/\d{1,3})`
+	cidrSchema  = map[string]interface{}{
+		"description": "CIDRs like 192.168.1.1/24 or 2001:db8::/32, array or comma separated string",
+		"anyOf": []interface{}{
+			map[string]interface{}{
+				"type":     "array",
+				"minItems": 1,
+				"items":    map[string]interface{}{"type": "string", "pattern": "^" + cidrPattern + "$"},
+			},
+			map[string]interface{}{"type": "string", "pattern": `^\s*` + cidrPattern + `(\s*,\s*` + cidrPattern + `)*\s*$`},
+		},
+	}
+
+	// operationValueSchemas 内置操作符比较值的格式，未列出的操作符不做限制
+	operationValueSchemas = map[string]interface{}{
+		"=":       scalarSchema,
+		"eq":      scalarSchema,
+		"!=":      scalarSchema,
+		"<>":      scalarSchema,
+		"ne":      scalarSchema,
+		">":       scalarSchema,
+		"gt":      scalarSchema,
+		">=":      scalarSchema,
+		"gte":     scalarSchema,
+		"<":       scalarSchema,
+		"lt":      scalarSchema,
+		"<=":      scalarSchema,
+		"lte":     scalarSchema,
+		"between": betweenSchema,
+		"in":      listSchema,
+		"nin":     listSchema,
+		"any":     listSchema,
+		"has":     listSchema,
+		"not":     listSchema,
+		"~":       matchSchema,
+		"!~":      matchSchema,
+		"~*":      matchListSchema,
+		"!~*":     matchListSchema,
+		"vgt":     versionSchema,
+		"vgte":    versionSchema,
+		"vlt":     versionSchema,
+		"vlte":    versionSchema,
+		"iir":     cidrSchema,
+		"niir":    cidrSchema,
+	}
+)
+
+// JSONSchema 根据当前已注册的变量、操作符以及赋值操作生成配置的JSON Schema，可用于编辑器自动补全以及配置校验
+func JSONSchema() map[string]interface{} {
+	var (
+		names    []interface{}
+		prefixes []string
+	)
+
+	var missing []string
+	names = append(names, condition.SegmentVariable)
+	for _, name := range variables.Names() {
+		if strings.HasSuffix(name, ".") {
+			prefixes = append(prefixes, regexp.QuoteMeta(name))
+			continue
+		}
+		names = append(names, name)
+		missing = append(missing, regexp.QuoteMeta(name))
+	}
+
+	variableSchema := map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"enum": names},
+		},
+	}
+	if len(prefixes) > 0 {
+		variableSchema["anyOf"] = append(variableSchema["anyOf"].([]interface{}), map[string]interface{}{
+			"type":    "string",
+			"pattern": "^(" + strings.Join(prefixes, "|") + ").+$",
+		})
+	}
+	if len(missing) > 0 {
+		// 带有缺失策略的变量名，例如 version?、version?false，前缀变量已经包含在上面的规则中
+		variableSchema["anyOf"] = append(variableSchema["anyOf"].([]interface{}), map[string]interface{}{
+			"type":    "string",
+			"pattern": `^(` + strings.Join(missing, "|") + `)\?(error|false|true|unknown|=.*)?$`,
+		})
+	}
+
+	var (
+		operationNames = operations.Names()
+		operationEnum  = make([]interface{}, 0, len(operationNames)*2)
+		valueRules     = make([]interface{}, 0, len(operationNames)*2)
+		registered     = make(map[string]bool, len(operationNames))
+	)
+
+	// 取反的操作使用同样的比较值，例如 !between
+	for _, name := range operationNames {
+		registered[name] = true
+	}
+	for _, name := range operationNames {
+		negated := operations.NegatePrefix + name
+		if !strings.HasPrefix(name, operations.NegatePrefix) && !registered[negated] {
+			operationNames = append(operationNames, negated)
+		}
+	}
+
+	for _, name := range operationNames {
+		operationEnum = append(operationEnum, name)
+		schemaName := name
+		if !registered[name] {
+			schemaName = name[len(operations.NegatePrefix):]
+		}
+
+		valueSchema, ok := operationValueSchemas[schemaName]
+		if !ok {
+			continue
+		}
+
+		valueRules = append(valueRules, map[string]interface{}{
+			"if": map[string]interface{}{
+				"prefixItems": []interface{}{true, map[string]interface{}{"const": name}},
+			},
+			"then": map[string]interface{}{
+				"prefixItems": []interface{}{true, true, map[string]interface{}{
+					"anyOf": []interface{}{operandSchema, valueSchema},
+				}},
+			},
+		})
+	}
+
+	assignmentNames := assignment.Names()
+	assignmentEnum := make([]interface{}, 0, len(assignmentNames))
+	for _, name := range assignmentNames {
+		assignmentEnum = append(assignmentEnum, name)
+	}
+
+	return map[string]interface{}{
+		"$schema": schemaDraft,
+		"title":   "filter config",
+		"type":    "object",
+		"properties": map[string]interface{}{
+			"filters": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"$ref": "#/$defs/filter"},
+			},
+			"batch":    map[string]interface{}{"type": "boolean"},
+			"optimize": map[string]interface{}{"type": "boolean"},
+			"segments": map[string]interface{}{
+				"description":          "named conditions referenced as [\"segment\", \"=\", name]",
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"$ref": "#/$defs/condition"},
+			},
+		},
+		"$defs": map[string]interface{}{
+			"filter": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id":       map[string]interface{}{"type": "string"},
+					"weight":   map[string]interface{}{"type": "integer", "minimum": 0},
+					"priority": map[string]interface{}{"type": "integer"},
+					"filter": map[string]interface{}{
+						"description": "conditions followed by the executor as the last item",
+						"type":        "array",
+						"minItems":    2,
+						"items": map[string]interface{}{
+							"anyOf": []interface{}{
+								map[string]interface{}{"$ref": "#/$defs/condition"},
+								map[string]interface{}{"$ref": "#/$defs/executor"},
+							},
+						},
+						// 只有执行项可以不是合法的条件，有误的条件不会被当作执行项通过校验
+						"contains":    map[string]interface{}{"not": map[string]interface{}{"$ref": "#/$defs/condition"}},
+						"minContains": 0,
+						"maxContains": 1,
+					},
+				},
+				"required": []interface{}{"filter"},
+			},
+			"variable": variableSchema,
+			"condition": map[string]interface{}{
+				"anyOf": []interface{}{
+					map[string]interface{}{"$ref": "#/$defs/group"},
+					map[string]interface{}{"$ref": "#/$defs/conditions"},
+					map[string]interface{}{"$ref": "#/$defs/baseCondition"},
+					map[string]interface{}{"$ref": "#/$defs/quantifier"},
+				},
+			},
+			"quantifier": map[string]interface{}{
+				"description": "condition evaluated against each element of an array, e.g. [\"data.orders\", \"anyof\", [[\"status\", \"=\", \"paid\"]]]",
+				"type":        "array",
+				"minItems":    3,
+				"maxItems":    4,
+				"prefixItems": []interface{}{
+					map[string]interface{}{"$ref": "#/$defs/variable"},
+					map[string]interface{}{"enum": []interface{}{condition.QuantifierAny, condition.QuantifierAll, condition.QuantifierNone}},
+					// 元素的字段可以省略 data. 前缀，不能使用 variable 校验
+					map[string]interface{}{"type": "array", "minItems": 1},
+					map[string]interface{}{"$ref": "#/$defs/conditionOptions"},
+				},
+			},
+			"conditions": map[string]interface{}{
+				"type":     "array",
+				"minItems": 1,
+				"items": map[string]interface{}{
+					"type":     "array",
+					"$ref":     "#/$defs/condition",
+					"minItems": 1,
+				},
+			},
+			"group": map[string]interface{}{
+				"type":     "array",
+				"minItems": 3,
+				"maxItems": 4,
+				"prefixItems": []interface{}{
+					map[string]interface{}{"anyOf": []interface{}{
+						map[string]interface{}{"enum": []interface{}{"and", "or", "not", "nor", "xor", "nand"}},
+						map[string]interface{}{
+							"description": "at least or at most N conditions are satisfied",
+							"type":        "string",
+							"pattern":     "^(atleast|atmost):[0-9]+$",
+						},
+					}},
+					map[string]interface{}{"const": "=>"},
+					map[string]interface{}{"$ref": "#/$defs/condition"},
+					map[string]interface{}{"$ref": "#/$defs/groupOptions"},
+				},
+			},
+			"groupOptions": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"ordered": map[string]interface{}{
+						"description": "keep config order when optimize is enabled",
+						"type":        "boolean",
+					},
+					"cached": cachedSchema,
+				},
+				"additionalProperties": false,
+			},
+			"conditionOptions": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"cached": cachedSchema,
+				},
+				"additionalProperties": false,
+			},
+			"baseCondition": map[string]interface{}{
+				"type":     "array",
+				"minItems": 3,
+				"maxItems": 4,
+				"prefixItems": []interface{}{
+					map[string]interface{}{"$ref": "#/$defs/variable"},
+					map[string]interface{}{"enum": operationEnum},
+					true,
+					map[string]interface{}{"$ref": "#/$defs/conditionOptions"},
+				},
+				"allOf": valueRules,
+			},
+			"executor": map[string]interface{}{
+				"anyOf": []interface{}{
+					map[string]interface{}{"$ref": "#/$defs/assignment"},
+					map[string]interface{}{
+						"type":     "array",
+						"minItems": 1,
+						"items":    map[string]interface{}{"$ref": "#/$defs/assignment"},
+					},
+				},
+			},
+			"assignment": map[string]interface{}{
+				"type":     "array",
+				"minItems": 3,
+				"maxItems": 3,
+				"prefixItems": []interface{}{
+					map[string]interface{}{"type": "string", "minLength": 1},
+					map[string]interface{}{"enum": assignmentEnum},
+					true,
+				},
+			},
+		},
+	}
+}
+
+// MarshalJSONSchema 返回格式化之后的JSON Schema
+func MarshalJSONSchema() ([]byte, error) {
+	return json.MarshalIndent(JSONSchema(), "", "  ")
+}
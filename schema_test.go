@@ -0,0 +1,368 @@
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONSchema(t *testing.T) {
+	schema := JSONSchema()
+	assert.Equal(t, schemaDraft, schema["$schema"])
+
+	defs := schema["$defs"].(map[string]interface{})
+
+	// variables
+	variableSchema := defs["variable"].(map[string]interface{})["anyOf"].([]interface{})
+	assert.Equal(t, 3, len(variableSchema))
+	names := variableSchema[0].(map[string]interface{})["enum"].([]interface{})
+	for _, name := range []string{"city", "hour", "ip", "platform", "success", "version"} {
+		assert.Contains(t, names, name)
+	}
+	assert.NotContains(t, names, "data.")
+
+	prefix := regexp.MustCompile(variableSchema[1].(map[string]interface{})["pattern"].(string))
+	for _, name := range []string{"data.a.b", "freq.daily", "calc.__a+__b", "ctx.trace"} {
+		assert.True(t, prefix.MatchString(name), name)
+	}
+	assert.False(t, prefix.MatchString("data."))
+	assert.False(t, prefix.MatchString("golang.a"))
+
+	missing := regexp.MustCompile(variableSchema[2].(map[string]interface{})["pattern"].(string))
+	for _, name := range []string{"version?", "platform?false", "hour?=10", "ip?error"} {
+		assert.True(t, missing.MatchString(name), name)
+	}
+	assert.False(t, missing.MatchString("version?maybe"))
+	assert.False(t, missing.MatchString("golang?"))
+
+	// quantifier
+	quantifier := defs["quantifier"].(map[string]interface{})["prefixItems"].([]interface{})
+	assert.Equal(t, []interface{}{"anyof", "allof", "noneof"}, quantifier[1].(map[string]interface{})["enum"])
+
+	// options
+	assert.Equal(t, cachedSchema, defs["groupOptions"].(map[string]interface{})["properties"].(map[string]interface{})["cached"])
+	assert.Equal(t, cachedSchema, defs["conditionOptions"].(map[string]interface{})["properties"].(map[string]interface{})["cached"])
+
+	// operations
+	baseCondition := defs["baseCondition"].(map[string]interface{})
+	operationEnum := baseCondition["prefixItems"].([]interface{})[1].(map[string]interface{})["enum"].([]interface{})
+	for _, name := range []string{"=", "between", "in", "iir", "vgt", "~*", "!between", "!vgt", "!has"} {
+		assert.Contains(t, operationEnum, name)
+	}
+	assert.NotContains(t, operationEnum, "!!=")
+
+	rules := make(map[string]interface{})
+	for _, rule := range baseCondition["allOf"].([]interface{}) {
+		condition := rule.(map[string]interface{})["if"].(map[string]interface{})["prefixItems"].([]interface{})[1]
+		then := rule.(map[string]interface{})["then"].(map[string]interface{})["prefixItems"].([]interface{})[2]
+		value := then.(map[string]interface{})["anyOf"].([]interface{})
+		assert.Equal(t, operandSchema, value[0])
+		rules[condition.(map[string]interface{})["const"].(string)] = value[1]
+	}
+	assert.Equal(t, len(operationEnum), len(rules))
+	assert.Equal(t, betweenSchema, rules["between"])
+	assert.Equal(t, cidrSchema, rules["iir"])
+	assert.Equal(t, versionSchema, rules["vlte"])
+	assert.Equal(t, betweenSchema, rules["!between"])
+
+	between := regexp.MustCompile(betweenSchema["anyOf"].([]interface{})[1].(map[string]interface{})["pattern"].(string))
+	assert.True(t, between.MatchString("10,19"))
+	assert.False(t, between.MatchString("10,19,20"))
+
+	operand := regexp.MustCompile(operandSchema["pattern"].(string))
+	assert.True(t, operand.MatchString("$data.price"))
+	assert.False(t, operand.MatchString("$$100"))
+
+	cidr := regexp.MustCompile(cidrSchema["anyOf"].([]interface{})[1].(map[string]interface{})["pattern"].(string))
+	assert.True(t, cidr.MatchString("127.0.0.1/24, 192.168.0.1/24"))
+	assert.True(t, cidr.MatchString("2001:db8::/32, ::1/128, ::ffff:10.0.0.0/104"))
+	assert.True(t, cidr.MatchString("127.0.0.1/24,fe80::/10"))
+	assert.False(t, cidr.MatchString("127.0.0.1"))
+	assert.False(t, cidr.MatchString("2001:db8::"))
+
+	// assignments
+	assignmentSchema := defs["assignment"].(map[string]interface{})["prefixItems"].([]interface{})[1]
+	assert.Equal(t, []interface{}{"=", "del"}, assignmentSchema.(map[string]interface{})["enum"])
+
+	content, err := MarshalJSONSchema()
+	assert.Nil(t, err)
+	assert.True(t, json.Valid(content))
+}
+
+func TestJSONSchemaValidate(t *testing.T) {
+	content, err := MarshalJSONSchema()
+	assert.Nil(t, err)
+
+	var schema map[string]interface{}
+	assert.Nil(t, json.Unmarshal(content, &schema))
+	validator := &schemaValidator{defs: schema["$defs"].(map[string]interface{})}
+
+	cases := []struct {
+		Name    string
+		JsonStr string
+		Valid   bool
+	}{
+		// README
+		{
+			Name:    "condition",
+			JsonStr: `{"filters":[{"id":"1","filter":[["city","in","上海,天津,北京"],["hour","between","10,19"],["name","=","golang"]]}]}`,
+			Valid:   true,
+		},
+		{
+			Name:    "assignments",
+			JsonStr: `{"filters":[{"id":"1","filter":[["city","in","上海,天津,北京"],["hour","between","10,19"],[["name","=","golang"],["desc","=","description"]]]}]}`,
+			Valid:   true,
+		},
+		{
+			Name:    "group",
+			JsonStr: `{"filters":[{"id":"1","filter":[["or","=>",[[["city","in","上海,天津,北京"],["hour","between","10,19"]],[["version","vgt","3.4.5"],["ip","iir","192.168.1.1/24"]]]],["name","=","golang"]]}]}`,
+			Valid:   true,
+		},
+		{
+			Name:    "segment",
+			JsonStr: `{"segments":{"vip_android":[["platform","=","android"],["data.level",">=",3]]},"filters":[{"id":"1","filter":[["segment","=","vip_android"],["hour","between","10,19"],["name","=","golang"]]}]}`,
+			Valid:   true,
+		},
+		{
+			Name:    "operand",
+			JsonStr: `{"filters":[{"id":"1","filter":[["data.balance",">=","$data.price"],["version","vgte","$data.min_version"],["name","=","golang"]]}]}`,
+			Valid:   true,
+		},
+		{
+			Name:    "ipv6",
+			JsonStr: `{"filters":[{"id":"1","filter":[["ip","iir","2001:db8::/32,::ffff:10.0.0.0/104"],["ip","!iir",["fe80::/10","192.168.1.1/24"]],["name","=","golang"]]}]}`,
+			Valid:   true,
+		},
+		// invalid
+		{
+			Name:    "unknown operation",
+			JsonStr: `{"filters":[{"id":"1","filter":[["city","like","上海"],["name","=","golang"]]}]}`,
+		},
+		{
+			Name:    "invalid between",
+			JsonStr: `{"filters":[{"id":"1","filter":[["hour","between","10,19,20"],["name","=","golang"]]}]}`,
+		},
+		{
+			Name:    "invalid cidr",
+			JsonStr: `{"filters":[{"id":"1","filter":[["ip","iir","2001:db8::"],["name","=","golang"]]}]}`,
+		},
+		{
+			Name:    "malformed condition",
+			JsonStr: `{"filters":[{"id":"1","filter":[["platfrom","=","android"],["hour","between","10,19"],["name","=","golang"]]}]}`,
+		},
+		{
+			Name:    "only one item",
+			JsonStr: `{"filters":[{"id":"1","filter":[["name","=","golang"]]}]}`,
+		},
+		{
+			Name:    "invalid assignment",
+			JsonStr: `{"filters":[{"id":"1","filter":[["hour","between","10,19"],["name","+=","golang"]]}]}`,
+		},
+	}
+
+	for _, tt := range cases {
+		var config interface{}
+		assert.Nil(t, json.Unmarshal([]byte(tt.JsonStr), &config), tt.Name)
+
+		err = validator.validate(schema, config, "#")
+		if !tt.Valid {
+			assert.NotNil(t, err, tt.Name)
+			continue
+		}
+		assert.Nil(t, err, tt.Name)
+
+		// 通过校验的配置同样可以构建
+		_, err = NewFilter(context.Background(), tt.JsonStr, nil)
+		assert.Nil(t, err, tt.Name)
+	}
+}
+
+// schemaValidator JSONSchema 中用到的关键字的最小实现，schema 使用了其它关键字时校验失败，需要在这里补充
+type schemaValidator struct {
+	defs map[string]interface{}
+}
+
+func (s *schemaValidator) validate(schema, value interface{}, path string) error {
+	switch schema := schema.(type) {
+	case bool:
+		if !schema {
+			return fmt.Errorf("%s: not allowed", path)
+		}
+		return nil
+	case map[string]interface{}:
+		for key, rule := range schema {
+			if err := s.keyword(schema, key, rule, value, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%s: invalid schema %v", path, schema)
+}
+
+func (s *schemaValidator) keyword(schema map[string]interface{}, key string, rule, value interface{}, path string) error {
+	var (
+		array, isArray   = value.([]interface{})
+		object, isObject = value.(map[string]interface{})
+		str, isString    = value.(string)
+		number, isNumber = value.(float64)
+	)
+
+	switch key {
+	case "$schema", "$defs", "title", "description", "then", "minContains", "maxContains":
+		// 注释或者跟其它关键字一起校验
+	case "$ref":
+		return s.validate(s.defs[strings.TrimPrefix(rule.(string), "#/$defs/")], value, path)
+	case "type":
+		types, ok := rule.([]interface{})
+		if !ok {
+			types = []interface{}{rule}
+		}
+		for _, t := range types {
+			if isType(t.(string), value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %v is not %v", path, value, rule)
+	case "enum":
+		for _, item := range rule.([]interface{}) {
+			if reflect.DeepEqual(item, value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %v is not one of %v", path, value, rule)
+	case "const":
+		if !reflect.DeepEqual(rule, value) {
+			return fmt.Errorf("%s: %v is not %v", path, value, rule)
+		}
+	case "pattern":
+		if isString && !regexp.MustCompile(rule.(string)).MatchString(str) {
+			return fmt.Errorf("%s: %q does not match %s", path, str, rule)
+		}
+	case "minLength":
+		if isString && float64(utf8.RuneCountInString(str)) < rule.(float64) {
+			return fmt.Errorf("%s: %q is too short", path, str)
+		}
+	case "minimum":
+		if isNumber && number < rule.(float64) {
+			return fmt.Errorf("%s: %v is less than %v", path, number, rule)
+		}
+	case "minItems":
+		if isArray && float64(len(array)) < rule.(float64) {
+			return fmt.Errorf("%s: less than %v items", path, rule)
+		}
+	case "maxItems":
+		if isArray && float64(len(array)) > rule.(float64) {
+			return fmt.Errorf("%s: more than %v items", path, rule)
+		}
+	case "prefixItems":
+		for index, item := range rule.([]interface{}) {
+			if isArray && index < len(array) {
+				if err := s.validate(item, array[index], fmt.Sprintf("%s/%d", path, index)); err != nil {
+					return err
+				}
+			}
+		}
+	case "items":
+		prefix, _ := schema["prefixItems"].([]interface{})
+		for index := len(prefix); isArray && index < len(array); index++ {
+			if err := s.validate(rule, array[index], fmt.Sprintf("%s/%d", path, index)); err != nil {
+				return err
+			}
+		}
+	case "contains":
+		if !isArray {
+			return nil
+		}
+		minimum, maximum := 1.0, math.Inf(1)
+		if v, ok := schema["minContains"]; ok {
+			minimum = v.(float64)
+		}
+		if v, ok := schema["maxContains"]; ok {
+			maximum = v.(float64)
+		}
+
+		matched := 0.0
+		for _, item := range array {
+			if s.validate(rule, item, path) == nil {
+				matched++
+			}
+		}
+		if matched < minimum || matched > maximum {
+			return fmt.Errorf("%s: %v items match contains", path, matched)
+		}
+	case "properties":
+		for name, property := range rule.(map[string]interface{}) {
+			if v, ok := object[name]; isObject && ok {
+				if err := s.validate(property, v, path+"/"+name); err != nil {
+					return err
+				}
+			}
+		}
+	case "additionalProperties":
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, v := range object {
+			if _, ok := properties[name]; !ok {
+				if err := s.validate(rule, v, path+"/"+name); err != nil {
+					return err
+				}
+			}
+		}
+	case "required":
+		for _, name := range rule.([]interface{}) {
+			if _, ok := object[name.(string)]; isObject && !ok {
+				return fmt.Errorf("%s: missing property %s", path, name)
+			}
+		}
+	case "anyOf":
+		for _, item := range rule.([]interface{}) {
+			if s.validate(item, value, path) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %v does not match any schema", path, value)
+	case "allOf":
+		for _, item := range rule.([]interface{}) {
+			if err := s.validate(item, value, path); err != nil {
+				return err
+			}
+		}
+	case "not":
+		if s.validate(rule, value, path) == nil {
+			return fmt.Errorf("%s: %v should not match", path, value)
+		}
+	case "if":
+		if then, ok := schema["then"]; ok && s.validate(rule, value, path) == nil {
+			return s.validate(then, value, path)
+		}
+	default:
+		return fmt.Errorf("%s: unsupported keyword %s", path, key)
+	}
+	return nil
+}
+
+func isType(name string, value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return name == "null"
+	case bool:
+		return name == "boolean"
+	case string:
+		return name == "string"
+	case float64:
+		return name == "number" || (name == "integer" && v == math.Trunc(v))
+	case []interface{}:
+		return name == "array"
+	case map[string]interface{}:
+		return name == "object"
+	}
+	return false
+}
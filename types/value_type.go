@@ -0,0 +1,77 @@
+package types
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ValueType 变量取值以及比较值的类型，用于构建条件时检查操作是否适用，TypeUnknown 时只在执行时检查
+type ValueType int
+
+const (
+	TypeUnknown ValueType = iota
+	TypeNumber            // 数字或者数字形式的字符串
+	TypeString
+	TypeVersion // 版本号，例如 3.4.5、v1.0，也可以是数字
+	TypeIP      // ip或者CIDR
+	TypeList    // 数组或者逗号分隔的字符串
+	TypeRegex   // 正则，例如 /^ios$/，不是 /.../ 形式时为包含的字符串
+)
+
+var valueTypeNames = map[ValueType]string{
+	TypeUnknown: "unknown",
+	TypeNumber:  "number",
+	TypeString:  "string",
+	TypeVersion: "version",
+	TypeIP:      "ip",
+	TypeList:    "list",
+	TypeRegex:   "regex",
+}
+
+func (t ValueType) String() string {
+	if name, ok := valueTypeNames[t]; ok {
+		return name
+	}
+	return valueTypeNames[TypeUnknown]
+}
+
+var versionPattern = regexp.MustCompile(`^[vV]?[0-9]+(\.[0-9]+)*$`)
+
+// Check 配置中的值是否为类型t，TypeUnknown 接受任意值
+func (t ValueType) Check(value interface{}) bool {
+	switch t {
+	case TypeNumber:
+		if IsNumber(value) {
+			return true
+		}
+		str, ok := value.(string)
+		if !ok {
+			return false
+		}
+		_, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+		return err == nil
+	case TypeString, TypeRegex:
+		return IsString(value)
+	case TypeVersion:
+		if IsNumber(value) {
+			return true
+		}
+		str, ok := value.(string)
+		return ok && versionPattern.MatchString(strings.TrimSpace(str))
+	case TypeIP:
+		str, ok := value.(string)
+		if !ok {
+			return false
+		}
+		if net.ParseIP(str) != nil {
+			return true
+		}
+		_, _, err := net.ParseCIDR(str)
+		return err == nil
+	case TypeList:
+		return IsArray(value) || IsString(value) || IsNumber(value)
+	}
+	return true
+}
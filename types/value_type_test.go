@@ -0,0 +1,42 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueType(t *testing.T) {
+	cases := []struct {
+		Type  ValueType
+		Value interface{}
+		OK    bool
+	}{
+		{Type: TypeNumber, Value: 10, OK: true},
+		{Type: TypeNumber, Value: " 1.5", OK: true},
+		{Type: TypeNumber, Value: "a", OK: false},
+		{Type: TypeNumber, Value: []interface{}{1}, OK: false},
+		{Type: TypeString, Value: "a", OK: true},
+		{Type: TypeString, Value: 1, OK: false},
+		{Type: TypeVersion, Value: "3.4.5", OK: true},
+		{Type: TypeVersion, Value: "v1.0", OK: true},
+		{Type: TypeVersion, Value: float64(3), OK: true},
+		{Type: TypeVersion, Value: "3.x", OK: false},
+		{Type: TypeVersion, Value: "", OK: false},
+		{Type: TypeIP, Value: "192.168.1.1", OK: true},
+		{Type: TypeIP, Value: "192.168.1.1/24", OK: true},
+		{Type: TypeIP, Value: "a", OK: false},
+		{Type: TypeList, Value: []interface{}{"a"}, OK: true},
+		{Type: TypeList, Value: "a,b", OK: true},
+		{Type: TypeList, Value: nil, OK: false},
+		{Type: TypeRegex, Value: "/^ios$/", OK: true},
+		{Type: TypeUnknown, Value: nil, OK: true},
+	}
+
+	for index, tt := range cases {
+		assert.Equal(t, tt.OK, tt.Type.Check(tt.Value), index)
+	}
+
+	assert.Equal(t, "version", TypeVersion.String())
+	assert.Equal(t, "unknown", ValueType(100).String())
+}
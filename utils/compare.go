@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/airunny/filter/types"
+)
+
+// Comparer 跟 ObjectCompare(value, operand) 结果一致，操作数的类型以及数字只在编译时转换一次
+type Comparer func(value interface{}) int
+
+func floatCompare(a, b float64) int {
+	if FloatEquals(a, b) {
+		return 0
+	}
+
+	if a-b > 0 {
+		return 1
+	}
+	return -1
+}
+
+func CompileCompare(operand interface{}) Comparer {
+	switch types.GetFilterType(operand) {
+	case types.NUMBER, types.BOOL:
+		// 操作数为数字时都按照数字比较
+		number := types.GetFloat(operand)
+		return func(value interface{}) int {
+			return floatCompare(types.GetFloat(value), number)
+		}
+	case types.STRING:
+		var (
+			str    = types.GetString(operand)
+			number = types.GetFloat(operand)
+		)
+		return func(value interface{}) int {
+			switch v := value.(type) {
+			case string:
+				return strings.Compare(v, str)
+			case float64:
+				return floatCompare(v, number)
+			case int:
+				return floatCompare(float64(v), number)
+			case bool:
+				return floatCompare(types.GetFloat(v), number)
+			}
+			return ObjectCompare(value, operand)
+		}
+	}
+
+	return func(value interface{}) int {
+		return ObjectCompare(value, operand)
+	}
+}
+
+// Set 作为比较值的集合，例如 ValueSet 以及 "@list:" 引用的名单
+type Set interface {
+	Contains(value interface{}) bool
+}
+
+// ValueSet 预先建立的集合，Contains 跟逐个使用 ObjectCompare 比较是否相等的结果一致
+type ValueSet struct {
+	values  []interface{}
+	strings map[string]struct{} // 字符串变量按照字符串比较的元素
+	numbers []float64           // 字符串变量按照数字比较的元素（数字以及布尔）
+	floats  []float64           // 数字变量跟所有元素都按照数字比较
+}
+
+func NewValueSet(values []interface{}) *ValueSet {
+	set := &ValueSet{
+		values:  values,
+		strings: make(map[string]struct{}, len(values)),
+		floats:  make([]float64, 0, len(values)),
+	}
+
+	for _, value := range values {
+		set.floats = append(set.floats, types.GetFloat(value))
+		switch types.GetFilterType(value) {
+		case types.NUMBER, types.BOOL:
+			set.numbers = append(set.numbers, types.GetFloat(value))
+		default:
+			set.strings[types.GetString(value)] = struct{}{}
+		}
+	}
+
+	sort.Float64s(set.numbers)
+	sort.Float64s(set.floats)
+	return set
+}
+
+func (s *ValueSet) Values() []interface{} { return s.values }
+func (s *ValueSet) Len() int              { return len(s.values) }
+
+// String 跟准备之前的数组一致，用于 hook 等输出执行过程
+func (s *ValueSet) String() string { return fmt.Sprint(s.values) }
+
+func (s *ValueSet) Contains(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		if _, ok := s.strings[v]; ok {
+			return true
+		}
+		return len(s.numbers) > 0 && searchFloat(s.numbers, types.GetFloat(v))
+	case float64, float32, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, bool:
+		return searchFloat(s.floats, types.GetFloat(v))
+	}
+
+	for _, element := range s.values {
+		if ObjectCompare(value, element) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// searchFloat 在有序数组中查找跟 value 相等（误差小于 EPSILON）的元素
+func searchFloat(values []float64, value float64) bool {
+	for i := sort.SearchFloat64s(values, value-EPSILON); i < len(values) && values[i] < value+EPSILON; i++ {
+		if FloatEquals(values[i], value) {
+			return true
+		}
+	}
+	return false
+}
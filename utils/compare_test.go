@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var compareValues = []interface{}{
+	nil, "", "a", "b", "1", "1.0", "2", " 1", "abc", "[1]",
+	0, 1, 2, -1, 1.5, float32(1), int64(2), uint8(1), 0.000000001,
+	true, false,
+	[]interface{}{1}, map[string]interface{}{"a": 1},
+}
+
+func TestCompileCompare(t *testing.T) {
+	for _, operand := range compareValues {
+		compare := CompileCompare(operand)
+		for _, value := range compareValues {
+			assert.Equal(t, ObjectCompare(value, operand), compare(value), fmt.Sprintf("%#v %#v", value, operand))
+		}
+	}
+}
+
+func TestValueSet(t *testing.T) {
+	sets := [][]interface{}{
+		{"a", "b"},
+		{"1", 2},
+		{1, 2.5, true},
+		{nil, "a"},
+		{[]interface{}{1}, "x"},
+		{map[string]interface{}{"a": 1}},
+		{1.000000001, "abc"},
+		{0},
+	}
+
+	for _, values := range sets {
+		set := NewValueSet(values)
+		assert.Equal(t, len(values), set.Len())
+		for _, value := range compareValues {
+			expected := false
+			for _, element := range values {
+				if ObjectCompare(value, element) == 0 {
+					expected = true
+				}
+			}
+			assert.Equal(t, expected, set.Contains(value), fmt.Sprintf("%#v in %#v", value, values))
+		}
+	}
+}
+
+func BenchmarkValueSet(b *testing.B) {
+	values := make([]interface{}, 0, 5000)
+	for i := 0; i < 5000; i++ {
+		values = append(values, strconv.Itoa(100000+i))
+	}
+	set := NewValueSet(values)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		set.Contains("104999")
+		set.Contains(104999)
+	}
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Path 预先解析的取值路径，例如 user.works.0.name，结构体字段的下标按类型缓存，取值时不再比较字段名以及json tag
+type Path struct {
+	segs []segment
+}
+
+type segment struct {
+	name  string
+	index int // name 可以转换为数字时为数组下标，否则为-1
+}
+
+type fieldKey struct {
+	typ  reflect.Type
+	name string
+}
+
+var (
+	paths  sync.Map // key -> *Path
+	fields sync.Map // fieldKey -> []int，字段不存在时为nil
+)
+
+// ParsePath 解析后的路径会被缓存，相同的key返回同一个 Path
+func ParsePath(key string) *Path {
+	if path, ok := paths.Load(key); ok {
+		return path.(*Path)
+	}
+
+	path := &Path{}
+	trimmed := strings.TrimSpace(key)
+	if trimmed != "." && trimmed != "" {
+		for _, seg := range strings.Split(trimmed, ".") {
+			seg = strings.TrimSpace(seg)
+			index, err := strconv.Atoi(seg)
+			if err != nil {
+				index = -1
+			}
+			path.segs = append(path.segs, segment{name: seg, index: index})
+		}
+	}
+
+	actual, _ := paths.LoadOrStore(key, path)
+	return actual.(*Path)
+}
+
+// FieldIndex 结构体中名字或者json tag为name的字段下标，结果按类型缓存
+func FieldIndex(typ reflect.Type, name string) ([]int, bool) {
+	if typ.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	key := fieldKey{typ: typ, name: name}
+	if index, ok := fields.Load(key); ok {
+		return index.([]int), index.([]int) != nil
+	}
+
+	var index []int
+	if f, ok := typ.FieldByName(name); ok {
+		index = f.Index
+	} else {
+		for i := 0; i < typ.NumField(); i++ {
+			if typ.Field(i).Tag.Get("json") == name {
+				index = []int{i}
+				break
+			}
+		}
+	}
+
+	fields.Store(key, index)
+	return index, index != nil
+}
+
+func (p *Path) Get(data interface{}) (interface{}, bool) {
+	for index := 0; index < len(p.segs); {
+		seg := p.segs[index]
+		if data == nil {
+			return nil, false
+		}
+
+		switch reflect.TypeOf(data).Kind() {
+		case reflect.Map:
+			v, ok := data.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+
+			if data, ok = v[seg.name]; !ok {
+				return nil, false
+			}
+		case reflect.Array, reflect.Slice:
+			value := reflect.ValueOf(data)
+			if seg.index < 0 || seg.index >= value.Len() {
+				return nil, false
+			}
+			data = value.Index(seg.index).Interface()
+		case reflect.Struct:
+			value := reflect.ValueOf(data)
+			fieldIndex, ok := FieldIndex(value.Type(), seg.name)
+			if !ok {
+				return nil, false
+			}
+
+			f, err := value.FieldByIndexErr(fieldIndex)
+			if err != nil {
+				return nil, false
+			}
+			data = f.Interface()
+		case reflect.Ptr:
+			value := reflect.ValueOf(data)
+			if value.IsNil() {
+				return nil, false
+			}
+			data = value.Elem().Interface()
+			continue
+		default:
+			return nil, false
+		}
+		index++
+	}
+	return data, true
+}
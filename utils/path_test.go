@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Base struct {
+	ID int `json:"id"`
+}
+
+type Account struct {
+	*Base
+	User  User   `json:"user"`
+	Email string `json:"email,omitempty"`
+}
+
+func TestParsePath(t *testing.T) {
+	path := ParsePath("user. works .0")
+	assert.Equal(t, []segment{{name: "user", index: -1}, {name: "works", index: -1}, {name: "0", index: 0}}, path.segs)
+	assert.Same(t, path, ParsePath("user. works .0"))
+
+	assert.Len(t, ParsePath("").segs, 0)
+	assert.Len(t, ParsePath(" . ").segs, 0)
+}
+
+func TestFieldIndex(t *testing.T) {
+	cases := []struct {
+		Type     reflect.Type
+		Name     string
+		Expected []int
+		OK       bool
+	}{
+		{
+			Type:     reflect.TypeOf(User{}),
+			Name:     "Name",
+			Expected: []int{0},
+			OK:       true,
+		},
+		{
+			Type:     reflect.TypeOf(User{}),
+			Name:     "id_card",
+			Expected: []int{2},
+			OK:       true,
+		},
+		{
+			Type: reflect.TypeOf(User{}),
+			Name: "unknown",
+		},
+		{
+			Type:     reflect.TypeOf(Account{}),
+			Name:     "ID",
+			Expected: []int{0, 0},
+			OK:       true,
+		},
+		{
+			Type:     reflect.TypeOf(Account{}),
+			Name:     "email,omitempty",
+			Expected: []int{2},
+			OK:       true,
+		},
+		{
+			Type: reflect.TypeOf(&User{}),
+			Name: "Name",
+		},
+		{
+			Type: reflect.TypeOf(map[string]interface{}{}),
+			Name: "Name",
+		},
+	}
+
+	for index, v := range cases {
+		// 第二次使用缓存的结果
+		for i := 0; i < 2; i++ {
+			ret, ok := FieldIndex(v.Type, v.Name)
+			assert.Equal(t, v.OK, ok, index)
+			assert.Equal(t, v.Expected, ret, index)
+		}
+	}
+}
+
+func TestPathGet(t *testing.T) {
+	var nilAccount *Account
+	account := Account{Base: &Base{ID: 1}, User: User{Name: "zhangsan"}}
+
+	cases := []struct {
+		Data     interface{}
+		Key      string
+		Expected interface{}
+		OK       bool
+	}{
+		{
+			Data:     account,
+			Key:      "ID",
+			Expected: 1,
+			OK:       true,
+		},
+		{
+			Data:     &account,
+			Key:      "user.name",
+			Expected: "zhangsan",
+			OK:       true,
+		},
+		{
+			Data: Account{},
+			Key:  "ID",
+		},
+		{
+			Data: nilAccount,
+			Key:  "user",
+		},
+		{
+			Data: map[string]interface{}{"account": account},
+			Key:  "account.User.Works.-1",
+		},
+	}
+
+	for index, v := range cases {
+		ret, ok := ParsePath(v.Key).Get(v.Data)
+		assert.Equal(t, v.OK, ok, index)
+		assert.Equal(t, v.Expected, ret, index)
+	}
+}
+
+func BenchmarkGetObjectValueByKey(b *testing.B) {
+	data := map[string]interface{}{
+		"temp": Temp{User: User{Works: []Work{{Name: "111"}}}},
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GetObjectValueByKey(data, "temp.user.works.0.name")
+	}
+}
@@ -21,83 +21,27 @@ func FloatEquals(a, b float64) bool {
 	return false
 }
 
+// GetObjectValueByKey 按路径获取值，参见 Path
 func GetObjectValueByKey(data interface{}, key string) (interface{}, bool) {
-	key = strings.TrimSpace(key)
-	if key == "." || key == "" {
-		return data, true
-	}
-
-	segs := strings.Split(key, ".")
-	if len(segs) <= 0 {
-		return data, true
-	}
-
-	for index := 0; index < len(segs); {
-		seg := segs[index]
-		if data == nil {
-			return nil, false
-		}
-
-		seg = strings.TrimSpace(seg)
-		switch reflect.TypeOf(data).Kind() {
-		case reflect.Map:
-			if v, ok := data.(map[string]interface{}); ok {
-				if data, ok = v[seg]; !ok {
-					return nil, false
-				}
-			} else {
-				return nil, false
-			}
-		case reflect.Array, reflect.Slice:
-			value := reflect.ValueOf(data)
-			if i, err := strconv.Atoi(seg); err != nil {
-				return nil, false
-			} else if i < 0 || i >= value.Len() {
-				return nil, false
-			} else {
-				data = value.Index(i).Interface()
-			}
-		case reflect.Struct:
-			var (
-				value     = reflect.ValueOf(data)
-				valueType = reflect.TypeOf(data)
-			)
-
-			f := value.FieldByName(seg)
-			if !f.IsValid() {
-				existsJson := false
-				for i := 0; i < valueType.NumField(); i++ {
-					tf := valueType.Field(i)
-					if tf.Tag.Get("json") == seg {
-						existsJson = true
-						f = value.Field(i)
-						break
-					}
-				}
-
-				if !existsJson {
-					return nil, false
-				}
-			}
-			data = f.Interface()
-		case reflect.Ptr:
-			data = reflect.ValueOf(data).Elem().Interface()
-			continue
-		default:
-			return nil, false
-		}
-		index++
-	}
-	return data, true
+	return ParsePath(key).Get(data)
 }
 
 func ParseTargetArrayValue(value interface{}) []interface{} {
+	// in、any 等操作准备之后的比较值
+	if set, ok := value.(*ValueSet); ok {
+		return set.Values()
+	}
+
 	var target []interface{}
 	switch types.GetFilterType(value) {
 	case types.STRING:
-		err := json.Unmarshal([]byte(value.(string)), &target)
-		if err == nil {
-			return target
+		// 只有json数组以及null可以解析为数组，其它字符串跳过json解析
+		trimmed := strings.TrimSpace(value.(string))
+		if strings.HasPrefix(trimmed, "[") || trimmed == "null" {
+			err := json.Unmarshal([]byte(trimmed), &target)
+			if err == nil {
+				return target
+			}
 		}
 
 		targetValue := value.(string)
@@ -106,7 +50,15 @@ func ParseTargetArrayValue(value interface{}) []interface{} {
 			target = append(target, strings.TrimSpace(v))
 		}
 	case types.ARRAY:
-		target = value.([]interface{})
+		if values, ok := value.([]interface{}); ok {
+			return values
+		}
+
+		// []string 等其它类型的切片以及数组逐个转换
+		elements := reflect.Indirect(reflect.ValueOf(value))
+		for i := 0; i < elements.Len(); i++ {
+			target = append(target, elements.Index(i).Interface())
+		}
 	default:
 		target = append(target, value)
 	}
@@ -264,8 +216,15 @@ func VersionCompare(compare, compared string) int {
 type IPRange struct {
 	start int
 	end   int
+	ipv6  bool
 }
 
+func (r IPRange) Start() int { return r.start }
+func (r IPRange) End() int   { return r.end }
+
+// IsIPv4 是否为ipv4的区间，Start、End 只对ipv4的区间有意义
+func (r IPRange) IsIPv4() bool { return !r.ipv6 }
+
 func (r IPRange) String() string {
 	return fmt.Sprintf("%v-%v", IntToIP(r.start), IntToIP(r.end))
 }
@@ -296,6 +255,7 @@ func IPRanges(ipCIDR ...string) ([]IPRange, error) {
 		ranges = append(ranges, IPRange{
 			start: ToInt(ipNet.IP),
 			end:   ToInt(BytesOR(ipNet.IP, BytesNOT(ipNet.Mask))),
+			ipv6:  ipNet.IP.To4() == nil,
 		})
 	}
 	return ranges, nil
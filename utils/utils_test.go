@@ -352,6 +352,26 @@ func TestGetObjectValueByKey(t *testing.T) {
 	}
 }
 
+func TestParseTargetArrayValue(t *testing.T) {
+	cases := []struct {
+		Value    interface{}
+		Expected []interface{}
+	}{
+		{Value: "a, b", Expected: []interface{}{"a", "b"}},
+		{Value: `["a",1]`, Expected: []interface{}{"a", 1.0}},
+		{Value: []interface{}{"a", 1}, Expected: []interface{}{"a", 1}},
+		{Value: []string{"a", "b"}, Expected: []interface{}{"a", "b"}},
+		{Value: &[]int{1, 2}, Expected: []interface{}{1, 2}},
+		{Value: [2]float64{1, 2}, Expected: []interface{}{1.0, 2.0}},
+		{Value: 1, Expected: []interface{}{1}},
+		{Value: NewValueSet([]interface{}{"a", 1}), Expected: []interface{}{"a", 1}},
+	}
+
+	for index, v := range cases {
+		assert.Equal(t, v.Expected, ParseTargetArrayValue(v.Value), index)
+	}
+}
+
 func TestObjectCompare(t *testing.T) {
 	cases := []struct {
 		compare  interface{}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/location"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/variables"
 	"github.com/airunny/filter/variables/ip"
 )
@@ -19,13 +20,13 @@ const (
 func init() {
 	variables.Register(variables.NewSimpleVariable(&Area{
 		name: CountryName,
-	}))
+	}, variables.WithType(types.TypeString)))
 	variables.Register(variables.NewSimpleVariable(&Area{
 		name: ProvinceName,
-	}))
+	}, variables.WithType(types.TypeString)))
 	variables.Register(variables.NewSimpleVariable(&Area{
 		name: CityName,
-	}))
+	}, variables.WithType(types.TypeString)))
 }
 
 // Area 从IP中解析获取country信息
@@ -33,8 +34,10 @@ type Area struct {
 	name string
 }
 
-func (s *Area) Cacheable() bool { return true }
-func (s *Area) Name() string    { return s.name }
+func (s *Area) Cacheable() bool   { return true }
+func (s *Area) Cost() int         { return variables.CostModerate }
+func (s *Area) Depends() []string { return []string{ip.Name} }
+func (s *Area) Name() string      { return s.name }
 func (s *Area) Value(ctx context.Context, data interface{}, cache *cache.Cache) (interface{}, error) {
 	ipVariable, ok := variables.Get(ip.Name)
 	if !ok {
@@ -2,7 +2,6 @@ package area
 
 import (
 	"context"
-	"errors"
 	"reflect"
 	"testing"
 
@@ -29,7 +28,7 @@ func TestArea(t *testing.T) {
 		{
 			name: CityName,
 			ctx:  ctx,
-			err:  errors.New("ip not found in context"),
+			err:  variables.Missing("ip not found in context"),
 		},
 		// success
 		{
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/variables"
 	"github.com/liyanbing/calc/compute"
 	calcVariables "github.com/liyanbing/calc/variables"
@@ -22,6 +23,10 @@ func (*calcBuilder) Name() string {
 	return Name
 }
 
+func (*calcBuilder) Type(string) types.ValueType {
+	return types.TypeNumber
+}
+
 func (*calcBuilder) Build(name string) variables.Variable {
 	expr := strings.TrimPrefix(name, Name)
 	if expr == "" {
@@ -40,6 +45,7 @@ type Calculator struct {
 }
 
 func (s *Calculator) Cacheable() bool { return false }
+func (s *Calculator) Cost() int       { return variables.CostExpensive }
 func (s *Calculator) Name() string    { return s.name }
 func (s *Calculator) Value(ctx context.Context, data interface{}, cache *cache.Cache) (interface{}, error) {
 	return compute.Evaluate(s.expr, calcVariables.ValueSourceFunc(func(key string) float64 {
@@ -2,17 +2,17 @@ package channel
 
 import (
 	"context"
-	"errors"
 
 	"github.com/airunny/filter/cache"
 	filterContext "github.com/airunny/filter/context"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/variables"
 )
 
 const Name = "channel"
 
 func init() {
-	variables.Register(variables.NewSimpleVariable(&Channel{}))
+	variables.Register(variables.NewSimpleVariable(&Channel{}, variables.WithType(types.TypeString)))
 }
 
 // Channel 渠道
@@ -23,7 +23,7 @@ func (s *Channel) Cacheable() bool { return true }
 func (s *Channel) Value(ctx context.Context, _ interface{}, _ *cache.Cache) (interface{}, error) {
 	value, ok := filterContext.FromChannel(ctx)
 	if !ok {
-		return nil, errors.New("channel not found in context")
+		return nil, variables.Missing("channel not found in context")
 	}
 	return value, nil
 }
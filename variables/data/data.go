@@ -2,7 +2,6 @@ package data
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
 	"github.com/airunny/filter/cache"
@@ -30,25 +29,28 @@ func (*dataBuilder) Build(name string) variables.Variable {
 	return &Data{
 		name: name,
 		key:  key,
+		path: utils.ParsePath(key),
 	}
 }
 
-// Data 获取传递的data中的值
+// Data 获取传递的data中的值，缓存在 cache.ScopeData 中，执行赋值之后失效
 type Data struct {
 	name string
 	key  string
+	path *utils.Path
 }
 
-func (s *Data) Name() string    { return s.name }
-func (s *Data) Cacheable() bool { return false }
+func (s *Data) Name() string       { return s.name }
+func (s *Data) Cacheable() bool    { return true }
+func (s *Data) Scope() cache.Scope { return cache.ScopeData }
 func (s *Data) Value(ctx context.Context, data interface{}, _ *cache.Cache) (interface{}, error) {
 	if valuer, ok := data.(variables.Valuer); ok {
 		return valuer.Value(ctx, s.key)
 	}
 
-	value, ok := utils.GetObjectValueByKey(data, s.key)
+	value, ok := s.path.Get(data)
 	if !ok {
-		return nil, fmt.Errorf("%s not found in data", s.name)
+		return nil, variables.Missing(s.name + " not found in data")
 	}
 	return value, nil
 }
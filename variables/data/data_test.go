@@ -285,7 +285,7 @@ func TestData(t *testing.T) {
 			},
 			Key:  "data.user.User.Teacher",
 			Want: nil,
-			Err:  fmt.Errorf("%s not found in data", "data.user.User.Teacher"),
+			Err:  variables.Missing("data.user.User.Teacher not found in data"),
 		},
 		// array
 		{
@@ -503,7 +503,7 @@ func TestData(t *testing.T) {
 			},
 			Key:  "data.5",
 			Want: nil,
-			Err:  fmt.Errorf("%s not found in data", "data.5"),
+			Err:  variables.Missing("data.5 not found in data"),
 		},
 		// struct
 		{
@@ -633,7 +633,7 @@ func TestData(t *testing.T) {
 			},
 			Key:  "data.Age",
 			Want: nil,
-			Err:  fmt.Errorf("%s not found in data", "data.Age"),
+			Err:  variables.Missing("data.Age not found in data"),
 		},
 	}
 
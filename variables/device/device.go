@@ -2,17 +2,17 @@ package device
 
 import (
 	"context"
-	"errors"
 
 	"github.com/airunny/filter/cache"
 	filterContext "github.com/airunny/filter/context"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/variables"
 )
 
 const Name = "device"
 
 func init() {
-	variables.Register(variables.NewSimpleVariable(&Device{}))
+	variables.Register(variables.NewSimpleVariable(&Device{}, variables.WithType(types.TypeString)))
 }
 
 // Device 设备ID
@@ -23,7 +23,7 @@ func (s *Device) Cacheable() bool { return true }
 func (s *Device) Value(ctx context.Context, _ interface{}, _ *cache.Cache) (interface{}, error) {
 	device, ok := filterContext.FromDevice(ctx)
 	if !ok {
-		return nil, errors.New("device not found in context")
+		return nil, variables.Missing("device not found in context")
 	}
 	return device, nil
 }
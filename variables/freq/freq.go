@@ -6,6 +6,7 @@ import (
 
 	"github.com/airunny/filter/cache"
 	"github.com/airunny/filter/variables"
+	"github.com/airunny/filter/variables/uid"
 )
 
 const Name = "freq."
@@ -37,8 +38,10 @@ type Freq struct {
 	key  string
 }
 
-func (s *Freq) Name() string    { return s.name }
-func (s *Freq) Cacheable() bool { return false }
+func (s *Freq) Name() string      { return s.name }
+func (s *Freq) Cacheable() bool   { return false }
+func (s *Freq) Cost() int         { return variables.CostExpensive }
+func (s *Freq) Depends() []string { return []string{uid.Name} }
 func (s *Freq) Value(ctx context.Context, data interface{}, _ *cache.Cache) (interface{}, error) {
 	if getter, ok := data.(variables.Frequency); ok {
 		return getter.FrequencyValue(ctx, s.key)
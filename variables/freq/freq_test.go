@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/airunny/filter/cache"
+	filterContext "github.com/airunny/filter/context"
 	_ "github.com/airunny/filter/location"
 	"github.com/airunny/filter/variables"
 	"github.com/stretchr/testify/assert"
@@ -65,3 +66,32 @@ func TestData(t *testing.T) {
 		}
 	}
 }
+
+type countData struct {
+	calls int
+}
+
+func (s *countData) FrequencyValue(_ context.Context, key string) (interface{}, error) {
+	s.calls++
+	return s.calls, nil
+}
+
+func TestShared(t *testing.T) {
+	variables.Share(Name, cache.NewShared())
+	defer variables.Share(Name, nil)
+
+	v, ok := variables.Get("freq.view")
+	assert.True(t, ok)
+
+	var (
+		data  = &countData{}
+		alice = filterContext.WithUserID(context.Background(), "alice")
+		bob   = filterContext.WithUserID(context.Background(), "bob")
+	)
+	for _, ctx := range []context.Context{alice, alice, bob, alice} {
+		_, err := variables.GetValue(ctx, v, data, cache.NewCache())
+		assert.Nil(t, err)
+	}
+	// 按照uid缓存频次
+	assert.Equal(t, 2, data.calls)
+}
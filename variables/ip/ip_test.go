@@ -2,7 +2,6 @@ package ip
 
 import (
 	"context"
-	"errors"
 	"reflect"
 	"testing"
 
@@ -24,7 +23,7 @@ func TestIP(t *testing.T) {
 		// err
 		{
 			ctx: ctx,
-			err: errors.New("ip not found in context"),
+			err: variables.Missing("ip not found in context"),
 		},
 		// success
 		{
@@ -0,0 +1,120 @@
+package variables
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMissing 变量在上下文或者data中不存在，变量返回的错误可以使用 errors.Is 判断，参见 Missing
+var ErrMissing = errors.New("variable missing")
+
+type missingError string
+
+func (e missingError) Error() string        { return string(e) }
+func (e missingError) Is(target error) bool { return target == ErrMissing }
+
+// Missing 变量不存在时返回的错误，错误信息为 msg，errors.Is(err, ErrMissing) 为true
+func Missing(msg string) error {
+	return missingError(msg)
+}
+
+type MissingAction int
+
+const (
+	MissingError   MissingAction = iota // 返回错误，中断执行，默认的策略
+	MissingFalse                        // 条件不成立
+	MissingTrue                         // 条件成立
+	MissingUnknown                      // 条件的结果未知，分组按照三值逻辑处理，最终结果未知时filter不成立
+	MissingDefault                      // 使用 MissingPolicy.Default 作为变量的值
+)
+
+var missingActions = map[string]MissingAction{
+	"error":   MissingError,
+	"false":   MissingFalse,
+	"true":    MissingTrue,
+	"unknown": MissingUnknown,
+}
+
+// MissingPolicy 变量不存在时条件的处理方式
+type MissingPolicy struct {
+	Action  MissingAction
+	Default interface{} // Action 为 MissingDefault 时使用的值
+}
+
+// ParseMissingPolicy 解析 error、false、true、unknown 以及 =默认值，默认值可以是JSON，否则为字符串，例如 =0、="1.0"、=1.0.0。
+// 空字符串为 unknown，对应条件中的 "version?"
+func ParseMissingPolicy(str string) (MissingPolicy, error) {
+	if str == "" {
+		return MissingPolicy{Action: MissingUnknown}, nil
+	}
+
+	if action, ok := missingActions[str]; ok {
+		return MissingPolicy{Action: action}, nil
+	}
+
+	if !strings.HasPrefix(str, "=") {
+		return MissingPolicy{}, fmt.Errorf("unknown missing policy [%s]", str)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(str[1:]), &value); err != nil {
+		value = str[1:]
+	}
+	return MissingPolicy{Action: MissingDefault, Default: value}, nil
+}
+
+func (p MissingPolicy) String() string {
+	for name, action := range missingActions {
+		if action == p.Action {
+			return name
+		}
+	}
+
+	// 不是JSON的字符串原样输出，例如 =1.0.0
+	if str, ok := p.Default.(string); ok && !json.Valid([]byte(str)) {
+		return "=" + str
+	}
+
+	value, err := json.Marshal(p.Default)
+	if err != nil {
+		return fmt.Sprintf("=%v", p.Default)
+	}
+	return "=" + string(value)
+}
+
+// SetMissing 设置名字为 name（以.结尾时为前缀，例如 data.）的变量不存在时的策略，name 为空时设置所有变量默认的策略，
+// 需要在构建过滤器之前调用，条件中的变量名带有?后缀时以条件中的为准，例如：
+//
+//	variables.SetMissing("version", variables.MissingPolicy{Action: variables.MissingFalse})
+func SetMissing(name string, policy MissingPolicy) {
+	defaultFactory.SetMissing(name, policy)
+}
+
+// MissingOf 名字为 name 的变量不存在时的策略
+func MissingOf(name string) MissingPolicy {
+	return defaultFactory.MissingOf(name)
+}
+
+func (s *factory) SetMissing(name string, policy MissingPolicy) {
+	s.Lock()
+	defer s.Unlock()
+	s.missing[name] = policy
+}
+
+func (s *factory) MissingOf(name string) MissingPolicy {
+	s.Lock()
+	defer s.Unlock()
+
+	if policy, ok := s.missing[name]; ok {
+		return policy
+	}
+
+	if index := strings.Index(name, "."); index >= 0 {
+		if policy, ok := s.missing[name[:index+1]]; ok {
+			return policy
+		}
+	}
+	return s.missing[""]
+}
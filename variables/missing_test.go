@@ -0,0 +1,61 @@
+package variables
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissing(t *testing.T) {
+	err := Missing("version not found in context")
+	assert.EqualError(t, err, "version not found in context")
+	assert.True(t, errors.Is(err, ErrMissing))
+	assert.True(t, errors.Is(fmt.Errorf("wrapped: %w", err), ErrMissing))
+	assert.False(t, errors.Is(errors.New("version not found in context"), ErrMissing))
+}
+
+func TestParseMissingPolicy(t *testing.T) {
+	cases := []struct {
+		Str      string
+		Expected MissingPolicy
+		String   string
+		Err      string
+	}{
+		{Str: "", Expected: MissingPolicy{Action: MissingUnknown}, String: "unknown"},
+		{Str: "error", Expected: MissingPolicy{Action: MissingError}, String: "error"},
+		{Str: "false", Expected: MissingPolicy{Action: MissingFalse}, String: "false"},
+		{Str: "true", Expected: MissingPolicy{Action: MissingTrue}, String: "true"},
+		{Str: "unknown", Expected: MissingPolicy{Action: MissingUnknown}, String: "unknown"},
+		{Str: "=0", Expected: MissingPolicy{Action: MissingDefault, Default: float64(0)}, String: "=0"},
+		{Str: `="1.0"`, Expected: MissingPolicy{Action: MissingDefault, Default: "1.0"}, String: `="1.0"`},
+		{Str: "=1.0.0", Expected: MissingPolicy{Action: MissingDefault, Default: "1.0.0"}, String: "=1.0.0"},
+		{Str: "=", Expected: MissingPolicy{Action: MissingDefault, Default: ""}, String: "="},
+		{Str: "maybe", Err: "unknown missing policy [maybe]"},
+	}
+
+	for index, tt := range cases {
+		policy, err := ParseMissingPolicy(tt.Str)
+		if tt.Err != "" {
+			assert.EqualError(t, err, tt.Err, index)
+			continue
+		}
+
+		assert.Nil(t, err, index)
+		assert.Equal(t, tt.Expected, policy, index)
+		assert.Equal(t, tt.String, policy.String(), index)
+	}
+}
+
+func TestMissingOf(t *testing.T) {
+	f := &factory{missing: make(map[string]MissingPolicy)}
+	assert.Equal(t, MissingPolicy{}, f.MissingOf("version"))
+
+	f.SetMissing("", MissingPolicy{Action: MissingFalse})
+	f.SetMissing("data.", MissingPolicy{Action: MissingUnknown})
+	f.SetMissing("data.age", MissingPolicy{Action: MissingDefault, Default: 0})
+	assert.Equal(t, MissingPolicy{Action: MissingFalse}, f.MissingOf("version"))
+	assert.Equal(t, MissingPolicy{Action: MissingUnknown}, f.MissingOf("data.name"))
+	assert.Equal(t, MissingPolicy{Action: MissingDefault, Default: 0}, f.MissingOf("data.age"))
+}
@@ -2,7 +2,6 @@ package variables
 
 import (
 	"context"
-	"errors"
 	"reflect"
 	"testing"
 
@@ -24,7 +23,7 @@ func TestPlatform(t *testing.T) {
 		// err
 		{
 			ctx: ctx,
-			err: errors.New("platform not found in context"),
+			err: variables.Missing("platform not found in context"),
 		},
 		// success
 		{
@@ -5,13 +5,14 @@ import (
 	"math/rand"
 
 	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/variables"
 )
 
 const Name = "rand"
 
 func init() {
-	variables.Register(variables.NewSimpleVariable(&Rand{}))
+	variables.Register(variables.NewSimpleVariable(&Rand{}, variables.WithType(types.TypeNumber)))
 }
 
 // Rand 随机返回1-100之间的值[1,100]
@@ -2,17 +2,17 @@ package referer
 
 import (
 	"context"
-	"errors"
 
 	"github.com/airunny/filter/cache"
 	filterContext "github.com/airunny/filter/context"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/variables"
 )
 
 const Name = "referer"
 
 func init() {
-	variables.Register(variables.NewSimpleVariable(&Referer{}))
+	variables.Register(variables.NewSimpleVariable(&Referer{}, variables.WithType(types.TypeString)))
 }
 
 // Referer referer
@@ -23,7 +23,7 @@ func (s *Referer) Cacheable() bool { return true }
 func (s *Referer) Value(ctx context.Context, _ interface{}, _ *cache.Cache) (interface{}, error) {
 	value, ok := filterContext.FromReferer(ctx)
 	if !ok {
-		return nil, errors.New("referer not found in context")
+		return nil, variables.Missing("referer not found in context")
 	}
 	return value, nil
 }
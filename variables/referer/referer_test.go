@@ -2,7 +2,6 @@ package referer
 
 import (
 	"context"
-	"errors"
 	"reflect"
 	"testing"
 
@@ -24,7 +23,7 @@ func TestReferer(t *testing.T) {
 		// err
 		{
 			ctx: ctx,
-			err: errors.New("referer not found in context"),
+			err: variables.Missing("referer not found in context"),
 		},
 		// success
 		{
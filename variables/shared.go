@@ -0,0 +1,137 @@
+package variables
+
+import (
+	"context"
+	"strings"
+
+	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/types"
+)
+
+// SharedCacheable 可选实现，声明取值只依赖 Depends 中的变量（例如 uid、ip），通过 Share 开启共享缓存时使用
+type SharedCacheable interface {
+	Depends() []string
+}
+
+type sharedConfig struct {
+	shared  *cache.Shared
+	depends []string
+}
+
+// Share 让名字为 name（以.结尾时为前缀，例如 freq.）的变量在多个请求之间共享取值，需要在构建过滤器之前调用。
+// 共享缓存的key由变量名以及 depends 中变量的取值组成，depends 为空时使用变量实现的 SharedCacheable，
+// 两者都没有时不共享，避免所有请求使用同一个取值，例如：
+//
+//	variables.Share("freq.", cache.NewShared(cache.WithTTL(time.Minute), cache.WithSize(100000)), "uid")
+func Share(name string, shared *cache.Shared, depends ...string) {
+	defaultFactory.Share(name, shared, depends...)
+}
+
+func (s *factory) Share(name string, shared *cache.Shared, depends ...string) {
+	s.Lock()
+	defer s.Unlock()
+
+	if shared == nil {
+		delete(s.shared, name)
+		return
+	}
+	s.shared[name] = sharedConfig{shared: shared, depends: depends}
+}
+
+// withShared 开启了共享缓存的变量使用 Shared 包装
+func (s *factory) withShared(name string, v Variable) Variable {
+	if v == nil || len(s.shared) == 0 {
+		return v
+	}
+
+	cnf, ok := s.shared[name]
+	if !ok {
+		if index := strings.Index(name, "."); index >= 0 {
+			cnf, ok = s.shared[name[:index+1]]
+		}
+	}
+
+	if !ok {
+		return v
+	}
+
+	depends := cnf.depends
+	if len(depends) == 0 {
+		if cacheable, ok := v.(SharedCacheable); ok {
+			depends = cacheable.Depends()
+		}
+	}
+
+	// 没有依赖的变量时key只有变量名，所有请求会共享同一个取值
+	if len(depends) == 0 {
+		return v
+	}
+	return s.newShared(v, cnf.shared, depends...)
+}
+
+// SharedVariable 在多个请求之间共享取值的变量
+type SharedVariable struct {
+	Variable
+	shared  *cache.Shared
+	depends []Variable
+	invalid bool // 没有依赖的变量或者依赖的变量不存在，不使用共享缓存
+}
+
+// NewShared depends 为空时不使用共享缓存，每次都直接取值
+func NewShared(v Variable, shared *cache.Shared, depends ...string) *SharedVariable {
+	return defaultFactory.newShared(v, shared, depends...)
+}
+
+func (s *factory) newShared(v Variable, shared *cache.Shared, depends ...string) *SharedVariable {
+	variable := &SharedVariable{
+		Variable: v,
+		shared:   shared,
+		invalid:  len(depends) == 0,
+	}
+
+	// 依赖的变量不使用共享缓存
+	for _, name := range depends {
+		depend, ok := s.build(name)
+		if !ok || depend == nil {
+			variable.invalid = true
+			break
+		}
+		variable.depends = append(variable.depends, depend)
+	}
+	return variable
+}
+
+func (s *SharedVariable) Unwrap() Variable   { return s.Variable }
+func (s *SharedVariable) Cost() int          { return Cost(s.Variable) }
+func (s *SharedVariable) Scope() cache.Scope { return Scope(s.Variable) }
+
+func (s *SharedVariable) Value(ctx context.Context, data interface{}, c *cache.Cache) (interface{}, error) {
+	key, ok := s.key(ctx, data, c)
+	if !ok {
+		return s.Variable.Value(ctx, data, c)
+	}
+
+	return s.shared.Load(key, func() (interface{}, error) {
+		return s.Variable.Value(ctx, data, c)
+	})
+}
+
+// key 变量名以及依赖的变量的取值，依赖的变量取值失败时不使用共享缓存
+func (s *SharedVariable) key(ctx context.Context, data interface{}, c *cache.Cache) (string, bool) {
+	if s.invalid {
+		return "", false
+	}
+
+	var builder strings.Builder
+	builder.WriteString(s.Name())
+	for _, depend := range s.depends {
+		value, err := GetValue(ctx, depend, data, c)
+		if err != nil {
+			return "", false
+		}
+
+		builder.WriteByte(0)
+		builder.WriteString(types.GetString(value))
+	}
+	return builder.String(), true
+}
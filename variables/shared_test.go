@@ -0,0 +1,130 @@
+package variables
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/airunny/filter/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+type countVariable struct {
+	name  string
+	calls int
+}
+
+func (s *countVariable) Name() string      { return s.name }
+func (s *countVariable) Cacheable() bool   { return false }
+func (s *countVariable) Depends() []string { return []string{"shared_user"} }
+func (s *countVariable) Value(_ context.Context, data interface{}, _ *cache.Cache) (interface{}, error) {
+	s.calls++
+	if data == nil {
+		return nil, errors.New("empty data")
+	}
+	return data, nil
+}
+
+type countBuilder struct {
+	variables map[string]*countVariable
+}
+
+func (s *countBuilder) Name() string { return "shared_count." }
+func (s *countBuilder) Build(name string) Variable {
+	if s.variables[name] == nil {
+		s.variables[name] = &countVariable{name: name}
+	}
+	return s.variables[name]
+}
+
+type userKey struct{}
+
+type userVariable struct{}
+
+func (userVariable) Name() string    { return "shared_user" }
+func (userVariable) Cacheable() bool { return true }
+func (userVariable) Value(ctx context.Context, _ interface{}, _ *cache.Cache) (interface{}, error) {
+	user, ok := ctx.Value(userKey{}).(string)
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+// plainVariable 没有实现 SharedCacheable
+type plainVariable struct{}
+
+func (plainVariable) Name() string    { return "shared_plain" }
+func (plainVariable) Cacheable() bool { return false }
+func (plainVariable) Value(context.Context, interface{}, *cache.Cache) (interface{}, error) {
+	return 1, nil
+}
+
+func TestShare(t *testing.T) {
+	f := &factory{
+		builder: make(map[string]Builder),
+		shared:  make(map[string]sharedConfig),
+	}
+	builder := &countBuilder{variables: make(map[string]*countVariable)}
+	f.Register(builder)
+	f.Register(NewSimpleVariable(userVariable{}))
+
+	shared := cache.NewShared()
+	f.Share("shared_count.", shared)
+
+	v, ok := f.Get("shared_count.a")
+	assert.True(t, ok)
+	assert.IsType(t, &SharedVariable{}, v)
+
+	var (
+		alice = context.WithValue(context.Background(), userKey{}, "alice")
+		bob   = context.WithValue(context.Background(), userKey{}, "bob")
+	)
+	for i := 0; i < 3; i++ {
+		value, err := GetValue(alice, v, "a", cache.NewCache())
+		assert.Nil(t, err)
+		assert.Equal(t, "a", value)
+	}
+	assert.Equal(t, 1, builder.variables["shared_count.a"].calls)
+
+	// 依赖的变量取值不同时分别缓存
+	value, err := GetValue(bob, v, "b", cache.NewCache())
+	assert.Nil(t, err)
+	assert.Equal(t, "b", value)
+	assert.Equal(t, 2, builder.variables["shared_count.a"].calls)
+	assert.Equal(t, 2, shared.Len())
+
+	// 依赖的变量取值失败时不使用共享缓存，错误不缓存
+	_, err = GetValue(context.Background(), v, nil, cache.NewCache())
+	assert.Equal(t, "empty data", err.Error())
+	_, err = GetValue(bob, v, nil, cache.NewCache())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, builder.variables["shared_count.a"].calls)
+
+	// 显式指定依赖，依赖不存在时不使用共享缓存
+	f.Share("shared_count.b", shared, "not_exists")
+	v, _ = f.Get("shared_count.b")
+	for i := 0; i < 2; i++ {
+		_, err = GetValue(alice, v, "b", cache.NewCache())
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 2, builder.variables["shared_count.b"].calls)
+
+	// 没有依赖的变量时不共享
+	f.Register(NewSimpleVariable(plainVariable{}))
+	f.Share("shared_plain", shared)
+	v, _ = f.Get("shared_plain")
+	assert.IsType(t, plainVariable{}, v)
+
+	v = f.newShared(builder.Build("shared_count.c"), shared)
+	for i := 0; i < 2; i++ {
+		_, err = GetValue(alice, v, "c", cache.NewCache())
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 2, builder.variables["shared_count.c"].calls)
+
+	// 关闭共享缓存
+	f.Share("shared_count.", nil)
+	v, _ = f.Get("shared_count.a")
+	assert.IsType(t, &countVariable{}, v)
+}
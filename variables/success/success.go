@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/variables"
 )
 
@@ -13,7 +14,7 @@ const (
 )
 
 func init() {
-	variables.Register(variables.NewSimpleVariable(&Success{}))
+	variables.Register(variables.NewSimpleVariable(&Success{}, variables.WithType(types.TypeNumber)))
 }
 
 // Success 永远返回1
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/airunny/filter/cache"
+	filterContext "github.com/airunny/filter/context"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/variables"
 )
 
@@ -26,37 +28,37 @@ const (
 func init() {
 	variables.Register(variables.NewSimpleVariable(&Time{
 		name: TimestampName,
-	}))
+	}, variables.WithType(types.TypeNumber)))
 	variables.Register(variables.NewSimpleVariable(&Time{
 		name: TsSimpleName,
-	}))
+	}, variables.WithType(types.TypeNumber)))
 	variables.Register(variables.NewSimpleVariable(&Time{
 		name: SecondName,
-	}))
+	}, variables.WithType(types.TypeNumber)))
 	variables.Register(variables.NewSimpleVariable(&Time{
 		name: MinuteName,
-	}))
+	}, variables.WithType(types.TypeNumber)))
 	variables.Register(variables.NewSimpleVariable(&Time{
 		name: HourName,
-	}))
+	}, variables.WithType(types.TypeNumber)))
 	variables.Register(variables.NewSimpleVariable(&Time{
 		name: DayName,
-	}))
+	}, variables.WithType(types.TypeNumber)))
 	variables.Register(variables.NewSimpleVariable(&Time{
 		name: MonthName,
-	}))
+	}, variables.WithType(types.TypeNumber)))
 	variables.Register(variables.NewSimpleVariable(&Time{
 		name: YearName,
-	}))
+	}, variables.WithType(types.TypeNumber)))
 	variables.Register(variables.NewSimpleVariable(&Time{
 		name: WdayName,
-	}))
+	}, variables.WithType(types.TypeNumber)))
 	variables.Register(variables.NewSimpleVariable(&Time{
 		name: DateName,
-	}))
+	}, variables.WithType(types.TypeString)))
 	variables.Register(variables.NewSimpleVariable(&Time{
 		name: TimeName,
-	}))
+	}, variables.WithType(types.TypeString)))
 
 }
 
@@ -67,12 +69,16 @@ type Time struct {
 
 func (s *Time) Name() string    { return s.name }
 func (s *Time) Cacheable() bool { return false }
-func (s *Time) Value(_ context.Context, _ interface{}, _ *cache.Cache) (interface{}, error) {
+func (s *Time) Value(ctx context.Context, _ interface{}, _ *cache.Cache) (interface{}, error) {
 	var (
 		now   = time.Now()
 		value interface{}
 	)
 
+	if pinned, ok := filterContext.FromNow(ctx); ok {
+		now = pinned
+	}
+
 	switch s.name {
 	case TimestampName:
 		value = now.Unix()
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/airunny/filter/cache"
+	filterContext "github.com/airunny/filter/context"
 	_ "github.com/airunny/filter/location"
 	"github.com/airunny/filter/variables"
 	"github.com/stretchr/testify/assert"
@@ -103,3 +104,29 @@ func TestIP(t *testing.T) {
 		assert.Equal(t, tt.want(), ret, index)
 	}
 }
+
+func TestPinnedNow(t *testing.T) {
+	var (
+		now = time.Date(2023, 12, 31, 23, 59, 58, 0, time.UTC)
+		ctx = filterContext.WithNow(context.Background(), now)
+	)
+
+	cases := map[string]interface{}{
+		HourName:  23,
+		DayName:   31,
+		MonthName: 12,
+		YearName:  2023,
+		WdayName:  0,
+		DateName:  "2023-12-31",
+		TimeName:  "2023-12-31 23:59:58",
+	}
+
+	for name, want := range cases {
+		variable, ok := variables.Get(name)
+		assert.True(t, ok)
+
+		ret, err := variable.Value(ctx, nil, cache.NewCache())
+		assert.Nil(t, err)
+		assert.Equal(t, want, ret, name)
+	}
+}
@@ -2,17 +2,17 @@ package ua
 
 import (
 	"context"
-	"errors"
 
 	"github.com/airunny/filter/cache"
 	filterContext "github.com/airunny/filter/context"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/variables"
 )
 
 const Name = "ua"
 
 func init() {
-	variables.Register(variables.NewSimpleVariable(&UserAgent{}))
+	variables.Register(variables.NewSimpleVariable(&UserAgent{}, variables.WithType(types.TypeString)))
 }
 
 // UserAgent 用户代理信息
@@ -23,7 +23,7 @@ func (s *UserAgent) Cacheable() bool { return true }
 func (s *UserAgent) Value(ctx context.Context, _ interface{}, _ *cache.Cache) (interface{}, error) {
 	ua, ok := filterContext.FromUA(ctx)
 	if !ok {
-		return nil, errors.New("ua not found in context")
+		return nil, variables.Missing("ua not found in context")
 	}
 	return ua, nil
 }
@@ -2,7 +2,6 @@ package ua
 
 import (
 	"context"
-	"errors"
 	"reflect"
 	"testing"
 
@@ -24,7 +23,7 @@ func TestChannel(t *testing.T) {
 		// err
 		{
 			ctx: ctx,
-			err: errors.New("ua not found in context"),
+			err: variables.Missing("ua not found in context"),
 		},
 		// success
 		{
@@ -2,7 +2,6 @@ package uid
 
 import (
 	"context"
-	"errors"
 
 	"github.com/airunny/filter/cache"
 	filterContext "github.com/airunny/filter/context"
@@ -23,7 +22,7 @@ func (s *UID) Cacheable() bool { return true }
 func (s *UID) Value(ctx context.Context, _ interface{}, _ *cache.Cache) (interface{}, error) {
 	uid, ok := filterContext.FromUserId(ctx)
 	if !ok {
-		return nil, errors.New("uid not found in context")
+		return nil, variables.Missing("uid not found in context")
 	}
 	return uid, nil
 }
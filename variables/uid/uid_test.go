@@ -2,7 +2,6 @@ package uid
 
 import (
 	"context"
-	"errors"
 	"reflect"
 	"testing"
 
@@ -24,7 +23,7 @@ func TestUID(t *testing.T) {
 		// err
 		{
 			ctx: ctx,
-			err: errors.New("uid not found in context"),
+			err: variables.Missing("uid not found in context"),
 		},
 		// success
 		{
@@ -2,7 +2,6 @@ package user_tag
 
 import (
 	"context"
-	"errors"
 
 	"github.com/airunny/filter/cache"
 	filterContext "github.com/airunny/filter/context"
@@ -22,7 +21,7 @@ func (s *UserTag) Cacheable() bool { return true }
 func (s *UserTag) Value(ctx context.Context, _ interface{}, _ *cache.Cache) (interface{}, error) {
 	value, ok := filterContext.FromUserTag(ctx)
 	if !ok {
-		return nil, errors.New("user_tag not found in context")
+		return nil, variables.Missing("user_tag not found in context")
 	}
 	return value, nil
 }
@@ -2,7 +2,6 @@ package user_tag
 
 import (
 	"context"
-	"errors"
 	"reflect"
 	"testing"
 
@@ -24,7 +23,7 @@ func TestUserTag(t *testing.T) {
 		// err
 		{
 			ctx: ctx,
-			err: errors.New("user_tag not found in context"),
+			err: variables.Missing("user_tag not found in context"),
 		},
 		// success
 		{
@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/types"
 )
 
 type Variable interface {
@@ -17,11 +19,49 @@ type Variable interface {
 	Value(ctx context.Context, data interface{}, cache *cache.Cache) (interface{}, error)
 }
 
+// 变量取值的代价，用于按照代价重排条件
+const (
+	CostCheap     = 1   // 直接从上下文或者data中取值
+	CostModerate  = 10  // 需要本地计算，例如根据ip解析地区
+	CostExpensive = 100 // 需要访问外部服务，例如频率、计算
+)
+
+// Coster 可选实现，返回变量取值的代价，未实现时为 CostCheap
+type Coster interface {
+	Cost() int
+}
+
+// Cost 变量取值的代价
+func Cost(v Variable) int {
+	if coster, ok := v.(Coster); ok {
+		return coster.Cost()
+	}
+	return CostCheap
+}
+
+// Scoped 可选实现，返回变量缓存的作用域，未实现时为 cache.ScopeRequest
+type Scoped interface {
+	Scope() cache.Scope
+}
+
+// Scope 变量缓存的作用域
+func Scope(v Variable) cache.Scope {
+	if scoped, ok := v.(Scoped); ok {
+		return scoped.Scope()
+	}
+	return cache.ScopeRequest
+}
+
 type Builder interface {
 	Name() string
 	Build(string) Variable
 }
 
+// TypedBuilder 可选实现，构建的变量取值的类型，构建条件时用于检查操作以及比较值，未实现时为 types.TypeUnknown
+type TypedBuilder interface {
+	Type(name string) types.ValueType
+}
+
 type Calculator interface {
 	CalcValue(ctx context.Context, key string) (float64, error)
 }
@@ -38,14 +78,26 @@ type Valuer interface {
 
 var defaultFactory = &factory{
 	builder: make(map[string]Builder),
+	shared:  make(map[string]sharedConfig),
+	missing: make(map[string]MissingPolicy),
 }
 
 type factory struct {
 	builder map[string]Builder
+	shared  map[string]sharedConfig
+	missing map[string]MissingPolicy
 	sync.Mutex
 }
 
 func (s *factory) Get(name string) (Variable, bool) {
+	v, ok := s.build(name)
+	if !ok {
+		return nil, false
+	}
+	return s.withShared(name, v), true
+}
+
+func (s *factory) build(name string) (Variable, bool) {
 	if builder, ok := s.builder[name]; ok {
 		return builder.Build(name), true
 	}
@@ -61,6 +113,18 @@ func (s *factory) Get(name string) (Variable, bool) {
 	return nil, false
 }
 
+func (s *factory) TypeOf(name string) types.ValueType {
+	builder, ok := s.builder[name]
+	if !ok {
+		builder, ok = s.builder[strings.Split(name, ".")[0]+"."]
+	}
+
+	if typed, ok := builder.(TypedBuilder); ok {
+		return typed.Type(name)
+	}
+	return types.TypeUnknown
+}
+
 func (s *factory) Register(builder Builder) {
 	if builder == nil {
 		panic("cannot register a nil variable builder")
@@ -77,14 +141,35 @@ func (s *factory) Register(builder Builder) {
 	s.builder[builder.Name()] = builder
 }
 
+func (s *factory) Names() []string {
+	s.Lock()
+	defer s.Unlock()
+	names := make([]string, 0, len(s.builder))
+	for name := range s.builder {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func Register(builder Builder) {
 	defaultFactory.Register(builder)
 }
 
+// Names 返回所有已注册的变量名，以.结尾的为变量前缀（例如 data.）
+func Names() []string {
+	return defaultFactory.Names()
+}
+
 func Get(name string) (Variable, bool) {
 	return defaultFactory.Get(name)
 }
 
+// TypeOf 变量取值的类型，参见 TypedBuilder
+func TypeOf(name string) types.ValueType {
+	return defaultFactory.TypeOf(name)
+}
+
 func Print() {
 	fmt.Printf("Variables: \n")
 	for name, builder := range defaultFactory.builder {
@@ -96,8 +181,9 @@ func Print() {
 // ============================== Builder ==========================
 
 type SimpleBuilder struct {
-	name     string
-	variable Variable
+	name      string
+	variable  Variable
+	valueType types.ValueType
 }
 
 func NewSimpleVariable(variable Variable, opts ...Option) *SimpleBuilder {
@@ -107,8 +193,9 @@ func NewSimpleVariable(variable Variable, opts ...Option) *SimpleBuilder {
 	}
 
 	return &SimpleBuilder{
-		name:     o.name,
-		variable: variable,
+		name:      o.name,
+		variable:  variable,
+		valueType: o.valueType,
 	}
 }
 
@@ -123,8 +210,13 @@ func (s *SimpleBuilder) Build(_ string) Variable {
 	return s.variable
 }
 
+func (s *SimpleBuilder) Type(_ string) types.ValueType {
+	return s.valueType
+}
+
 type options struct {
-	name string
+	name      string
+	valueType types.ValueType
 }
 
 type Option func(o *options)
@@ -135,13 +227,21 @@ func WithName(name string) Option {
 	}
 }
 
+// WithType 变量取值的类型，参见 TypedBuilder
+func WithType(valueType types.ValueType) Option {
+	return func(o *options) {
+		o.valueType = valueType
+	}
+}
+
 func GetValue(ctx context.Context, v Variable, data interface{}, cache *cache.Cache) (interface{}, error) {
 	if v == nil {
 		return nil, errors.New("empty variable")
 	}
 
-	if v.Cacheable() {
-		if value, ok := cache.Get(v.Name()); ok {
+	cacheable := v.Cacheable()
+	if cacheable {
+		if value, ok := cache.GetScoped(Scope(v), v.Name()); ok {
 			return value, nil
 		}
 	}
@@ -151,8 +251,8 @@ func GetValue(ctx context.Context, v Variable, data interface{}, cache *cache.Ca
 		return nil, err
 	}
 
-	if v.Cacheable() {
-		cache.Set(v.Name(), value)
+	if cacheable {
+		cache.SetScoped(Scope(v), v.Name(), value)
 	}
 	return value, nil
 }
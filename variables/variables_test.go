@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/airunny/filter/cache"
+	"github.com/airunny/filter/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -58,6 +59,39 @@ func TestRegister(t *testing.T) {
 	if got != op {
 		t.Fatalf("Register(%v) want %v got %v", op, op, got)
 	}
+	assert.Equal(t, []string{"mock"}, Names())
+}
+
+type costVariable struct {
+	mockVariable
+	cost int
+}
+
+func (m costVariable) Cost() int {
+	return m.cost
+}
+
+func TestCost(t *testing.T) {
+	assert.Equal(t, CostCheap, Cost(&mockVariable{name: "cheap"}))
+	assert.Equal(t, CostExpensive, Cost(&costVariable{cost: CostExpensive}))
+}
+
+type typedBuilder struct{}
+
+func (typedBuilder) Name() string                { return "typed." }
+func (typedBuilder) Build(name string) Variable  { return &mockVariable{name: name} }
+func (typedBuilder) Type(string) types.ValueType { return types.TypeNumber }
+
+func TestTypeOf(t *testing.T) {
+	f := &factory{builder: make(map[string]Builder)}
+	f.Register(NewSimpleVariable(&mockVariable{name: "version"}, WithType(types.TypeVersion)))
+	f.Register(NewSimpleVariable(&mockVariable{name: "mock"}))
+	f.Register(typedBuilder{})
+
+	assert.Equal(t, types.TypeVersion, f.TypeOf("version"))
+	assert.Equal(t, types.TypeUnknown, f.TypeOf("mock"))
+	assert.Equal(t, types.TypeNumber, f.TypeOf("typed.a"))
+	assert.Equal(t, types.TypeUnknown, f.TypeOf("golang"))
 }
 
 type PanicTestFunc func()
@@ -2,17 +2,17 @@ package version
 
 import (
 	"context"
-	"errors"
 
 	"github.com/airunny/filter/cache"
 	filterContext "github.com/airunny/filter/context"
+	"github.com/airunny/filter/types"
 	"github.com/airunny/filter/variables"
 )
 
 const Name = "version"
 
 func init() {
-	variables.Register(variables.NewSimpleVariable(&Version{}))
+	variables.Register(variables.NewSimpleVariable(&Version{}, variables.WithType(types.TypeVersion)))
 }
 
 // Version 应用版本
@@ -23,7 +23,7 @@ func (s *Version) Cacheable() bool { return true }
 func (s *Version) Value(ctx context.Context, _ interface{}, _ *cache.Cache) (interface{}, error) {
 	version, ok := filterContext.FromVersion(ctx)
 	if !ok {
-		return nil, errors.New("version not found in context")
+		return nil, variables.Missing("version not found in context")
 	}
 	return version, nil
 }
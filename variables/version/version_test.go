@@ -2,7 +2,6 @@ package version
 
 import (
 	"context"
-	"errors"
 	"reflect"
 	"testing"
 
@@ -24,7 +23,7 @@ func TestDevice(t *testing.T) {
 		// err
 		{
 			ctx: ctx,
-			err: errors.New("version not found in context"),
+			err: variables.Missing("version not found in context"),
 		},
 		// success
 		{
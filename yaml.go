@@ -0,0 +1,236 @@
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NewFilterFromYAML 使用yaml格式的配置创建Filter，条件数组的格式跟json配置完全一致
+//
+//	filters:
+//	  - id: "1"
+//	    priority: 1
+//	    filter:
+//	      - [city, in, "上海,天津,北京"]
+//	      - [name, "=", golang]
+//	batch: false
+func NewFilterFromYAML(ctx context.Context, yamlStr string, reporter Reporter, opts ...Option) (*Filter, error) {
+	f := newFilter(reporter, opts...)
+	err := f.RefreshYAML(ctx, yamlStr)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *Filter) RefreshYAML(ctx context.Context, yamlStr string) error {
+	cnf, lines, err := decodeYAMLConfig(yamlStr)
+	if err != nil {
+		return err
+	}
+
+	batch, err := buildBatchFilterWithLines(s.buildContext(ctx), cnf, lines)
+	if err != nil {
+		return err
+	}
+
+	s.store(batch)
+	return nil
+}
+
+// ParseConfig 根据内容自动识别json或者yaml格式的配置
+func ParseConfig(content string) (*Config, error) {
+	if strings.HasPrefix(strings.TrimSpace(content), "{") {
+		var cnf Config
+		err := json.NewDecoder(strings.NewReader(content)).Decode(&cnf)
+		if err != nil {
+			return nil, err
+		}
+		return &cnf, nil
+	}
+
+	cnf, _, err := decodeYAMLConfig(content)
+	return cnf, err
+}
+
+// decodeYAMLConfig 返回解析之后的配置以及每个filter、分群所在的行号
+func decodeYAMLConfig(content string) (*Config, *configLines, error) {
+	var root yaml.Node
+	err := yaml.Unmarshal([]byte(content), &root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cnf := &Config{}
+	if len(root.Content) == 0 {
+		return cnf, nil, nil
+	}
+
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return nil, nil, yamlErrorf(doc, "config must be a mapping")
+	}
+
+	lines := &configLines{}
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		switch strings.ToLower(key.Value) {
+		case "filters":
+			lines.filters, err = decodeYAMLFilters(cnf, value)
+		case "batch":
+			err = decodeYAMLScalar(value, &cnf.Batch)
+		case "optimize":
+			err = decodeYAMLScalar(value, &cnf.Optimize)
+		case "segments":
+			cnf.Segments, lines.segments, err = decodeYAMLSegments(value)
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return cnf, lines, nil
+}
+
+func decodeYAMLFilters(cnf *Config, node *yaml.Node) ([]int, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, yamlErrorf(node, "filters must be a sequence")
+	}
+
+	lines := make([]int, 0, len(node.Content))
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode {
+			return nil, yamlErrorf(item, "filter must be a mapping")
+		}
+
+		var (
+			filter FilterConfig
+			line   = item.Line
+			err    error
+		)
+
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			key, value := item.Content[i], item.Content[i+1]
+			switch strings.ToLower(key.Value) {
+			case "id":
+				err = decodeYAMLScalar(value, &filter.Id)
+			case "weight":
+				err = decodeYAMLScalar(value, &filter.Weight)
+			case "priority":
+				err = decodeYAMLScalar(value, &filter.Priority)
+			case "filter":
+				line = value.Line
+				var items interface{}
+				items, err = yamlValue(value)
+				if err == nil {
+					var ok bool
+					filter.Filter, ok = items.([]interface{})
+					if !ok && items != nil {
+						err = yamlErrorf(value, "filter must be a sequence")
+					}
+				}
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		cnf.Filters = append(cnf.Filters, filter)
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// decodeYAMLSegments 返回解析之后的分群以及每个分群所在的行号
+func decodeYAMLSegments(node *yaml.Node) (map[string][]interface{}, map[string]int, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, nil, yamlErrorf(node, "segments must be a mapping")
+	}
+
+	var (
+		segments = make(map[string][]interface{}, len(node.Content)/2)
+		lines    = make(map[string]int, len(node.Content)/2)
+	)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		lines[key.Value] = value.Line
+		if value.Kind == yaml.AliasNode {
+			value = value.Alias
+		}
+
+		if value.Kind != yaml.SequenceNode {
+			return nil, nil, yamlErrorf(value, "segment [%s] must be a sequence", key.Value)
+		}
+
+		items, err := yamlValue(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		segments[key.Value] = items.([]interface{})
+	}
+	return segments, lines, nil
+}
+
+func decodeYAMLScalar(node *yaml.Node, out interface{}) error {
+	if node.Kind != yaml.ScalarNode {
+		return yamlErrorf(node, "expected a scalar value")
+	}
+
+	err := node.Decode(out)
+	if err != nil {
+		return yamlErrorf(node, "%v", strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	return nil
+}
+
+// yamlValue 转化为跟json解析之后相同的结构，数字统一为float64
+func yamlValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.SequenceNode:
+		values := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case yaml.MappingNode:
+		values := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := yamlValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			values[node.Content[i].Value] = value
+		}
+		return values, nil
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!int", "!!float":
+			var value float64
+			err := decodeYAMLScalar(node, &value)
+			return value, err
+		case "!!bool":
+			var value bool
+			err := decodeYAMLScalar(node, &value)
+			return value, err
+		case "!!null":
+			return nil, nil
+		}
+		return node.Value, nil
+	}
+	return nil, yamlErrorf(node, "unsupported yaml node")
+}
+
+func yamlErrorf(node *yaml.Node, format string, args ...interface{}) error {
+	return fmt.Errorf("yaml: line %d: %s", node.Line, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,219 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	filterContext "github.com/airunny/filter/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFilterFromYAML(t *testing.T) {
+	cases := []struct {
+		YamlStr      string
+		BuildErr     error
+		Ctx          context.Context
+		ExpectedData interface{}
+		FilterIds    []string
+	}{
+		// err
+		{
+			YamlStr:  "filters: [",
+			BuildErr: errors.New("yaml: line 1: did not find expected node content"),
+		},
+		{
+			YamlStr:  "- 1",
+			BuildErr: errors.New("yaml: line 1: config must be a mapping"),
+		},
+		{
+			YamlStr: `
+filters:
+  - id: "1"
+    weight: one
+`,
+			BuildErr: errors.New("yaml: line 4: unmarshal errors:\n  line 4: cannot unmarshal !!str `one` into int64"),
+		},
+		{
+			YamlStr: `
+filters:
+  - id: "1"
+    filter:
+      - [success, "=", 1]
+  - id: "2"
+    filter:
+      - [golang, "=", 1]
+      - [name, "=", golang]
+`,
+			BuildErr: errors.New("line 5: filter [1]: filter must contain at least two items"),
+		},
+		{
+			YamlStr: `
+filters:
+  - id: "1"
+    filter:
+      - [success, "=", 1]
+      - [name, "=", golang]
+  - id: "2"
+    filter:
+      - [golang, "=", 1]
+      - [name, "=", golang]
+`,
+			BuildErr: errors.New("line 9: filter [2]: condition not exists variable [golang]"),
+		},
+		{
+			YamlStr: `
+segments:
+  ios: [[platform, "=", ios]]
+  pc: pc
+filters:
+  - id: "1"
+    filter:
+      - [segment, "=", ios]
+      - [name, "=", ios]
+`,
+			BuildErr: errors.New("yaml: line 4: segment [pc] must be a sequence"),
+		},
+		{
+			YamlStr: `
+segments:
+  ios: [[platform, "=", ios]]
+  vip:
+    - [segment, "=", ios]
+    - [golang, "=", 1]
+filters:
+  - id: "1"
+    filter:
+      - [segment, "=", vip]
+      - [name, "=", ios]
+`,
+			BuildErr: errors.New("line 5: segment [vip]: condition not exists variable [golang]"),
+		},
+		{
+			YamlStr: `
+segments:
+  android: [[segment, "=", vip]]
+  vip:
+    - [golang, "=", 1]
+filters:
+  - id: "1"
+    filter:
+      - [segment, "=", android]
+      - [name, "=", android]
+`,
+			BuildErr: errors.New("line 5: segment [android]: segment [vip]: condition not exists variable [golang]"),
+		},
+		// success
+		{
+			YamlStr: `
+filters:
+  - id: 1
+    priority: 1
+    filter:
+      - [platform, "=", ios]
+      - - [name, "=", ios]
+        - [age, "=", 10]
+  - id: "2"
+    priority: 2
+    filter:
+      - - or
+        - "=>"
+        - - [platform, in, "android,pc"]
+          - [hour, between, [0, 23]]
+      - [name, "=", default]
+batch: false
+`,
+			Ctx: filterContext.WithPlatform(context.Background(), "ios"),
+			ExpectedData: map[string]interface{}{
+				"name": "ios",
+				"age":  float64(10),
+			},
+			FilterIds: []string{"1"},
+		},
+		{
+			YamlStr: `
+filters:
+  - id: "1"
+    priority: 1
+    filter:
+      - [platform, "=", ios]
+      - [name, "=", ios]
+  - id: "2"
+    priority: 2
+    filter:
+      - [success, "=", 1]
+      - [name, "=", default]
+batch: true
+`,
+			Ctx: filterContext.WithPlatform(context.Background(), "ios"),
+			ExpectedData: map[string]interface{}{
+				"name": "default",
+			},
+			FilterIds: []string{"1", "2"},
+		},
+		{
+			YamlStr: `
+segments:
+  ios: &ios
+    - [platform, "=", ios]
+  mobile: *ios
+filters:
+  - id: "1"
+    filter:
+      - [segment, "=", mobile]
+      - [name, "=", ios]
+`,
+			Ctx: filterContext.WithPlatform(context.Background(), "ios"),
+			ExpectedData: map[string]interface{}{
+				"name": "ios",
+			},
+			FilterIds: []string{"1"},
+		},
+	}
+
+	for index, tt := range cases {
+		var filterIds []string
+		f, err := NewFilterFromYAML(context.Background(), tt.YamlStr, ReportFunc(func(ctx context.Context, data interface{}, ids []string) {
+			filterIds = ids
+		}))
+		if tt.BuildErr != nil {
+			assert.Equal(t, tt.BuildErr, errors.New(err.Error()), index)
+			continue
+		}
+		assert.Nil(t, err, index)
+
+		data, err := f.Execute(tt.Ctx, nil)
+		assert.Nil(t, err, index)
+		assert.Equal(t, tt.ExpectedData, data, index)
+		assert.Equal(t, tt.FilterIds, filterIds, index)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	jsonStr := `{"filters":[{"id":"1","weight":1,"priority":2,"filter":[["hour","between","10,19"],["name","=","golang"]]}],"batch":true}`
+	yamlStr := `
+filters:
+  - id: "1"
+    weight: 1
+    priority: 2
+    filter:
+      - [hour, between, "10,19"]
+      - [name, "=", golang]
+batch: true
+`
+
+	fromJson, err := ParseConfig(jsonStr)
+	assert.Nil(t, err)
+	fromYaml, err := ParseConfig(yamlStr)
+	assert.Nil(t, err)
+	assert.Equal(t, fromJson, fromYaml)
+
+	content, err := fromYaml.JSON()
+	assert.Nil(t, err)
+	assert.Equal(t, jsonStr, content)
+
+	f, err := NewFilter(context.Background(), jsonStr, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, f.RefreshYAML(context.Background(), yamlStr))
+	assert.EqualError(t, f.RefreshYAML(context.Background(), "filters: 1"), "yaml: line 1: filters must be a sequence")
+}